@@ -0,0 +1,111 @@
+// Package agent is the worker side of the coordinator/agent protocol
+// described in proto/loadgen.proto: it runs one coordinator-assigned
+// slice of a distributed test by handing the assignment straight to the
+// existing internal/generator.Generator, unchanged, and reconnects with
+// exponential backoff when its stream to the coordinator drops.
+package agent
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"loadgen/internal/cleanup"
+	"loadgen/internal/config"
+	"loadgen/internal/generator"
+)
+
+// UserRange mirrors coordinator.UserRange; duplicated here rather than
+// imported so this package doesn't have to depend on coordinator's
+// assignment internals, just the shape of a range.
+type UserRange struct {
+	Start int
+	End   int
+}
+
+// Count returns how many user ids this range covers.
+func (r UserRange) Count() int { return r.End - r.Start }
+
+// StartTestCommand is the Go-side counterpart of loadgen.proto's
+// StartTest message: everything generator.New's users/duration/ramp/
+// workloadProfile parameters need, addressed to this agent's assigned
+// UserRange instead of the standalone [0, users) range a CLI- or
+// web-driven run would use.
+type StartTestCommand struct {
+	TestID          string
+	Config          *config.Config
+	UserRange       UserRange
+	Duration        time.Duration
+	Ramp            string
+	WorkloadProfile string
+	// Seed is the base RNG seed this agent's users derive theirs from
+	// (see generator.New's seed parameter); the coordinator sends the
+	// same Seed to every agent in a test so re-sharding a disconnected
+	// agent's range onto another agent doesn't change that range's
+	// users' randomness.
+	Seed int64
+	// Shard is this agent's position among the test's agents, carried
+	// through purely for log/metric attribution - UserRange is what
+	// actually partitions work.
+	Shard int32
+}
+
+// Agent runs one coordinator-assigned slice of a distributed test.
+type Agent struct {
+	ID      string
+	cleanup *cleanup.Cleanup
+}
+
+// New returns an Agent identified by id, tracking created/deleted test
+// users through cl the same way a standalone run does.
+func New(id string, cl *cleanup.Cleanup) *Agent {
+	return &Agent{ID: id, cleanup: cl}
+}
+
+// RunTest executes cmd by handing it straight to generator.New/Run -
+// the whole point of this package is that Generator needs no changes to
+// run as one slice of a distributed test.
+func (a *Agent) RunTest(ctx context.Context, cmd StartTestCommand) {
+	gen := generator.New(ctx, cmd.Config, cmd.UserRange.Count(), cmd.Duration, cmd.Ramp, cmd.WorkloadProfile, a.cleanup, cmd.UserRange.Start, cmd.Seed)
+	gen.Run(ctx)
+}
+
+// Reconnect calls dial in a loop with exponential backoff and jitter
+// (this repo doesn't vendor avast/retry-go, so the same shape is
+// hand-rolled here) until it succeeds or ctx is cancelled, so a
+// coordinator restart pauses an agent's stream instead of aborting its
+// in-flight test. baseDelay is the first retry's delay; it doubles each
+// attempt up to maxDelay.
+func Reconnect(ctx context.Context, baseDelay, maxDelay time.Duration, dial func(context.Context) error) error {
+	delay := baseDelay
+	for attempt := 1; ; attempt++ {
+		err := dial(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("agent: connect attempt %d failed: %v (retrying in %s)", attempt, err, delay)
+
+		select {
+		case <-time.After(delay + jitter(delay)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// jitter returns a random duration in [0, d/2), spreading out
+// simultaneous agents' reconnect attempts after a shared coordinator
+// restart so they don't all redial in the same instant.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d) / 2))
+}
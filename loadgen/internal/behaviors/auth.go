@@ -5,18 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"math/rand"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"loadgen/internal/chaos"
 	"loadgen/internal/config"
+	"loadgen/internal/httpx"
+	"loadgen/internal/logging"
 	"loadgen/internal/metrics"
 )
 
 type AuthBehavior struct {
-	baseURL string
+	baseURL atomic.Value // string
 	client  *http.Client
 }
 
@@ -40,49 +43,21 @@ type AuthResponse struct {
 }
 
 func NewAuth(cfg *config.Config) *AuthBehavior {
-	client := &http.Client{Timeout: 10 * time.Second}
-	
-	// Add chaos middleware if configured
-	if cfg.Chaos.ErrorRate > 0 || cfg.Chaos.DelayRate > 0 {
-		chaos := &chaosTransport{
-			base:   client.Transport,
-			config: &cfg.Chaos,
-		}
-		if client.Transport == nil {
-			chaos.base = http.DefaultTransport
-		}
-		client.Transport = chaos
-	}
-	
-	return &AuthBehavior{
-		baseURL: cfg.Services.UserService.BaseURL,
-		client:  client,
-	}
-}
+	client := httpx.NewHTTPClient("user", cfg.Services.UserService, 10*time.Second)
+	transport := chaos.Shared(&cfg.Chaos).WrapTransport("user", client.Transport)
+	client.Transport = otelhttp.NewTransport(transport)
 
-type chaosTransport struct {
-	base   http.RoundTripper
-	config *config.Chaos
+	a := &AuthBehavior{client: client}
+	a.baseURL.Store(cfg.Services.UserService.BaseURL)
+	return a
 }
 
-func (ct *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Random delay injection
-	if rand.Float64() < ct.config.DelayRate {
-		delay := time.Duration(rand.Intn(ct.config.MaxDelayMs)) * time.Millisecond
-		log.Printf("🌪️ Chaos: Adding %v delay to %s", delay, req.URL.Path)
-		time.Sleep(delay)
-	}
-
-	resp, err := ct.base.RoundTrip(req)
-	
-	// Random error injection
-	if err == nil && rand.Float64() < ct.config.ErrorRate {
-		log.Printf("🌪️ Chaos: Injecting 500 error for %s", req.URL.Path)
-		resp.StatusCode = 500
-		resp.Status = "500 Internal Server Error"
-	}
-
-	return resp, err
+// UpdateConfig re-reads this behavior's tunables from a hot-reloaded
+// config, so a config.Watcher subscriber can point it at a different
+// environment without restarting the process. Chaos rates are shared
+// process-wide via chaos.Shared and don't need to be re-applied here.
+func (a *AuthBehavior) UpdateConfig(cfg *config.Config) {
+	a.baseURL.Store(cfg.Services.UserService.BaseURL)
 }
 
 func (a *AuthBehavior) Login(ctx context.Context, username, password string) (string, error) {
@@ -98,28 +73,33 @@ func (a *AuthBehavior) Login(ctx context.Context, username, password string) (st
 	req := LoginRequest{Username: username, Password: password}
 	body, _ := json.Marshal(req)
 
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/api/users/login", bytes.NewBuffer(body))
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", a.baseURL.Load().(string)+"/api/users/login", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := a.client.Do(httpReq)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("user", "login", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("user", "login", "error")...).Inc()
+		logging.Request(ctx, "user", "login", "error", time.Since(start), username, err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("user", "login", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("user", "login", status)...).Inc()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("login failed: %d", resp.StatusCode)
+		err := fmt.Errorf("login failed: %d", resp.StatusCode)
+		logging.Request(ctx, "user", "login", status, time.Since(start), username, err)
+		return "", err
 	}
 
 	var authResp AuthResponse
 	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		logging.Request(ctx, "user", "login", status, time.Since(start), username, err)
 		return "", err
 	}
 
+	logging.Request(ctx, "user", "login", status, time.Since(start), username, nil)
 	return authResp.Token, nil
 }
 
@@ -136,22 +116,26 @@ func (a *AuthBehavior) Register(ctx context.Context, username, email, password s
 	req := RegisterRequest{Username: username, Email: email, Password: password}
 	body, _ := json.Marshal(req)
 
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/api/users/register", bytes.NewBuffer(body))
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", a.baseURL.Load().(string)+"/api/users/register", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := a.client.Do(httpReq)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("user", "register", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("user", "register", "error")...).Inc()
+		logging.Request(ctx, "user", "register", "error", time.Since(start), username, err)
 		return err
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("user", "register", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("user", "register", status)...).Inc()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("register failed: %d", resp.StatusCode)
+		err := fmt.Errorf("register failed: %d", resp.StatusCode)
+		logging.Request(ctx, "user", "register", status, time.Since(start), username, err)
+		return err
 	}
 
+	logging.Request(ctx, "user", "register", status, time.Since(start), username, nil)
 	return nil
 }
@@ -3,22 +3,25 @@ package behaviors
 import (
 	"context"
 	"fmt"
-	"log"
 	"net/http"
-	"net/url"
+	"sync/atomic"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"loadgen/internal/chaos"
 	"loadgen/internal/config"
+	"loadgen/internal/httpx"
+	"loadgen/internal/logging"
 	"loadgen/internal/metrics"
+	"loadgen/internal/socketio"
 )
 
 type ChatBehavior struct {
-	baseURL string
-	conn    *websocket.Conn
-	client  *http.Client
-	token   string
+	baseURL  atomic.Value // string
+	conn     *socketio.Client
+	client   *httpx.Client
+	token    string
 	username string
 }
 
@@ -29,86 +32,64 @@ type ChatMessage struct {
 }
 
 func NewChat(cfg *config.Config) *ChatBehavior {
-	return &ChatBehavior{
-		baseURL: cfg.Services.ChatService.BaseURL,
-		client:  &http.Client{Timeout: 10 * time.Second},
-	}
+	client := httpx.NewHTTPClient("chat", cfg.Services.ChatService, 10*time.Second)
+	transport := chaos.Shared(&cfg.Chaos).WrapTransport("chat", client.Transport)
+	client.Transport = otelhttp.NewTransport(transport)
+
+	c := &ChatBehavior{client: httpx.New("chat", cfg.Services.ChatService.Resilience, client)}
+	c.baseURL.Store(cfg.Services.ChatService.BaseURL)
+	return c
+}
+
+// UpdateConfig re-reads this behavior's tunables from a hot-reloaded
+// config; see AuthBehavior.UpdateConfig for why chaos rates aren't
+// re-applied here.
+func (c *ChatBehavior) UpdateConfig(cfg *config.Config) {
+	c.baseURL.Store(cfg.Services.ChatService.BaseURL)
 }
 
 func (c *ChatBehavior) Connect(ctx context.Context, token string) {
 	c.token = token
-	
+
 	tracer := otel.Tracer("loadgen")
 	ctx, span := tracer.Start(ctx, "chat.connect")
 	defer span.End()
 
-	// Try WebSocket connection with proper Socket.IO handshake
-	u, _ := url.Parse(c.baseURL)
-	u.Scheme = "ws"
-	u.Path = "/socket.io/"
-	u.RawQuery = "EIO=4&transport=websocket"
-
-	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
-	}
-
-	conn, _, err := dialer.DialContext(ctx, u.String(), nil)
+	dialStart := time.Now()
+	sio, err := socketio.Dial(ctx, c.baseURL.Load().(string)+"/socket.io/", socketio.Options{})
 	if err != nil {
-		log.Printf("WebSocket connection failed: %v", err)
+		logging.Request(ctx, "chat", "connect", "error", time.Since(dialStart), "", err)
 		return
 	}
 
-	c.conn = conn
+	c.conn = sio
 	metrics.WebSocketConnections.Inc()
 	defer func() {
 		metrics.WebSocketConnections.Dec()
-		conn.Close()
+		sio.Close()
 	}()
 
-	// Socket.IO handshake sequence
-	c.conn.WriteMessage(websocket.TextMessage, []byte("40"))
-	time.Sleep(100 * time.Millisecond)
-	
-	// Send join message with username
+	sio.On("message", func(args []interface{}) {
+		logging.Debug(ctx, "chat message received", "service", "chat", "payload", fmt.Sprintf("%v", args))
+	})
+	disconnected := make(chan error, 1)
+	sio.OnDisconnect(func(err error) { disconnected <- err })
+
 	username := fmt.Sprintf("loadtest_user_%d", time.Now().Unix()%1000)
-	joinMsg := fmt.Sprintf(`42["join","%s"]`, username)
-	c.conn.WriteMessage(websocket.TextMessage, []byte(joinMsg))
 	c.username = username
-	
-	log.Printf("✅ WebSocket connected for %s", username)
-	
-	// Listen for messages in background
-	go c.readMessages(ctx)
-
-	// Keep connection alive with ping
-	ticker := time.NewTicker(25 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			c.conn.WriteMessage(websocket.TextMessage, []byte("2"))
-		}
+	if err := sio.Emit("join", username); err != nil {
+		logging.Request(ctx, "chat", "connect", "error", time.Since(dialStart), username, err)
+		return
 	}
-}
 
-func (c *ChatBehavior) readMessages(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			_, message, err := c.conn.ReadMessage()
-			if err != nil {
-				return
-			}
-			// Log received messages for debugging
-			if len(message) > 2 && string(message[:2]) == "42" {
-				log.Printf("📨 Received chat message: %s", string(message[2:]))
-			}
-		}
+	logging.Request(ctx, "chat", "connect", "connected", time.Since(dialStart), username, nil)
+
+	// Heartbeat (ping/pong) is handled internally by socketio.Client
+	// using the server-advertised pingInterval/pingTimeout, so this just
+	// waits for the scenario to end or the connection to drop.
+	select {
+	case <-ctx.Done():
+	case <-disconnected:
 	}
 }
 
@@ -123,22 +104,20 @@ func (c *ChatBehavior) SendMessage(ctx context.Context, message string) {
 	}()
 
 	if c.conn == nil {
-		metrics.RequestsTotal.WithLabelValues("chat", "send_message", "no_connection").Inc()
-		log.Printf("⚠️ No WebSocket connection for message: %s", message)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("chat", "send_message", "no_connection")...).Inc()
+		logging.Request(ctx, "chat", "send_message", "no_connection", time.Since(start), c.username, fmt.Errorf("no active WebSocket connection"))
 		return
 	}
 
-	// Send via WebSocket using Socket.IO protocol - this will appear in real-time chat
-	socketIOMsg := fmt.Sprintf(`42["message",{"message":"%s","room":"general","isPrivate":false}]`, message)
-
-	err := c.conn.WriteMessage(websocket.TextMessage, []byte(socketIOMsg))
+	// Send via the socket.io client - this will appear in real-time chat
+	err := c.conn.Emit("message", ChatMessage{Message: message, Room: "general", IsPrivate: false})
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("chat", "send_message", "error").Inc()
-		log.Printf("❌ Failed to send WebSocket message: %v", err)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("chat", "send_message", "error")...).Inc()
+		logging.Request(ctx, "chat", "send_message", "error", time.Since(start), c.username, err)
 		c.conn = nil
 	} else {
-		metrics.RequestsTotal.WithLabelValues("chat", "send_message", "200").Inc()
-		log.Printf("✅ Sent chat message: %s", message)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("chat", "send_message", "200")...).Inc()
+		logging.Request(ctx, "chat", "send_message", "200", time.Since(start), c.username, nil)
 	}
 }
 
@@ -152,16 +131,18 @@ func (c *ChatBehavior) GetMessages(ctx context.Context) {
 		metrics.RequestDuration.WithLabelValues("chat", "get_messages").Observe(time.Since(start).Seconds())
 	}()
 
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/messages", nil)
+	httpReq, _ := http.NewRequestWithContext(ctx, "GET", c.baseURL.Load().(string)+"/api/messages", nil)
 	httpReq.Header.Set("Authorization", "Bearer "+c.token)
 
 	resp, err := c.client.Do(httpReq)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("chat", "get_messages", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("chat", "get_messages", "error")...).Inc()
+		logging.Request(ctx, "chat", "get_messages", "error", time.Since(start), c.username, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("chat", "get_messages", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("chat", "get_messages", status)...).Inc()
+	logging.Request(ctx, "chat", "get_messages", status, time.Since(start), c.username, nil)
 }
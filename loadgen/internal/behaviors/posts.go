@@ -5,19 +5,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"mime/multipart"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"loadgen/internal/chaos"
 	"loadgen/internal/config"
+	"loadgen/internal/httpx"
+	"loadgen/internal/logging"
 	"loadgen/internal/metrics"
 )
 
 type PostsBehavior struct {
-	baseURL string
-	client  *http.Client
+	baseURL atomic.Value // string
+	client  *httpx.Client
 }
 
 type Post struct {
@@ -32,10 +36,20 @@ type CreatePostRequest struct {
 }
 
 func NewPosts(cfg *config.Config) *PostsBehavior {
-	return &PostsBehavior{
-		baseURL: cfg.Services.PostsService.BaseURL,
-		client:  &http.Client{Timeout: 10 * time.Second},
-	}
+	client := httpx.NewHTTPClient("posts", cfg.Services.PostsService, 10*time.Second)
+	transport := chaos.Shared(&cfg.Chaos).WrapTransport("posts", client.Transport)
+	client.Transport = otelhttp.NewTransport(transport)
+
+	p := &PostsBehavior{client: httpx.New("posts", cfg.Services.PostsService.Resilience, client)}
+	p.baseURL.Store(cfg.Services.PostsService.BaseURL)
+	return p
+}
+
+// UpdateConfig re-reads this behavior's tunables from a hot-reloaded
+// config; see AuthBehavior.UpdateConfig for why chaos rates aren't
+// re-applied here.
+func (p *PostsBehavior) UpdateConfig(cfg *config.Config) {
+	p.baseURL.Store(cfg.Services.PostsService.BaseURL)
 }
 
 func (p *PostsBehavior) GetPosts(ctx context.Context, token string) []Post {
@@ -48,25 +62,28 @@ func (p *PostsBehavior) GetPosts(ctx context.Context, token string) []Post {
 		metrics.RequestDuration.WithLabelValues("posts", "get_posts").Observe(time.Since(start).Seconds())
 	}()
 
-	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/posts", nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL.Load().(string)+"/api/posts", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("posts", "get_posts", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "get_posts", "error")...).Inc()
+		logging.Request(ctx, "posts", "get_posts", "error", time.Since(start), "", err)
 		return nil
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("posts", "get_posts", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "get_posts", status)...).Inc()
 
 	if resp.StatusCode != http.StatusOK {
+		logging.Request(ctx, "posts", "get_posts", status, time.Since(start), "", fmt.Errorf("unexpected status %d", resp.StatusCode))
 		return nil
 	}
 
 	var posts []Post
 	json.NewDecoder(resp.Body).Decode(&posts)
+	logging.Request(ctx, "posts", "get_posts", status, time.Since(start), "", nil)
 	return posts
 }
 
@@ -92,25 +109,25 @@ func (p *PostsBehavior) CreatePost(ctx context.Context, token, content string) {
 	part.Write([]byte("dummy image content for load test"))
 	writer.Close()
 
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/posts", &body)
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL.Load().(string)+"/api/posts", &body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("posts", "create_post", "error").Inc()
-		log.Printf("❌ Failed to create post: %v", err)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "create_post", "error")...).Inc()
+		logging.Request(ctx, "posts", "create_post", "error", time.Since(start), "", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("posts", "create_post", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "create_post", status)...).Inc()
 
 	if resp.StatusCode == http.StatusCreated || resp.StatusCode == http.StatusOK {
-		log.Printf("✅ Created post: %s", content)
+		logging.Request(ctx, "posts", "create_post", status, time.Since(start), "", nil)
 	} else {
-		log.Printf("❌ Failed to create post, status: %d", resp.StatusCode)
+		logging.Request(ctx, "posts", "create_post", status, time.Since(start), "", fmt.Errorf("unexpected status %d", resp.StatusCode))
 	}
 }
 
@@ -124,16 +141,18 @@ func (p *PostsBehavior) LikePost(ctx context.Context, token, postID string) {
 		metrics.RequestDuration.WithLabelValues("posts", "like_post").Observe(time.Since(start).Seconds())
 	}()
 
-	req, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/api/posts/"+postID+"/like", nil)
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL.Load().(string)+"/api/posts/"+postID+"/like", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("posts", "like_post", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "like_post", "error")...).Inc()
+		logging.Request(ctx, "posts", "like_post", "error", time.Since(start), "", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("posts", "like_post", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("posts", "like_post", status)...).Inc()
+	logging.Request(ctx, "posts", "like_post", status, time.Since(start), "", nil)
 }
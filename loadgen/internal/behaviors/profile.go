@@ -5,17 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
+	"loadgen/internal/chaos"
 	"loadgen/internal/config"
+	"loadgen/internal/httpx"
+	"loadgen/internal/logging"
 	"loadgen/internal/metrics"
 )
 
 type ProfileBehavior struct {
-	baseURL string
+	baseURL atomic.Value // string
 	client  *http.Client
 }
 
@@ -25,10 +29,20 @@ type ProfileUpdateRequest struct {
 }
 
 func NewProfile(cfg *config.Config) *ProfileBehavior {
-	return &ProfileBehavior{
-		baseURL: cfg.Services.ProfileService.BaseURL,
-		client:  &http.Client{Timeout: 10 * time.Second},
-	}
+	client := httpx.NewHTTPClient("profile", cfg.Services.ProfileService, 10*time.Second)
+	transport := chaos.Shared(&cfg.Chaos).WrapTransport("profile", client.Transport)
+	client.Transport = otelhttp.NewTransport(transport)
+
+	p := &ProfileBehavior{client: client}
+	p.baseURL.Store(cfg.Services.ProfileService.BaseURL)
+	return p
+}
+
+// UpdateConfig re-reads this behavior's tunables from a hot-reloaded
+// config; see AuthBehavior.UpdateConfig for why chaos rates aren't
+// re-applied here.
+func (p *ProfileBehavior) UpdateConfig(cfg *config.Config) {
+	p.baseURL.Store(cfg.Services.ProfileService.BaseURL)
 }
 
 func (p *ProfileBehavior) UpdateProfile(ctx context.Context, token, userID string) {
@@ -60,25 +74,25 @@ func (p *ProfileBehavior) UpdateProfile(ctx context.Context, token, userID strin
 	}
 
 	body, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequestWithContext(ctx, "PUT", p.baseURL+"/api/profile/"+userID, bytes.NewBuffer(body))
+	req, _ := http.NewRequestWithContext(ctx, "PUT", p.baseURL.Load().(string)+"/api/profile/"+userID, bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("profile", "update_profile", "error").Inc()
-		log.Printf("❌ Failed to update profile: %v", err)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("profile", "update_profile", "error")...).Inc()
+		logging.Request(ctx, "profile", "update_profile", "error", time.Since(start), userID, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("profile", "update_profile", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("profile", "update_profile", status)...).Inc()
 
 	if resp.StatusCode == http.StatusOK {
-		log.Printf("✅ Updated profile for user %s", userID)
+		logging.Request(ctx, "profile", "update_profile", status, time.Since(start), userID, nil)
 	} else {
-		log.Printf("❌ Failed to update profile, status: %d", resp.StatusCode)
+		logging.Request(ctx, "profile", "update_profile", status, time.Since(start), userID, fmt.Errorf("unexpected status %d", resp.StatusCode))
 	}
 }
 
@@ -92,16 +106,18 @@ func (p *ProfileBehavior) GetProfile(ctx context.Context, token, userID string)
 		metrics.RequestDuration.WithLabelValues("profile", "get_profile").Observe(time.Since(start).Seconds())
 	}()
 
-	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/api/profile/"+userID, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL.Load().(string)+"/api/profile/"+userID, nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		metrics.RequestsTotal.WithLabelValues("profile", "get_profile", "error").Inc()
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("profile", "get_profile", "error")...).Inc()
+		logging.Request(ctx, "profile", "get_profile", "error", time.Since(start), userID, err)
 		return
 	}
 	defer resp.Body.Close()
 
 	status := fmt.Sprintf("%d", resp.StatusCode)
-	metrics.RequestsTotal.WithLabelValues("profile", "get_profile", status).Inc()
+	metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues("profile", "get_profile", status)...).Inc()
+	logging.Request(ctx, "profile", "get_profile", status, time.Since(start), userID, nil)
 }
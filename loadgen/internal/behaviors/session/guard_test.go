@@ -0,0 +1,109 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"loadgen/internal/config"
+)
+
+func TestCompileProfileRejectsUnrecognizedGuard(t *testing.T) {
+	profile := config.SessionProfile{
+		States: []config.SessionState{
+			{Name: "Browsing", Transitions: []config.SessionTransition{{To: "Posting", Weight: 1, Guard: "is_admin"}}},
+		},
+	}
+	if _, err := compileProfile(profile); err == nil {
+		t.Fatalf("expected an error for an unrecognized guard expression")
+	}
+}
+
+func TestGuardIsValid(t *testing.T) {
+	valid := []string{"", "has_token", "!has_token", "cycle_count % 4 == 0", "service_used.chat == false"}
+	for _, g := range valid {
+		if !guardIsValid(g) {
+			t.Errorf("expected %q to be a valid guard", g)
+		}
+	}
+	invalid := []string{"is_admin", "cycle_count % == 0", "service_used.chat == maybe"}
+	for _, g := range invalid {
+		if guardIsValid(g) {
+			t.Errorf("expected %q to be rejected as an invalid guard", g)
+		}
+	}
+}
+
+func TestEvalGuardHasToken(t *testing.T) {
+	ctx := context.Background()
+	if !evalGuard(ctx, "has_token", guardVars{hasToken: true}) {
+		t.Fatalf("expected has_token to pass when hasToken is true")
+	}
+	if evalGuard(ctx, "has_token", guardVars{hasToken: false}) {
+		t.Fatalf("expected has_token to fail when hasToken is false")
+	}
+	if !evalGuard(ctx, "!has_token", guardVars{hasToken: false}) {
+		t.Fatalf("expected !has_token to pass when hasToken is false")
+	}
+}
+
+func TestEvalGuardCycleCountMod(t *testing.T) {
+	ctx := context.Background()
+	gv := guardVars{cycleCount: 8}
+	if !evalGuard(ctx, "cycle_count % 4 == 0", gv) {
+		t.Fatalf("expected cycle_count %% 4 == 0 to pass for cycleCount=8")
+	}
+	if evalGuard(ctx, "cycle_count % 4 == 1", gv) {
+		t.Fatalf("expected cycle_count %% 4 == 1 to fail for cycleCount=8")
+	}
+}
+
+func TestEvalGuardServiceUsed(t *testing.T) {
+	ctx := context.Background()
+	gv := guardVars{serviceUsed: map[string]bool{"chat": true}}
+	if !evalGuard(ctx, "service_used.chat == true", gv) {
+		t.Fatalf("expected service_used.chat == true to pass")
+	}
+	if !evalGuard(ctx, "service_used.posts == false", gv) {
+		t.Fatalf("expected an unvisited service to read as false")
+	}
+}
+
+func TestEvalGuardEmptyAlwaysPasses(t *testing.T) {
+	if !evalGuard(context.Background(), "", guardVars{}) {
+		t.Fatalf("expected an empty guard to always pass")
+	}
+}
+
+func TestGuardVarsWindowsOnCycleCount(t *testing.T) {
+	v := &vuser{cycleCount: 5, lastUsedCycle: map[string]int{"chat": 5, "posts": 1}}
+	gv := v.guardVars()
+	if !gv.serviceUsed["chat"] {
+		t.Fatalf("expected chat, last used in the current 4-cycle window, to read as used")
+	}
+	if gv.serviceUsed["posts"] {
+		t.Fatalf("expected posts, last used before the current window, to read as unused")
+	}
+}
+
+func TestPickNextSkipsFailingGuards(t *testing.T) {
+	cs := compiledState{SessionState: config.SessionState{
+		Transitions: []config.SessionTransition{
+			{To: "LoggedIn", Weight: 1, Guard: "has_token"},
+			{To: "LoggedOut", Weight: 1, Guard: "!has_token"},
+		},
+	}}
+	got := pickNext(context.Background(), newRNG(), cs, "Start", guardVars{hasToken: true})
+	if got != "LoggedIn" {
+		t.Fatalf("expected the only guard-passing transition (LoggedIn), got %q", got)
+	}
+}
+
+func TestPickNextLoopsOnCurrentStateWhenAllGuardsFail(t *testing.T) {
+	cs := compiledState{SessionState: config.SessionState{
+		Transitions: []config.SessionTransition{{To: "LoggedIn", Weight: 1, Guard: "has_token"}},
+	}}
+	got := pickNext(context.Background(), newRNG(), cs, "Browsing", guardVars{hasToken: false})
+	if got != "Browsing" {
+		t.Fatalf("expected to loop on the current state when every guard fails, got %q", got)
+	}
+}
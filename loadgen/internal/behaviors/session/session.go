@@ -0,0 +1,422 @@
+// Package session drives a virtual user through a Markov-chain state
+// machine described by a config.SessionProfile, complementing the fixed
+// Scenario.Steps loop in internal/runner with branching, probabilistic
+// journeys (e.g. "LoggedOut -> Browsing -> Posting -> Chatting -> Liking
+// -> Idle -> LoggedOut"). Each state's outgoing edges are weighted
+// transitions to the next state, and per-state visit counts plus
+// per-edge transition counts are recorded in internal/metrics so
+// operators can validate generated traffic against the profile it was
+// configured to produce.
+package session
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"loadgen/internal/behaviors"
+	"loadgen/internal/config"
+	"loadgen/internal/logging"
+	"loadgen/internal/metrics"
+)
+
+// Session drives virtual users over one compiled SessionProfile. Profile
+// can be swapped at runtime via ApplyProfile, so a config.Watcher
+// subscriber can hot-reload behavior profiles without restarting the
+// process; only virtual users still walking the chain between states
+// pick up the new profile, since each step re-reads the current
+// snapshot.
+type Session struct {
+	mu      sync.RWMutex
+	profile compiledProfile
+}
+
+type compiledProfile struct {
+	config.SessionProfile
+	states map[string]compiledState
+}
+
+type compiledState struct {
+	config.SessionState
+	thinkMin time.Duration
+	thinkMax time.Duration
+}
+
+// New compiles profile, parsing think-time durations up front so
+// malformed config surfaces immediately rather than mid-run.
+func New(profile config.SessionProfile) (*Session, error) {
+	compiled, err := compileProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{profile: compiled}, nil
+}
+
+// ApplyProfile recompiles profile and swaps it in.
+func (s *Session) ApplyProfile(profile config.SessionProfile) error {
+	compiled, err := compileProfile(profile)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.profile = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Session) snapshot() compiledProfile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+func compileProfile(profile config.SessionProfile) (compiledProfile, error) {
+	cp := compiledProfile{SessionProfile: profile, states: make(map[string]compiledState, len(profile.States))}
+
+	for _, st := range profile.States {
+		cs := compiledState{SessionState: st}
+
+		if st.ThinkTime.Min != "" {
+			d, err := time.ParseDuration(st.ThinkTime.Min)
+			if err != nil {
+				return compiledProfile{}, fmt.Errorf("session %q: state %q: invalid think_time.min: %w", profile.Name, st.Name, err)
+			}
+			cs.thinkMin = d
+		}
+		cs.thinkMax = cs.thinkMin
+		if st.ThinkTime.Max != "" {
+			d, err := time.ParseDuration(st.ThinkTime.Max)
+			if err != nil {
+				return compiledProfile{}, fmt.Errorf("session %q: state %q: invalid think_time.max: %w", profile.Name, st.Name, err)
+			}
+			cs.thinkMax = d
+		}
+
+		for _, t := range st.Transitions {
+			if !guardIsValid(t.Guard) {
+				return compiledProfile{}, fmt.Errorf("session %q: state %q: unrecognized guard expression %q", profile.Name, st.Name, t.Guard)
+			}
+		}
+
+		cp.states[st.Name] = cs
+	}
+
+	if profile.InitialState != "" {
+		if _, ok := cp.states[profile.InitialState]; !ok {
+			return compiledProfile{}, fmt.Errorf("session %q: initial_state %q is not a defined state", profile.Name, profile.InitialState)
+		}
+	}
+
+	return cp, nil
+}
+
+// vuser is the behavior-client set a single virtual user carries across
+// states; it mirrors runner.vuser since both dispatch the same
+// "behavior.action" step names. rng is seeded once at construction (see
+// Run's seed parameter) so a given user id's whole journey - state
+// transitions, think times, and the random picks inside runStep - is
+// reproducible run over run instead of drawing from the shared
+// math/rand source every other caller in this codebase uses.
+type vuser struct {
+	id      int
+	token   string
+	userID  string
+	auth    *behaviors.AuthBehavior
+	chat    *behaviors.ChatBehavior
+	posts   *behaviors.PostsBehavior
+	profile *behaviors.ProfileBehavior
+	rng     *rand.Rand
+
+	// cycleCount counts states visited so far, and lastUsedCycle records
+	// the cycleCount at which each step's service (chat/posts/profile,
+	// parsed from its "service.action" step name) last ran - together
+	// these back the cycle_count and service_used guard variables (see
+	// evalGuard).
+	cycleCount    int
+	lastUsedCycle map[string]int
+}
+
+// guardVars is what evalGuard evaluates a transition's Guard expression
+// against, recomputed fresh from v before every pickNext call.
+type guardVars struct {
+	hasToken   bool
+	cycleCount int
+	// serviceUsed reports whether each service has run a step within the
+	// current 4-cycle window - the same "reset every 4 actions, guarantee
+	// each service gets a turn" window user.User.Run used to enforce by
+	// hand before this package took over driving it.
+	serviceUsed map[string]bool
+}
+
+// Run drives one virtual user through s's Markov chain, starting at
+// InitialState, until ctx is cancelled (immediately) or drain is closed
+// (gracefully: Run finishes the state it's currently in, then returns,
+// rather than stopping mid-step - the same contract as user.User.Run's
+// drain parameter). drain may be nil, in which case only ctx.Done() can
+// stop the loop. seed seeds v's own RNG (see the vuser.rng field doc);
+// callers that don't need reproducibility can pass
+// time.Now().UnixNano(). Each state entry is counted in
+// metrics.SessionStateVisits and each edge taken in
+// metrics.SessionTransitionsTotal, so a long-running Run call's
+// aggregate transitions can be compared against the profile's
+// configured probabilities.
+func (s *Session) Run(ctx context.Context, cfg *config.Config, id int, seed int64, drain <-chan struct{}) {
+	v := &vuser{
+		id:            id,
+		userID:        strconv.Itoa(id),
+		auth:          behaviors.NewAuth(cfg),
+		chat:          behaviors.NewChat(cfg),
+		posts:         behaviors.NewPosts(cfg),
+		profile:       behaviors.NewProfile(cfg),
+		rng:           rand.New(rand.NewSource(seed)),
+		lastUsedCycle: make(map[string]int),
+	}
+
+	profile := s.snapshot()
+	state := profile.InitialState
+	if state == "" {
+		logging.Error(ctx, "session has no initial_state configured", "profile", profile.Name)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-drain:
+			return
+		default:
+		}
+
+		profile = s.snapshot()
+		cs, ok := profile.states[state]
+		if !ok {
+			logging.Error(ctx, "session reached undefined state, stopping", "profile", profile.Name, "state", state)
+			return
+		}
+
+		metrics.SessionStateVisits.WithLabelValues(profile.Name, state).Inc()
+		if cs.Step != "" {
+			v.runStep(ctx, cs.Step)
+			v.lastUsedCycle[serviceOf(cs.Step)] = v.cycleCount
+		}
+		v.cycleCount++
+
+		thinkFor(ctx, v.rng, cs)
+
+		next := pickNext(ctx, v.rng, cs, state, v.guardVars())
+		metrics.SessionTransitionsTotal.WithLabelValues(profile.Name, state, next).Inc()
+		state = next
+	}
+}
+
+// guardVars snapshots v's current state for evalGuard to read.
+func (v *vuser) guardVars() guardVars {
+	windowStart := (v.cycleCount / 4) * 4
+	used := make(map[string]bool, len(v.lastUsedCycle))
+	for svc, last := range v.lastUsedCycle {
+		used[svc] = last >= windowStart
+	}
+	return guardVars{hasToken: v.token != "", cycleCount: v.cycleCount, serviceUsed: used}
+}
+
+// serviceOf returns a "service.action" step name's service half, e.g.
+// "chat" for "chat.send".
+func serviceOf(step string) string {
+	svc, _, _ := strings.Cut(step, ".")
+	return svc
+}
+
+var (
+	reCycleMod    = regexp.MustCompile(`^cycle_count\s*%\s*(\d+)\s*==\s*(\d+)$`)
+	reServiceUsed = regexp.MustCompile(`^service_used\.(\w+)\s*==\s*(true|false)$`)
+)
+
+// guardIsValid reports whether guard is one of evalGuard's recognized
+// forms, so compileProfile can reject a typo'd guard at load time
+// instead of it silently always evaluating to false at runtime.
+func guardIsValid(guard string) bool {
+	guard = strings.TrimSpace(guard)
+	switch guard {
+	case "", "has_token", "!has_token":
+		return true
+	}
+	return reCycleMod.MatchString(guard) || reServiceUsed.MatchString(guard)
+}
+
+// evalGuard evaluates a SessionTransition.Guard expression against gv.
+// Supported forms: "" (always true), "has_token", "!has_token",
+// "cycle_count % N == M", and "service_used.<name> == true|false" - see
+// guardIsValid, which compileProfile already checked every guard against
+// at load time, so the fallback below should be unreachable in
+// practice.
+func evalGuard(ctx context.Context, guard string, gv guardVars) bool {
+	guard = strings.TrimSpace(guard)
+	switch {
+	case guard == "":
+		return true
+	case guard == "has_token":
+		return gv.hasToken
+	case guard == "!has_token":
+		return !gv.hasToken
+	}
+
+	if m := reCycleMod.FindStringSubmatch(guard); m != nil {
+		mod, _ := strconv.Atoi(m[1])
+		want, _ := strconv.Atoi(m[2])
+		if mod <= 0 {
+			return false
+		}
+		return gv.cycleCount%mod == want
+	}
+
+	if m := reServiceUsed.FindStringSubmatch(guard); m != nil {
+		want := m[2] == "true"
+		return gv.serviceUsed[m[1]] == want
+	}
+
+	logging.Error(ctx, "session: unrecognized guard expression, treating as false", "guard", guard)
+	return false
+}
+
+// pickNext chooses the next state by weighted random selection among
+// cs's Transitions whose Guard passes, falling back to looping on the
+// current state when none are defined or none pass so a terminal or
+// fully-guarded-off state doesn't silently stall the driver.
+func pickNext(ctx context.Context, rng *rand.Rand, cs compiledState, current string, gv guardVars) string {
+	eligible := make([]config.SessionTransition, 0, len(cs.Transitions))
+	var totalWeight float64
+	for _, t := range cs.Transitions {
+		if evalGuard(ctx, t.Guard, gv) {
+			eligible = append(eligible, t)
+			totalWeight += t.Weight
+		}
+	}
+	if len(eligible) == 0 {
+		return current
+	}
+	if totalWeight <= 0 {
+		return eligible[rng.Intn(len(eligible))].To
+	}
+
+	r := rng.Float64() * totalWeight
+	for _, t := range eligible {
+		r -= t.Weight
+		if r <= 0 {
+			return t.To
+		}
+	}
+	return eligible[len(eligible)-1].To
+}
+
+// thinkFor pauses according to cs's think-time distribution, returning
+// early if ctx is cancelled mid-pause.
+func thinkFor(ctx context.Context, rng *rand.Rand, cs compiledState) {
+	d := thinkDuration(rng, cs)
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
+
+func thinkDuration(rng *rand.Rand, cs compiledState) time.Duration {
+	mean := (cs.thinkMin + cs.thinkMax) / 2
+
+	switch cs.ThinkTime.Distribution {
+	case "exponential":
+		if mean <= 0 {
+			return 0
+		}
+		d := time.Duration(rng.ExpFloat64() * float64(mean))
+		return clamp(d, cs.thinkMax)
+	case "log-normal":
+		if mean <= 0 {
+			return 0
+		}
+		// Sigma=0.5 keeps most samples within roughly half to double the
+		// mean, giving a thinner tail than "exponential" while still
+		// skewing toward occasional long pauses - closer to how a human
+		// actually paces browsing or chatting.
+		const sigma = 0.5
+		mu := math.Log(float64(mean)) - sigma*sigma/2
+		d := time.Duration(math.Exp(mu + sigma*rng.NormFloat64()))
+		return clamp(d, cs.thinkMax)
+	case "uniform":
+		if cs.thinkMax <= cs.thinkMin {
+			return cs.thinkMin
+		}
+		return cs.thinkMin + time.Duration(rng.Int63n(int64(cs.thinkMax-cs.thinkMin)))
+	default: // "constant"
+		return cs.thinkMin
+	}
+}
+
+func clamp(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+// runStep dispatches a single "behavior.action" step name, the same
+// vocabulary as runner.vuser.runStep, to the matching behaviors call.
+func (v *vuser) runStep(ctx context.Context, step string) {
+	switch step {
+	case "auth.login":
+		v.login(ctx)
+	case "auth.register":
+		v.auth.Register(ctx, v.username(), v.username()+"@example.com", "password123")
+	case "auth.logout":
+		v.token = ""
+	case "profile.update":
+		v.profile.UpdateProfile(ctx, v.token, v.userID)
+	case "profile.view":
+		v.profile.GetProfile(ctx, v.token, v.userID)
+	case "posts.create":
+		v.posts.CreatePost(ctx, v.token, fmt.Sprintf("session post from %s", v.username()))
+	case "posts.like":
+		if posts := v.posts.GetPosts(ctx, v.token); len(posts) > 0 {
+			v.posts.LikePost(ctx, v.token, posts[v.rng.Intn(len(posts))].ID)
+		}
+	case "posts.view":
+		v.posts.GetPosts(ctx, v.token)
+	case "chat.connect":
+		go v.chat.Connect(ctx, v.token)
+	case "chat.send":
+		v.chat.SendMessage(ctx, fmt.Sprintf("session message from %s", v.username()))
+	case "chat.read":
+		v.chat.GetMessages(ctx)
+	default:
+		logging.Error(ctx, "session: unknown step, skipping", "step", step)
+	}
+}
+
+func (v *vuser) username() string {
+	return fmt.Sprintf("session_user_%d", v.id)
+}
+
+func (v *vuser) login(ctx context.Context) {
+	token, err := v.auth.Login(ctx, v.username(), "password123")
+	if err != nil {
+		if err := v.auth.Register(ctx, v.username(), v.username()+"@example.com", "password123"); err != nil {
+			logging.Error(ctx, "session: user register failed", "user", v.username(), "error", err.Error())
+			return
+		}
+		token, err = v.auth.Login(ctx, v.username(), "password123")
+		if err != nil {
+			logging.Error(ctx, "session: user login after register failed", "user", v.username(), "error", err.Error())
+			return
+		}
+	}
+	v.token = token
+}
@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"loadgen/internal/config"
+)
+
+func newRNG() *rand.Rand {
+	return rand.New(rand.NewSource(1))
+}
+
+func TestCompileProfileParsesThinkTimes(t *testing.T) {
+	profile := config.SessionProfile{
+		Name:         "browse",
+		InitialState: "Browsing",
+		States: []config.SessionState{
+			{Name: "Browsing", ThinkTime: config.ThinkTime{Min: "1s", Max: "2s"}},
+		},
+	}
+
+	cp, err := compileProfile(profile)
+	if err != nil {
+		t.Fatalf("compileProfile: %v", err)
+	}
+	cs, ok := cp.states["Browsing"]
+	if !ok {
+		t.Fatalf("expected a compiled state for %q", "Browsing")
+	}
+	if cs.thinkMin.Seconds() != 1 || cs.thinkMax.Seconds() != 2 {
+		t.Fatalf("expected thinkMin=1s thinkMax=2s, got %v/%v", cs.thinkMin, cs.thinkMax)
+	}
+}
+
+func TestCompileProfileDefaultsThinkMaxToThinkMin(t *testing.T) {
+	profile := config.SessionProfile{
+		States: []config.SessionState{
+			{Name: "Idle", ThinkTime: config.ThinkTime{Min: "5s"}},
+		},
+	}
+
+	cp, err := compileProfile(profile)
+	if err != nil {
+		t.Fatalf("compileProfile: %v", err)
+	}
+	cs := cp.states["Idle"]
+	if cs.thinkMax != cs.thinkMin {
+		t.Fatalf("expected an unset think_time.max to default to min, got min=%v max=%v", cs.thinkMin, cs.thinkMax)
+	}
+}
+
+func TestCompileProfileRejectsInvalidThinkTime(t *testing.T) {
+	profile := config.SessionProfile{
+		States: []config.SessionState{
+			{Name: "Idle", ThinkTime: config.ThinkTime{Min: "not-a-duration"}},
+		},
+	}
+	if _, err := compileProfile(profile); err == nil {
+		t.Fatalf("expected an error for an unparsable think_time.min")
+	}
+}
+
+func TestCompileProfileRejectsUndefinedInitialState(t *testing.T) {
+	profile := config.SessionProfile{
+		InitialState: "Nowhere",
+		States:       []config.SessionState{{Name: "Browsing"}},
+	}
+	if _, err := compileProfile(profile); err == nil {
+		t.Fatalf("expected an error when initial_state isn't one of States")
+	}
+}
+
+func TestPickNextLoopsOnCurrentStateWhenNoTransitions(t *testing.T) {
+	cs := compiledState{}
+	got := pickNext(context.Background(), newRNG(), cs, "Idle", guardVars{})
+	if got != "Idle" {
+		t.Fatalf("expected a state with no transitions to loop on itself, got %q", got)
+	}
+}
+
+func TestPickNextWeightedSelection(t *testing.T) {
+	cs := compiledState{SessionState: config.SessionState{
+		Transitions: []config.SessionTransition{
+			{To: "A", Weight: 1},
+			{To: "B", Weight: 99},
+		},
+	}}
+
+	counts := map[string]int{}
+	rng := newRNG()
+	for i := 0; i < 1000; i++ {
+		counts[pickNext(context.Background(), rng, cs, "Start", guardVars{})]++
+	}
+	if counts["B"] <= counts["A"] {
+		t.Fatalf("expected B (weight 99) to be picked far more than A (weight 1), got %v", counts)
+	}
+}
+
+func TestServiceOf(t *testing.T) {
+	if got := serviceOf("chat.send"); got != "chat" {
+		t.Fatalf("expected %q, got %q", "chat", got)
+	}
+	if got := serviceOf("noservice"); got != "noservice" {
+		t.Fatalf("expected a step with no '.' to return as-is, got %q", got)
+	}
+}
@@ -1,51 +1,965 @@
 package chaos
 
 import (
-	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"loadgen/internal/config"
+	"loadgen/internal/metrics"
 )
 
+// ChaosMiddleware injects bounded, configurable faults into outgoing HTTP
+// requests made by the load generator's behaviors, scoped per service/path
+// and rate-limited by a token bucket so faults happen at a bounded rate
+// rather than purely probabilistically.
 type ChaosMiddleware struct {
-	config *config.Chaos
+	mu            sync.RWMutex
+	rules         []compiledRule
+	bucket        *tokenBucket
+	interceptors  []Interceptor
+	notifier      ChaosNotifier
+	rng           *Rand
+	deterministic bool
+
+	eventMu     sync.Mutex // serializes writes to eventWriter, separate from mu so logging never blocks rule/bucket reads
+	eventWriter io.Writer
+}
+
+// ChaosEvent is one structured JSON line written to a ChaosMiddleware's
+// event writer (see WithEventWriter) each time an interceptor fires,
+// independent of the Prometheus counters/histograms and the ChaosNotifier
+// callback. Where those are built for dashboards and tests, this is built
+// for after-the-fact auditing: grep/jq the log for the route and time
+// window a downstream error showed up in to see exactly which fault (and
+// which RNG seed) loadgen injected for it.
+type ChaosEvent struct {
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Service   string    `json:"service"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Seed      int64     `json:"seed"`
+	ElapsedMs float64   `json:"elapsed_ms"`
+}
+
+// Rand is a mutex-guarded *rand.Rand shared by a ChaosMiddleware's
+// interceptor chain. Plain *rand.Rand isn't safe for concurrent use, but
+// every behavior's requests round-trip through the same middleware
+// concurrently, so every random draw a fault decision makes goes through
+// here instead of the math/rand package-level functions.
+type Rand struct {
+	mu   sync.Mutex
+	src  *rand.Rand
+	seed int64
+}
+
+func newRand(seed int64) *Rand {
+	return &Rand{src: rand.New(rand.NewSource(seed)), seed: seed}
+}
+
+// Seed returns the value this Rand was constructed with, recorded on every
+// ChaosEvent so a run with config.Chaos.Deterministic set can be
+// replayed and its injected faults verified bit-for-bit. A Rand installed
+// via WithRand reports seed 0, since an externally supplied *rand.Rand's
+// seed isn't recoverable.
+func (r *Rand) Seed() int64 { return r.seed }
+
+func (r *Rand) Float64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.src.Float64()
+}
+
+func (r *Rand) ExpFloat64() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.src.ExpFloat64()
+}
+
+func (r *Rand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.src.Intn(n)
+}
+
+// Option configures a ChaosMiddleware at construction time, applied after
+// its config-derived defaults so an option can override them.
+type Option func(*ChaosMiddleware)
+
+// WithRand overrides a ChaosMiddleware's RNG with r, e.g. so a CI
+// regression test can hand it a rand.New(rand.NewSource(knownSeed)) and
+// assert on the exact resulting fault sequence.
+func WithRand(r *rand.Rand) Option {
+	return func(c *ChaosMiddleware) {
+		c.rng = &Rand{src: r}
+	}
+}
+
+// WithEventWriter configures w to receive a ChaosEvent JSON line for every
+// fault an interceptor injects, for audit/correlation purposes separate
+// from metrics/tracing. w is written to under eventMu, so it need not be
+// concurrency-safe itself.
+func WithEventWriter(w io.Writer) Option {
+	return func(c *ChaosMiddleware) {
+		c.eventWriter = w
+	}
+}
+
+// RoundTripFunc adapts a plain function to the subset of http.RoundTripper
+// an Interceptor needs to call onward, letting the chain be built out of
+// closures rather than requiring every interceptor to hold its own
+// reference to the base transport.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// Interceptor is one composable chaos behavior: a named link in the chain
+// that chaosTransport.RoundTrip builds per request. It decides whether to
+// call next (continuing to the next interceptor, and eventually the base
+// transport) and/or mutate the request before calling it or the response
+// after, reporting whether it actually injected a fault on this request so
+// the caller can record metrics/tracing/notifier callbacks exactly once per
+// effect. This mirrors the way k8s's chaosclient composes fault behaviors
+// as a chain rather than one monolithic function.
+type Interceptor interface {
+	Name() string
+	Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (resp *http.Response, err error, fired bool)
+}
+
+// ChaosNotifier is invoked synchronously whenever an interceptor fires,
+// letting tests and metrics/event-log exporters observe injected faults
+// deterministically instead of inferring them from response codes or
+// timing. Set via ChaosMiddleware.SetNotifier.
+type ChaosNotifier interface {
+	ChaosFired(req *http.Request, name, service string)
+}
+
+// interceptorRegistry maps the names accepted by config.Chaos.Interceptors
+// to their implementations, so a config can select/reorder the chain
+// without the package exporting constructors for each one.
+var interceptorRegistry = map[string]Interceptor{
+	"delay":        delayInterceptor{},
+	"dns_fail":     dnsFailInterceptor{},
+	"reset":        resetInterceptor{},
+	"corrupt_body": corruptBodyInterceptor{},
+	"status":       statusInterceptor{},
+	"truncate":     truncateInterceptor{},
+	"throttle":     throttleInterceptor{},
+}
+
+// defaultInterceptorOrder is the chain used when config.Chaos.Interceptors
+// is unset, preserving the fault ordering the single-function RoundTrip
+// used before interceptors were split out: request-side faults before the
+// round trip, response-side faults after it.
+var defaultInterceptorOrder = []string{"delay", "dns_fail", "reset", "corrupt_body", "status", "truncate", "throttle"}
+
+// buildInterceptors resolves names to Interceptors, falling back to
+// defaultInterceptorOrder when names is empty and silently skipping any
+// name absent from interceptorRegistry (treated the same as an unknown
+// scenario step elsewhere in config - a typo shouldn't crash the process).
+func buildInterceptors(names []string) []Interceptor {
+	if len(names) == 0 {
+		names = defaultInterceptorOrder
+	}
+	out := make([]Interceptor, 0, len(names))
+	for _, n := range names {
+		if ic, ok := interceptorRegistry[n]; ok {
+			out = append(out, ic)
+		}
+	}
+	return out
+}
+
+type compiledRule struct {
+	config.ChaosRule
+	pathRe *regexp.Regexp
+}
+
+var (
+	defaultMu sync.Mutex
+	defaultMw *ChaosMiddleware
+)
+
+func New(cfg *config.Chaos, opts ...Option) *ChaosMiddleware {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	cm := &ChaosMiddleware{
+		bucket:        bucketFromConfig(cfg),
+		interceptors:  buildInterceptors(cfg.Interceptors),
+		rng:           newRand(seed),
+		deterministic: cfg.Deterministic,
+	}
+	for _, opt := range opts {
+		opt(cm)
+	}
+	cm.SetRules(toRules(cfg))
+
+	defaultMu.Lock()
+	if defaultMw == nil {
+		defaultMw = cm
+	}
+	defaultMu.Unlock()
+
+	return cm
+}
+
+// Default returns the first ChaosMiddleware created via New, shared across
+// every behavior so a single control-plane endpoint (e.g. the dashboard's
+// PUT /api/chaos) can update rules for the whole process at once.
+func Default() *ChaosMiddleware {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultMw
+}
+
+// Shared returns the process-wide default ChaosMiddleware, creating it from
+// cfg if one doesn't exist yet. Behaviors use this instead of New directly
+// so they all inject faults through the same rule set and token bucket,
+// which is what lets a single control-plane update (dashboard or config
+// hot-reload) affect every behavior's requests at once.
+func Shared(cfg *config.Chaos) *ChaosMiddleware {
+	if cm := Default(); cm != nil {
+		return cm
+	}
+	return New(cfg)
+}
+
+// ApplyConfig replaces both the rule set and the token-bucket rate/burst
+// from cfg, used by the config package's Watcher to apply hot-reloaded
+// chaos tunables without restarting the process.
+func (c *ChaosMiddleware) ApplyConfig(cfg *config.Chaos) {
+	c.SetRules(toRules(cfg))
+
+	bucket := bucketFromConfig(cfg)
+	interceptors := buildInterceptors(cfg.Interceptors)
+	c.mu.Lock()
+	c.bucket = bucket
+	c.interceptors = interceptors
+	c.deterministic = cfg.Deterministic
+	c.mu.Unlock()
+}
+
+// SetNotifier installs a ChaosNotifier invoked on every subsequent
+// interceptor firing, replacing any previously set notifier. Passing nil
+// disables notification.
+func (c *ChaosMiddleware) SetNotifier(n ChaosNotifier) {
+	c.mu.Lock()
+	c.notifier = n
+	c.mu.Unlock()
+}
+
+// notify reports a fired interceptor to the installed notifier, if any.
+func (c *ChaosMiddleware) notify(req *http.Request, name, service string) {
+	c.mu.RLock()
+	n := c.notifier
+	c.mu.RUnlock()
+	if n != nil {
+		n.ChaosFired(req, name, service)
+	}
+}
+
+// logEvent writes a ChaosEvent for one fired interceptor to the installed
+// event writer, if any. Marshal/write errors are dropped rather than
+// surfaced, matching notify's "best-effort observability, never fail the
+// request" stance.
+func (c *ChaosMiddleware) logEvent(kind, service string, req *http.Request, rng *Rand, elapsed time.Duration) {
+	c.mu.RLock()
+	w := c.eventWriter
+	c.mu.RUnlock()
+	if w == nil {
+		return
+	}
+
+	data, err := json.Marshal(ChaosEvent{
+		Time:      time.Now(),
+		Kind:      kind,
+		Service:   service,
+		Method:    req.Method,
+		Route:     req.URL.Path,
+		Seed:      rng.Seed(),
+		ElapsedMs: float64(elapsed) / float64(time.Millisecond),
+	})
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	c.eventMu.Lock()
+	w.Write(data)
+	c.eventMu.Unlock()
+}
+
+// interceptorChain returns the active interceptor chain under the rules
+// lock, mirroring allow()'s pattern for reading fields ApplyConfig can
+// swap concurrently with in-flight requests.
+func (c *ChaosMiddleware) interceptorChain() []Interceptor {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.interceptors
+}
+
+// requestRand returns the RNG the chain should use for this request. In the
+// default mode it's the middleware's single shared RNG; in deterministic
+// mode it's a fresh one seeded from a hash of method+path+X-Request-ID, so
+// replaying identical traffic (same path, same client-supplied request ID)
+// draws the same fault decisions on every run regardless of what else has
+// drawn from the shared RNG in between - the property CI regression tests
+// and flaky-bisection runs need.
+func (c *ChaosMiddleware) requestRand(req *http.Request) *Rand {
+	c.mu.RLock()
+	deterministic := c.deterministic
+	shared := c.rng
+	c.mu.RUnlock()
+
+	if !deterministic {
+		return shared
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(req.Method))
+	h.Write([]byte(req.URL.Path))
+	h.Write([]byte(req.Header.Get("X-Request-ID")))
+	return newRand(int64(h.Sum64()))
 }
 
-func New(cfg *config.Chaos) *ChaosMiddleware {
-	return &ChaosMiddleware{config: cfg}
+// allow reads the current token bucket under the rules lock, since
+// ApplyConfig can swap it concurrently with in-flight requests.
+func (c *ChaosMiddleware) allow() bool {
+	c.mu.RLock()
+	b := c.bucket
+	c.mu.RUnlock()
+	return b.Allow()
 }
 
-func (c *ChaosMiddleware) WrapTransport(rt http.RoundTripper) http.RoundTripper {
-	return &chaosTransport{
-		base:   rt,
-		config: c.config,
+func bucketFromConfig(cfg *config.Chaos) *tokenBucket {
+	rate := cfg.RatePerSec
+	if rate <= 0 {
+		rate = 1000 // effectively unbounded unless configured
 	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return newTokenBucket(rate, burst)
+}
+
+// toRules derives the rule set from a config.Chaos, folding the legacy
+// top-level ErrorRate/DelayRate/MaxDelayMs into a catch-all rule so existing
+// configs keep working unchanged.
+func toRules(cfg *config.Chaos) []config.ChaosRule {
+	rules := make([]config.ChaosRule, 0, len(cfg.Rules)+1)
+	rules = append(rules, cfg.Rules...)
+	if cfg.ErrorRate > 0 || cfg.DelayRate > 0 {
+		meanDelay := float64(cfg.MaxDelayMs) / 2
+		rules = append(rules, config.ChaosRule{
+			Service:       "",
+			PathPattern:   ".*",
+			DelayRate:     cfg.DelayRate,
+			MeanDelayMs:   meanDelay,
+			ErrorRate:     cfg.ErrorRate,
+			StatusWeights: map[int]float64{500: 1},
+		})
+	}
+	return rules
+}
+
+// SetRules replaces the active rule set, allowing live reload (e.g. via the
+// dashboard's PUT /api/chaos endpoint) without restarting the process.
+func (c *ChaosMiddleware) SetRules(rules []config.ChaosRule) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		re, err := compileRulePattern(r.PathPattern)
+		if err != nil {
+			continue
+		}
+		if r.ID == "" {
+			r.ID = newRuleID()
+		}
+		compiled = append(compiled, compiledRule{ChaosRule: r, pathRe: re})
+	}
+
+	c.mu.Lock()
+	c.rules = compiled
+	c.mu.Unlock()
+}
+
+// Rules returns the currently active rule set (for GET /api/chaos).
+func (c *ChaosMiddleware) Rules() []config.ChaosRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]config.ChaosRule, 0, len(c.rules))
+	for _, r := range c.rules {
+		out = append(out, r.ChaosRule)
+	}
+	return out
+}
+
+// UpsertRule adds or replaces a single rule addressed by RuleSpec.ID
+// (generating one if blank), without touching the rest of the active
+// rule set - this is what lets /chaos/rules add one route's fault
+// injection mid-run instead of requiring a full SetRules replacement.
+func (c *ChaosMiddleware) UpsertRule(spec RuleSpec) (RuleSpec, error) {
+	rule := spec.toChaosRule()
+	re, err := compileRulePattern(rule.PathPattern)
+	if err != nil {
+		return RuleSpec{}, fmt.Errorf("invalid path pattern %q: %w", rule.PathPattern, err)
+	}
+	if rule.ID == "" {
+		rule.ID = newRuleID()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, r := range c.rules {
+		if r.ID == rule.ID {
+			c.rules[i] = compiledRule{ChaosRule: rule, pathRe: re}
+			return ruleSpecFromChaosRule(rule), nil
+		}
+	}
+	c.rules = append(c.rules, compiledRule{ChaosRule: rule, pathRe: re})
+	return ruleSpecFromChaosRule(rule), nil
+}
+
+// DeleteRule removes the rule with the given ID, reporting whether one
+// was found.
+func (c *ChaosMiddleware) DeleteRule(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, r := range c.rules {
+		if r.ID == id {
+			c.rules = append(c.rules[:i], c.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RuleSpecs returns the active rule set in /chaos/rules's friendlier
+// wire format (for GET /chaos/rules).
+func (c *ChaosMiddleware) RuleSpecs() []RuleSpec {
+	rules := c.Rules()
+	specs := make([]RuleSpec, 0, len(rules))
+	for _, r := range rules {
+		specs = append(specs, ruleSpecFromChaosRule(r))
+	}
+	return specs
+}
+
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		pattern = ".*"
+	}
+	return regexp.Compile(pattern)
+}
+
+func newRuleID() string {
+	var buf [8]byte
+	if _, err := cryptorand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("rule-%d", len(buf))
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// matchRule finds the most specific rule matching service/method/path.
+// Specificity is scored so a rule scoped to an exact method (and/or a
+// non-catch-all path pattern) always wins over a broader one regardless
+// of insertion order; among equally specific rules, the most recently
+// added wins, matching the rest of the package's "later rule overrides"
+// convention.
+func (c *ChaosMiddleware) matchRule(service, method, path string) *config.ChaosRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var best *compiledRule
+	bestScore := -1
+	for i := range c.rules {
+		r := &c.rules[i]
+		if r.Service != "" && r.Service != service {
+			continue
+		}
+		if r.Method != "" && !strings.EqualFold(r.Method, method) {
+			continue
+		}
+		if !r.pathRe.MatchString(path) {
+			continue
+		}
+
+		score := 0
+		if r.Method != "" {
+			score += 2
+		}
+		if r.PathPattern != "" && r.PathPattern != ".*" {
+			score += 1
+		}
+		if score >= bestScore {
+			bestScore = score
+			best = r
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return &best.ChaosRule
+}
+
+// WrapTransport returns an http.RoundTripper that applies chaos rules
+// scoped to the given service name to every request before delegating to rt.
+func (c *ChaosMiddleware) WrapTransport(service string, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &chaosTransport{base: rt, chaos: c, service: service}
 }
 
 type chaosTransport struct {
-	base   http.RoundTripper
-	config *config.Chaos
+	base    http.RoundTripper
+	chaos   *ChaosMiddleware
+	service string
 }
 
 func (ct *chaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Random delay injection
-	if rand.Float64() < ct.config.DelayRate {
-		delay := time.Duration(rand.Intn(ct.config.MaxDelayMs)) * time.Millisecond
-		select {
-		case <-time.After(delay):
-		case <-req.Context().Done():
-			return nil, req.Context().Err()
+	rule := ct.chaos.matchRule(ct.service, req.Method, req.URL.Path)
+	if rule == nil || !ct.chaos.allow() {
+		return ct.base.RoundTrip(req)
+	}
+
+	// Recorded on the request's active span so a single trace shows exactly
+	// which faults were injected into it, alongside the aggregate counters.
+	span := trace.SpanFromContext(req.Context())
+	span.SetAttributes(attribute.String("chaos.service", ct.service))
+
+	return ct.chaos.runChain(rule, req, ct.base, ct.service, span)
+}
+
+// runChain threads req through the active interceptor chain, each link
+// wrapping a next func that continues to the following interceptor and,
+// once the chain is exhausted, to base. A request-side interceptor (delay,
+// reset, dns_fail, corrupt_body) typically acts before calling next; a
+// response-side one (status, truncate, throttle) calls next first and then
+// mutates the result. Every interceptor that fires gets its effect recorded
+// on span, in metrics, and via the notifier, keyed by its Name().
+func (c *ChaosMiddleware) runChain(rule *config.ChaosRule, req *http.Request, base http.RoundTripper, service string, span trace.Span) (*http.Response, error) {
+	interceptors := c.interceptorChain()
+	rng := c.requestRand(req)
+	start := time.Now()
+
+	var run func(i int, req *http.Request) (*http.Response, error)
+	run = func(i int, req *http.Request) (*http.Response, error) {
+		if i >= len(interceptors) {
+			return base.RoundTrip(req)
 		}
+		ic := interceptors[i]
+		next := func(r *http.Request) (*http.Response, error) { return run(i+1, r) }
+
+		resp, err, fired := ic.Intercept(rule, req, rng, next)
+		if fired {
+			span.SetAttributes(attribute.Bool("chaos."+ic.Name(), true))
+			metrics.ChaosInjectedTotal.WithLabelValues(ic.Name(), req.Method, req.URL.Path).Inc()
+			c.notify(req, ic.Name(), service)
+			c.logEvent(ic.Name(), service, req, rng, time.Since(start))
+		}
+		return resp, err
 	}
+	return run(0, req)
+}
 
-	resp, err := ct.base.RoundTrip(req)
-	
-	// Random error injection
-	if err == nil && rand.Float64() < ct.config.ErrorRate {
-		resp.StatusCode = 500
-		resp.Status = "500 Internal Server Error"
+// drawDelay picks a latency for the delay interceptor: a rule with
+// Min/MaxDelayMs draws uniformly from that range (the shape a human
+// operator setting explicit bounds via /chaos/rules expects); a legacy
+// rule with only MeanDelayMs keeps the original exponential draw, so most
+// requests see small jitter and a long tail of slow outliers - closer to
+// real network/service behavior.
+func drawDelay(rule *config.ChaosRule, rng *Rand) time.Duration {
+	switch {
+	case rule.MinDelayMs > 0 || rule.MaxDelayMs > 0:
+		lo, hi := rule.MinDelayMs, rule.MaxDelayMs
+		if hi < lo {
+			hi = lo
+		}
+		return time.Duration(lo+rng.Float64()*(hi-lo)) * time.Millisecond
+	case rule.MeanDelayMs > 0:
+		return time.Duration(rng.ExpFloat64()*rule.MeanDelayMs) * time.Millisecond
 	}
+	return 0
+}
+
+// delayInterceptor sleeps before continuing the chain, simulating added
+// network/service latency.
+type delayInterceptor struct{}
+
+func (delayInterceptor) Name() string { return "delay" }
+
+func (delayInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	fired := false
+	if rule.DelayRate > 0 && rng.Float64() < rule.DelayRate {
+		if delay := drawDelay(rule, rng); delay > 0 {
+			select {
+			case <-time.After(delay):
+				fired = true
+				metrics.ChaosDelaySeconds.WithLabelValues(req.URL.Path).Observe(delay.Seconds())
+			case <-req.Context().Done():
+				return nil, req.Context().Err(), false
+			}
+		}
+	}
+	resp, err := next(req)
+	return resp, err, fired
+}
+
+// dnsFailInterceptor fails the request before it ever dials out, as if the
+// hostname didn't resolve - distinct from resetInterceptor, which fails as
+// though a connection was established and then torn down.
+type dnsFailInterceptor struct{}
+
+func (dnsFailInterceptor) Name() string { return "dns_fail" }
+
+func (dnsFailInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	if rule.DNSFailRate > 0 && rng.Float64() < rule.DNSFailRate {
+		return nil, &net.OpError{
+			Op:  "dial",
+			Net: "tcp",
+			Err: &net.DNSError{Err: "no such host (chaos)", Name: req.URL.Hostname(), IsNotFound: true},
+		}, true
+	}
+	resp, err := next(req)
+	return resp, err, false
+}
+
+// resetInterceptor fails before the request ever reaches the wire, as
+// though the peer reset an already-open connection.
+type resetInterceptor struct{}
+
+func (resetInterceptor) Name() string { return "reset" }
+
+func (resetInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	if rule.ResetRate > 0 && rng.Float64() < rule.ResetRate {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: errConnReset{}}, true
+	}
+	resp, err := next(req)
+	return resp, err, false
+}
+
+// corruptBodyInterceptor flips a single byte of the outgoing request body,
+// simulating on-the-wire corruption that a checksum/signature check on the
+// receiving service should catch and reject.
+type corruptBodyInterceptor struct{}
+
+func (corruptBodyInterceptor) Name() string { return "corrupt_body" }
 
-	return resp, err
+func (corruptBodyInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	if rule.CorruptRate > 0 && req.Body != nil && rng.Float64() < rule.CorruptRate {
+		req.Body = &corruptingBody{ReadCloser: req.Body}
+		resp, err := next(req)
+		return resp, err, true
+	}
+	resp, err := next(req)
+	return resp, err, false
+}
+
+// statusInterceptor overrides a successful response's status code (and
+// optionally its body), simulating the downstream service itself returning
+// an error.
+type statusInterceptor struct{}
+
+func (statusInterceptor) Name() string { return "status" }
+
+func (statusInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	resp, err := next(req)
+	if err != nil || resp == nil {
+		return resp, err, false
+	}
+	if rule.ErrorRate == 0 || rng.Float64() >= rule.ErrorRate {
+		return resp, nil, false
+	}
+
+	status := rule.Status
+	if status == 0 {
+		status = weightedStatus(rule.StatusWeights, rng)
+	}
+	resp.StatusCode = status
+	resp.Status = http.StatusText(status)
+
+	if rule.ErrorBody != "" {
+		resp.Body.Close()
+		resp.Body = io.NopCloser(strings.NewReader(rule.ErrorBody))
+		resp.ContentLength = int64(len(rule.ErrorBody))
+		resp.Header.Set("Content-Length", strconv.Itoa(len(rule.ErrorBody)))
+	}
+	metrics.ChaosErrorStatus.WithLabelValues(strconv.Itoa(status)).Inc()
+	return resp, nil, true
+}
+
+// truncateInterceptor cuts a response body short, simulating a connection
+// that drops mid-transfer.
+type truncateInterceptor struct{}
+
+func (truncateInterceptor) Name() string { return "truncate" }
+
+func (truncateInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	resp, err := next(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err, false
+	}
+	if rule.TruncateRate == 0 || rng.Float64() >= rule.TruncateRate {
+		return resp, nil, false
+	}
+	resp.Body = &truncatingBody{ReadCloser: resp.Body, remaining: rng.Intn(256)}
+	return resp, nil, true
+}
+
+// throttleInterceptor caps a response body's read rate, simulating a slow
+// link rather than an outright failure.
+type throttleInterceptor struct{}
+
+func (throttleInterceptor) Name() string { return "throttle" }
+
+func (throttleInterceptor) Intercept(rule *config.ChaosRule, req *http.Request, rng *Rand, next RoundTripFunc) (*http.Response, error, bool) {
+	resp, err := next(req)
+	if err != nil || resp == nil || resp.Body == nil || rule.ThrottleBytesPerSec <= 0 {
+		return resp, err, false
+	}
+	resp.Body = &throttledBody{ReadCloser: resp.Body, bytesPerSec: rule.ThrottleBytesPerSec}
+	return resp, nil, true
+}
+
+// weightedStatus picks a status code by weighted random selection, falling
+// back to 500 if no weights are configured.
+func weightedStatus(weights map[int]float64, rng *Rand) int {
+	if len(weights) == 0 {
+		return http.StatusInternalServerError
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+
+	r := rng.Float64() * total
+	for status, w := range weights {
+		r -= w
+		if r <= 0 {
+			return status
+		}
+	}
+
+	for status := range weights {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// truncatingBody wraps a response body and returns io.EOF after `remaining`
+// bytes, simulating a partial/truncated response.
+type truncatingBody struct {
+	io.ReadCloser
+	remaining int
+}
+
+func (t *truncatingBody) Read(p []byte) (int, error) {
+	if t.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if len(p) > t.remaining {
+		p = p[:t.remaining]
+	}
+	n, err := t.ReadCloser.Read(p)
+	t.remaining -= n
+	return n, err
+}
+
+// corruptingBody wraps a request body and flips one bit of each non-empty
+// read, simulating bit-rot or a buggy proxy mangling bytes in transit.
+type corruptingBody struct {
+	io.ReadCloser
+}
+
+func (b *corruptingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		p[rand.Intn(n)] ^= 0xFF
+	}
+	return n, err
+}
+
+// throttledBody wraps a response body and paces reads to bytesPerSec,
+// simulating a bandwidth-limited link rather than an outright failure.
+type throttledBody struct {
+	io.ReadCloser
+	bytesPerSec float64
+}
+
+// throttleTick is the window throttledBody paces reads over: short enough
+// that a caller reading in a loop sees a roughly steady drip rather than
+// long stalls, long enough not to be dominated by scheduler jitter.
+const throttleTick = 100 * time.Millisecond
+
+func (t *throttledBody) Read(p []byte) (int, error) {
+	maxBytes := int(t.bytesPerSec * throttleTick.Seconds())
+	if maxBytes < 1 {
+		maxBytes = 1
+	}
+	if len(p) > maxBytes {
+		p = p[:maxBytes]
+	}
+
+	start := time.Now()
+	n, err := t.ReadCloser.Read(p)
+	if elapsed := time.Since(start); elapsed < throttleTick {
+		time.Sleep(throttleTick - elapsed)
+	}
+	return n, err
+}
+
+type errConnReset struct{}
+
+func (errConnReset) Error() string   { return "connection reset by peer (chaos)" }
+func (errConnReset) Timeout() bool   { return false }
+func (errConnReset) Temporary() bool { return true }
+
+// tokenBucket is a minimal token-bucket rate limiter bounding how often
+// chaos faults fire, independent of how many requests flow through.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	capacity   float64
+	refillRate float64
+	lastRefill time.Time
+}
+
+func newTokenBucket(refillRate float64, capacity int) *tokenBucket {
+	return &tokenBucket{
+		tokens:     float64(capacity),
+		capacity:   float64(capacity),
+		refillRate: refillRate,
+		lastRefill: time.Now(),
+	}
+}
+
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// RulesJSON is a convenience for handlers serving/accepting rule sets over
+// the dashboard's chaos control API.
+func RulesJSON(rules []config.ChaosRule) ([]byte, error) {
+	return json.Marshal(rules)
+}
+
+// ParseRulesJSON decodes a rule set posted to the dashboard's chaos control API.
+func ParseRulesJSON(data []byte) ([]config.ChaosRule, error) {
+	var rules []config.ChaosRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// RuleSpec is the JSON shape accepted/returned by the /chaos/rules
+// per-rule control endpoint - friendlier for an operator scoping a fault
+// to one route by hand than config.ChaosRule's flat fields, e.g.:
+//
+//	{"method":"POST","path":"/api/orders",
+//	 "delay":{"min_ms":100,"max_ms":800,"rate":0.3},
+//	 "error":{"status":503,"body":"upstream busy","rate":0.1}}
+type RuleSpec struct {
+	ID       string     `json:"id,omitempty"`
+	Method   string     `json:"method,omitempty"`
+	Path     string     `json:"path"`
+	Delay    *DelaySpec `json:"delay,omitempty"`
+	Error    *ErrorSpec `json:"error,omitempty"`
+	ResetPct float64    `json:"reset_rate,omitempty"`
+	TruncPct float64    `json:"truncate_rate,omitempty"`
+}
+
+// DelaySpec draws a latency uniformly between MinMs and MaxMs, injected
+// on a fraction Rate of matching requests.
+type DelaySpec struct {
+	MinMs float64 `json:"min_ms"`
+	MaxMs float64 `json:"max_ms"`
+	Rate  float64 `json:"rate"`
+}
+
+// ErrorSpec overrides a fraction Rate of matching responses to Status,
+// optionally replacing the body with Body.
+type ErrorSpec struct {
+	Status int     `json:"status"`
+	Body   string  `json:"body"`
+	Rate   float64 `json:"rate"`
+}
+
+func (s RuleSpec) toChaosRule() config.ChaosRule {
+	rule := config.ChaosRule{
+		ID:           s.ID,
+		Method:       strings.ToUpper(s.Method),
+		PathPattern:  s.Path,
+		ResetRate:    s.ResetPct,
+		TruncateRate: s.TruncPct,
+	}
+	if s.Delay != nil {
+		rule.MinDelayMs = s.Delay.MinMs
+		rule.MaxDelayMs = s.Delay.MaxMs
+		rule.DelayRate = s.Delay.Rate
+	}
+	if s.Error != nil {
+		rule.Status = s.Error.Status
+		rule.ErrorBody = s.Error.Body
+		rule.ErrorRate = s.Error.Rate
+	}
+	return rule
+}
+
+func ruleSpecFromChaosRule(r config.ChaosRule) RuleSpec {
+	spec := RuleSpec{ID: r.ID, Method: r.Method, Path: r.PathPattern, ResetPct: r.ResetRate, TruncPct: r.TruncateRate}
+	if r.MinDelayMs > 0 || r.MaxDelayMs > 0 || r.DelayRate > 0 {
+		spec.Delay = &DelaySpec{MinMs: r.MinDelayMs, MaxMs: r.MaxDelayMs, Rate: r.DelayRate}
+	}
+	if r.Status != 0 || r.ErrorBody != "" || r.ErrorRate > 0 {
+		spec.Error = &ErrorSpec{Status: r.Status, Body: r.ErrorBody, Rate: r.ErrorRate}
+	}
+	return spec
+}
+
+// ParseRuleSpec decodes a single rule posted to PUT /chaos/rules.
+func ParseRuleSpec(data []byte) (RuleSpec, error) {
+	var spec RuleSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return RuleSpec{}, err
+	}
+	return spec, nil
 }
@@ -1,6 +1,7 @@
 package cleanup
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,51 +9,240 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"loadgen/internal/config"
+	"loadgen/internal/httpx"
+	"loadgen/internal/metrics"
 )
 
+// Cleanup keeps one httpx.Client per downstream service it talks to, so
+// rate limiting, retries, and circuit breaking apply independently -  a
+// degraded posts-service shouldn't throttle or trip the breaker for
+// user-service deletes.
+//
+// users is persisted to stateFile (if configured) after every tracked
+// change and reloaded in New, so a crashed or restarted process can
+// still clean up the accounts it created instead of orphaning them.
+// inFlight counts deletes currently in progress so WaitForShutdown can
+// give them a bounded grace period to finish before the process exits.
+// Observer receives notifications when Cleanup's tracked-user set changes.
+// internal/web.WebServer sets one via SetObserver to push live updates to
+// its SSE hub without Cleanup importing internal/web back - the same
+// inversion internal/chaos.ChaosNotifier uses for chaos fault callbacks.
+type Observer interface {
+	UserTracked(username string)
+	UserUntracked(username string)
+}
+
 type Cleanup struct {
-	config *config.Config
-	client *http.Client
-	users  []string
+	config      *config.Config
+	userClient  *httpx.Client
+	chatClient  *httpx.Client
+	postsClient *httpx.Client
+
+	chatCleaner  *resourceCleaner
+	postsCleaner *resourceCleaner
+	concurrency  int
+
+	mu        sync.Mutex
+	users     []string
+	stateFile string
+	inFlight  sync.WaitGroup
+	observer  Observer
+}
+
+// SetObserver registers o to be notified whenever a user is tracked or
+// untracked. Only one observer is supported, matching
+// chaos.ChaosMiddleware.SetNotifier; pass nil to clear it.
+func (c *Cleanup) SetObserver(o Observer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observer = o
 }
 
 func New(cfg *config.Config) *Cleanup {
-	return &Cleanup{
-		config: cfg,
-		client: &http.Client{Timeout: 10 * time.Second},
-		users:  make([]string, 0),
+	userClient := httpx.New("user", cfg.Services.UserService.Resilience, httpx.NewHTTPClient("user", cfg.Services.UserService, 10*time.Second))
+	chatClient := httpx.New("chat", cfg.Services.ChatService.Resilience, httpx.NewHTTPClient("chat", cfg.Services.ChatService, 10*time.Second))
+	postsClient := httpx.New("posts", cfg.Services.PostsService.Resilience, httpx.NewHTTPClient("posts", cfg.Services.PostsService, 10*time.Second))
+
+	concurrency := cfg.Cleanup.Concurrency
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+
+	c := &Cleanup{
+		config:      cfg,
+		userClient:  userClient,
+		chatClient:  chatClient,
+		postsClient: postsClient,
+		chatCleaner: &resourceCleaner{
+			name:     "chat",
+			client:   chatClient,
+			listURL:  cfg.Services.ChatService.BaseURL + "/api/messages",
+			bulkURL:  cfg.Services.ChatService.BaseURL + "/api/messages:bulkDeleteByUsername",
+			bulkMode: cfg.Cleanup.BulkDelete,
+			deleteURL: func(id string) string {
+				return cfg.Services.ChatService.BaseURL + "/api/messages/" + id
+			},
+		},
+		postsCleaner: &resourceCleaner{
+			name:     "posts",
+			client:   postsClient,
+			listURL:  cfg.Services.PostsService.BaseURL + "/api/posts",
+			bulkURL:  cfg.Services.PostsService.BaseURL + "/api/posts:bulkDeleteByUsername",
+			bulkMode: cfg.Cleanup.BulkDelete,
+			deleteURL: func(id string) string {
+				return cfg.Services.PostsService.BaseURL + "/api/posts/" + id
+			},
+		},
+		concurrency: concurrency,
+		users:       make([]string, 0),
+		stateFile:   cfg.Cleanup.StateFile,
+	}
+	c.loadState()
+	return c
+}
+
+// persistedState is the on-disk shape of a Cleanup's tracked-users list.
+type persistedState struct {
+	Users []string `json:"users"`
+}
+
+// loadState reloads a previously-persisted tracked-users list from
+// stateFile, if configured and present. A missing file is normal on
+// first run and isn't logged as an error.
+func (c *Cleanup) loadState() {
+	if c.stateFile == "" {
+		return
+	}
+	data, err := os.ReadFile(c.stateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️ Failed to read cleanup state file %s: %v", c.stateFile, err)
+		}
+		return
+	}
+	var state persistedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Printf("⚠️ Failed to parse cleanup state file %s: %v", c.stateFile, err)
+		return
+	}
+	c.mu.Lock()
+	c.users = state.Users
+	c.mu.Unlock()
+	log.Printf("♻️ Reloaded %d tracked users from %s", len(state.Users), c.stateFile)
+}
+
+// saveState persists the current tracked-users list to stateFile,
+// writing to a temp file and renaming it into place so a crash mid-write
+// can't leave a truncated state file behind. Persistence is best-effort:
+// failures are logged, not returned, since losing a write only risks
+// re-discovering orphaned users later rather than corrupting anything.
+func (c *Cleanup) saveState() {
+	if c.stateFile == "" {
+		return
+	}
+	c.mu.Lock()
+	state := persistedState{Users: append([]string(nil), c.users...)}
+	c.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️ Failed to marshal cleanup state: %v", err)
+		return
+	}
+	tmp := c.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		log.Printf("⚠️ Failed to write cleanup state file %s: %v", tmp, err)
+		return
+	}
+	if err := os.Rename(tmp, c.stateFile); err != nil {
+		log.Printf("⚠️ Failed to finalize cleanup state file %s: %v", c.stateFile, err)
+	}
+}
+
+// WaitForShutdown blocks until ctx is cancelled or a SIGTERM/SIGINT is
+// received, whichever comes first. On signal, it waits up to grace (or
+// 30s if grace <= 0) for in-flight deletes to finish - see cleanupUsers
+// - before returning, so a restart doesn't abandon deletes mid-flight
+// and lose track of their outcome. Callers run this in its own
+// goroutine alongside the rest of the load generator.
+func (c *Cleanup) WaitForShutdown(ctx context.Context, grace time.Duration) {
+	if grace <= 0 {
+		grace = 30 * time.Second
+	}
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	defer signal.Stop(sig)
+
+	select {
+	case <-ctx.Done():
+		return
+	case got := <-sig:
+		log.Printf("🛑 Received %s, draining in-flight cleanup deletes (grace=%s)...", got, grace)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("✅ In-flight deletes drained, shutting down")
+	case <-time.After(grace):
+		log.Printf("⚠️ Grace period elapsed with deletes still in flight, shutting down anyway")
 	}
 }
 
 func (c *Cleanup) AddUser(username string) {
-	// Avoid duplicates in the tracked users list
+	c.mu.Lock()
 	for _, u := range c.users {
 		if u == username {
+			c.mu.Unlock()
 			return
 		}
 	}
 	c.users = append(c.users, username)
+	observer := c.observer
+	c.mu.Unlock()
+	c.saveState()
+
+	if observer != nil {
+		observer.UserTracked(username)
+	}
 }
 
 func (c *Cleanup) GetTrackedUsers() []string {
-	return c.users
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, len(c.users))
+	copy(out, c.users)
+	return out
 }
 
 func (c *Cleanup) ReduceLoad(ctx context.Context, usersToDelete int) int {
-	if usersToDelete <= 0 || usersToDelete > len(c.users) {
-		usersToDelete = len(c.users)
+	tracked := c.GetTrackedUsers()
+	if usersToDelete <= 0 || usersToDelete > len(tracked) {
+		usersToDelete = len(tracked)
 	}
 
-	log.Printf("🔻 Reducing load: deleting %d out of %d load-generated users...", usersToDelete, len(c.users))
+	log.Printf("🔻 Reducing load: deleting %d out of %d load-generated users...", usersToDelete, len(tracked))
 
 	// Select unique users to delete: shuffle the tracked users and take the first N
-	all := make([]string, len(c.users))
-	copy(all, c.users)
+	all := make([]string, len(tracked))
+	copy(all, tracked)
 	rand.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
 	selectedUsers := all
 	if usersToDelete < len(all) {
@@ -60,7 +250,7 @@ func (c *Cleanup) ReduceLoad(ctx context.Context, usersToDelete int) int {
 	}
 
 	// First, attempt to delete user accounts from user-service
-	deletedList, _ := c.cleanupUsers(ctx, selectedUsers)
+	deletedList, _ := c.cleanupUsers(ctx, selectedUsers, 0)
 	deletedUsers := len(deletedList)
 
 	// Cleanup chat messages and posts for the selected users (best-effort)
@@ -68,374 +258,670 @@ func (c *Cleanup) ReduceLoad(ctx context.Context, usersToDelete int) int {
 	c.cleanupPostsFromUsers(ctx, selectedUsers)
 
 	// Remove deleted users from tracking
-	remaining := make([]string, 0)
-	for _, user := range c.users {
-		found := false
-		for _, deleted := range selectedUsers {
-			if user == deleted {
-				found = true
-				break
-			}
-		}
-		if !found {
-			remaining = append(remaining, user)
-		}
-	}
-	c.users = remaining
+	c.untrack(selectedUsers)
 
-	log.Printf("✅ Load reduction completed: %d user accounts removed, %d users remain", deletedUsers, len(c.users))
+	c.mu.Lock()
+	remaining := len(c.users)
+	c.mu.Unlock()
+	log.Printf("✅ Load reduction completed: %d user accounts removed, %d users remain", deletedUsers, remaining)
 	return deletedUsers
 }
 
-// cleanupUsers sends DELETE requests to the user service for the given usernames.
-// Returns a slice of usernames that were successfully deleted and a map of failed usernames to HTTP status codes.
-func (c *Cleanup) cleanupUsers(ctx context.Context, users []string) ([]string, map[string]int) {
-	deleted := make([]string, 0)
-	failed := make(map[string]int)
+// FailureInfo records why a user delete ultimately failed: the last HTTP
+// status it received (0 for a network error that never got a response)
+// and how many attempts deleteUserWithRetry made before giving up.
+type FailureInfo struct {
+	Status   int `json:"status"`
+	Attempts int `json:"attempts"`
+}
+
+const (
+	deleteRetryBaseDelay = 200 * time.Millisecond
+	deleteRetryFactor    = 2
+	deleteRetryCap       = 5 * time.Second
+	deleteRetryMaxTries  = 4
+
+	// defaultDeleteConcurrency is cleanupUsers' worker pool size when the
+	// caller doesn't ask for a specific one, capped at the batch size.
+	defaultDeleteConcurrency = 16
+)
+
+// cleanupUsers deletes users via a bounded pool of concurrency workers
+// (concurrency <= 0 defaults to min(defaultDeleteConcurrency, len(users))),
+// each retrying its own delete through deleteUserWithRetry. Returns the
+// usernames that were successfully deleted and, for the rest, why (see
+// FailureInfo).
+func (c *Cleanup) cleanupUsers(ctx context.Context, users []string, concurrency int) ([]string, map[string]FailureInfo) {
+	if len(users) == 0 {
+		return []string{}, map[string]FailureInfo{}
+	}
+	if concurrency <= 0 {
+		concurrency = defaultDeleteConcurrency
+	}
+	if concurrency > len(users) {
+		concurrency = len(users)
+	}
+
+	type result struct {
+		username string
+		ok       bool
+		info     FailureInfo
+	}
+	results := make(chan result, len(users))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
 	for _, u := range users {
-		url := c.config.Services.UserService.BaseURL + "/api/users/" + u
-		log.Printf("➡️ Deleting user via: %s", url)
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(username string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok, info := c.deleteUserWithRetry(ctx, username)
+			results <- result{username: username, ok: ok, info: info}
+		}(u)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	deleted := make([]string, 0, len(users))
+	failed := make(map[string]FailureInfo)
+	for r := range results {
+		if r.ok {
+			deleted = append(deleted, r.username)
+		} else {
+			failed[r.username] = r.info
+		}
+	}
+	return deleted, failed
+}
+
+// deleteUserWithRetry sends DELETE /api/users/<username>, retrying a
+// network error or 5xx response with exponential backoff and jitter
+// (base 200ms, factor 2, capped at 5s) up to deleteRetryMaxTries times. A
+// 4xx is never retried since repeating the identical request wouldn't
+// produce a different client error.
+func (c *Cleanup) deleteUserWithRetry(ctx context.Context, username string) (bool, FailureInfo) {
+	c.inFlight.Add(1)
+	defer c.inFlight.Done()
+
+	url := c.config.Services.UserService.BaseURL + "/api/users/" + username
+
+	var lastStatus int
+	for attempt := 1; attempt <= deleteRetryMaxTries; attempt++ {
+		log.Printf("➡️ Deleting user via: %s (attempt %d/%d)", url, attempt, deleteRetryMaxTries)
 		req, _ := http.NewRequestWithContext(ctx, "DELETE", url, nil)
-		resp, err := c.client.Do(req)
+		resp, err := c.userClient.Do(req)
 		if err != nil {
-			log.Printf("⚠️ Failed to delete user %s: %v", u, err)
-			failed[u] = 0
-			continue
-		}
-		// Read and close body for better diagnostics
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		log.Printf("⬅️ Response for DELETE %s: status=%d body=%s", url, resp.StatusCode, string(body))
-		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
-			deleted = append(deleted, u)
-			log.Printf("✅ Deleted user account: %s", u)
+			log.Printf("⚠️ Failed to delete user %s (attempt %d/%d): %v", username, attempt, deleteRetryMaxTries, err)
+			lastStatus = 0
 		} else {
-			log.Printf("⚠️ Could not delete user %s, status: %d", u, resp.StatusCode)
-			failed[u] = resp.StatusCode
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			log.Printf("⬅️ Response for DELETE %s: status=%d body=%s", url, resp.StatusCode, string(body))
+			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+				log.Printf("✅ Deleted user account: %s", username)
+				return true, FailureInfo{Status: resp.StatusCode, Attempts: attempt}
+			}
+			lastStatus = resp.StatusCode
+			if lastStatus < 500 {
+				log.Printf("⚠️ Could not delete user %s, status: %d (not retrying a non-5xx)", username, lastStatus)
+				return false, FailureInfo{Status: lastStatus, Attempts: attempt}
+			}
+		}
+
+		if attempt == deleteRetryMaxTries {
+			break
+		}
+		select {
+		case <-time.After(deleteBackoff(attempt)):
+		case <-ctx.Done():
+			return false, FailureInfo{Status: lastStatus, Attempts: attempt}
 		}
 	}
-	return deleted, failed
+	log.Printf("⚠️ Giving up deleting user %s after %d attempts, last status: %d", username, deleteRetryMaxTries, lastStatus)
+	return false, FailureInfo{Status: lastStatus, Attempts: deleteRetryMaxTries}
 }
 
-// DeleteTestUsers queries the user-service dashboard for users whose usernames start with
-// the test user prefix ("user_"), and deletes up to `count` of them. It returns the list
-// of usernames that were deleted.
-func (c *Cleanup) DeleteTestUsers(ctx context.Context, count int) ([]string, map[string]int) {
-	if count <= 0 {
-		return []string{}, map[string]int{}
+// deleteBackoff returns the delay before the retry following attempt n:
+// base*factor^(n-1) capped at deleteRetryCap, jittered by ±25% so many
+// workers retrying the same failure don't all hammer the server in lockstep.
+func deleteBackoff(attempt int) time.Duration {
+	d := deleteRetryBaseDelay
+	for i := 1; i < attempt; i++ {
+		d *= deleteRetryFactor
+		if d >= deleteRetryCap {
+			d = deleteRetryCap
+			break
+		}
 	}
+	jitter := 0.75 + rand.Float64()*0.5
+	return time.Duration(float64(d) * jitter)
+}
 
-	// Try to fetch user list from user-service dashboard
-	dashboardURL := c.config.Services.UserService.BaseURL + "/api/users/dashboard"
-	req, _ := http.NewRequestWithContext(ctx, "GET", dashboardURL, nil)
-	resp, err := c.client.Do(req)
+// dashboardPage is one page of the user-service dashboard listing. Next
+// is a continuation token (same pattern as an object-store's ListObjects
+// call): a non-empty value means another page follows at ?cursor=Next,
+// an empty value means this was the last page.
+type dashboardPage struct {
+	Users []interface{} `json:"users"`
+	Next  string        `json:"next"`
+}
+
+// DefaultUserPattern matches load-generated test accounts ("user_123"),
+// the prefix match ListTestUsers/DeleteTestUsers always assumed before
+// this became a regex - so an account like "bob" is never swept up.
+const DefaultUserPattern = `^user_\d+$`
+
+// ListTestUsers streams usernames matching the regex pattern from the
+// user-service dashboard, paging through it pageSize users at a time via
+// ?search=&cursor=&limit= instead of loading the whole listing into
+// memory, so cleanup scales to a dashboard tracking hundreds of
+// thousands of users. An empty or invalid pattern falls back to
+// DefaultUserPattern. The returned channel is closed once pagination
+// completes, ctx is cancelled, or a page request fails.
+//
+// The dashboard is also asked to search server-side, but matching is
+// re-applied client-side too: this repo has no user-service source to
+// confirm the dashboard actually honors ?search= as a regex, so the
+// client-side check is the safety net that makes correctness not depend
+// on that.
+func (c *Cleanup) ListTestUsers(ctx context.Context, pattern string, pageSize int) <-chan string {
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	if pattern == "" {
+		pattern = DefaultUserPattern
+	}
+	re, err := regexp.Compile(pattern)
 	if err != nil {
-		log.Printf("⚠️ Failed to fetch dashboard: %v", err)
-		return []string{}, map[string]int{}
+		log.Printf("⚠️ Invalid user pattern %q, falling back to %q: %v", pattern, DefaultUserPattern, err)
+		pattern = DefaultUserPattern
+		re = regexp.MustCompile(pattern)
 	}
-	defer resp.Body.Close()
 
-	var data map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Printf("⚠️ Failed to decode dashboard response: %v", err)
-		return []string{}, map[string]int{}
-	}
-
-	// Extract users array (if available). Items may be strings or objects with a "username" field.
-	candidates := make([]string, 0)
-	if ulist, ok := data["users"]; ok {
-		if arr, ok := ulist.([]interface{}); ok {
-			for _, v := range arr {
-				switch it := v.(type) {
-				case string:
-					if strings.HasPrefix(it, "user_") {
-						candidates = append(candidates, it)
-					}
-				case map[string]interface{}:
-					if uname, ok := it["username"].(string); ok {
-						if strings.HasPrefix(uname, "user_") {
-							candidates = append(candidates, uname)
-						}
-					}
+	out := make(chan string)
+
+	go func() {
+		defer close(out)
+
+		cursor := ""
+		for {
+			page, err := c.fetchDashboardPage(ctx, pattern, cursor, pageSize)
+			if err != nil {
+				log.Printf("⚠️ Failed to fetch dashboard page (cursor=%q): %v", cursor, err)
+				return
+			}
+
+			for _, v := range page.Users {
+				username, ok := usernameOf(v)
+				if !ok || !re.MatchString(username) {
+					continue
+				}
+				select {
+				case out <- username:
+				case <-ctx.Done():
+					return
 				}
 			}
-		}
-	}
 
-	// If dashboard didn't return users, fall back to tracked list
-	if len(candidates) == 0 {
-		for _, u := range c.users {
-			if strings.HasPrefix(u, "user_") {
-				candidates = append(candidates, u)
+			if page.Next == "" {
+				return
 			}
+			cursor = page.Next
 		}
+	}()
+
+	return out
+}
+
+func (c *Cleanup) fetchDashboardPage(ctx context.Context, search, cursor string, pageSize int) (*dashboardPage, error) {
+	u, err := url.Parse(c.config.Services.UserService.BaseURL + "/api/users/dashboard")
+	if err != nil {
+		return nil, err
 	}
+	q := u.Query()
+	q.Set("search", search)
+	q.Set("limit", strconv.Itoa(pageSize))
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+	u.RawQuery = q.Encode()
 
-	if len(candidates) == 0 {
-		log.Printf("ℹ️ No test users found to delete (prefix 'user_')")
-		return []string{}, map[string]int{}
+	req, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	resp, err := c.userClient.Do(req)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	// Ensure uniqueness and deterministic order: shuffle and pick up to count
-	uniq := make([]string, 0)
-	seen := map[string]bool{}
-	for _, s := range candidates {
-		if !seen[s] {
-			seen[s] = true
-			uniq = append(uniq, s)
+	var page dashboardPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("decoding dashboard page: %w", err)
+	}
+	return &page, nil
+}
+
+// usernameOf extracts a username from a dashboard listing entry, which
+// may be a bare string or an object with a "username" field.
+func usernameOf(v interface{}) (string, bool) {
+	switch it := v.(type) {
+	case string:
+		return it, true
+	case map[string]interface{}:
+		uname, ok := it["username"].(string)
+		return uname, ok
+	default:
+		return "", false
+	}
+}
+
+// collectUsers drains up to limit usernames from ch in the order they
+// arrive, stopping as soon as limit is reached without waiting for
+// ListTestUsers' producer goroutine to finish paging.
+func collectUsers(ctx context.Context, ch <-chan string, limit int) []string {
+	out := make([]string, 0, limit)
+	for len(out) < limit {
+		select {
+		case u, ok := <-ch:
+			if !ok {
+				return out
+			}
+			out = append(out, u)
+		case <-ctx.Done():
+			return out
 		}
 	}
-	rand.Shuffle(len(uniq), func(i, j int) { uniq[i], uniq[j] = uniq[j], uniq[i] })
+	return out
+}
 
-	toDelete := uniq
-	if count < len(uniq) {
-		toDelete = uniq[:count]
+// reservoirSample performs reservoir sampling over ch, selecting up to
+// limit usernames uniformly at random across the full stream without
+// needing to buffer more than limit of them at once, so a "random
+// subset" pick still scales to a paginated listing of arbitrary size.
+func reservoirSample(ctx context.Context, ch <-chan string, limit int) []string {
+	if limit <= 0 {
+		return nil
+	}
+	sample := make([]string, 0, limit)
+	n := 0
+	for {
+		select {
+		case u, ok := <-ch:
+			if !ok {
+				return sample
+			}
+			n++
+			if len(sample) < limit {
+				sample = append(sample, u)
+			} else if i := rand.Intn(n); i < limit {
+				sample[i] = u
+			}
+		case <-ctx.Done():
+			return sample
+		}
 	}
+}
 
-	// Use existing cleanup path to delete the selected users
-	deleted, failed := c.cleanupUsers(ctx, toDelete)
+// Forget removes usernames from tracked state without deleting them
+// remotely - the same "stop tracking, don't clean up" semantics Loki's
+// shutdown_and_forget applies to an abandoned ingester. Used by an
+// aborted test run (see internal/web.WebServer.handleAbortCurrent) to
+// disown the users it created before anyone gets a chance to act on
+// them as cleanup candidates.
+func (c *Cleanup) Forget(usernames []string) {
+	c.untrack(usernames)
+}
 
-	// Remove deleted users from tracked list if present
-	remaining := make([]string, 0)
-	delSet := map[string]bool{}
-	for _, d := range deleted {
-		delSet[d] = true
+// untrack removes the given usernames from the in-memory tracked list
+// and persists the result.
+func (c *Cleanup) untrack(removed []string) {
+	if len(removed) == 0 {
+		return
 	}
+	delSet := make(map[string]bool, len(removed))
+	for _, u := range removed {
+		delSet[u] = true
+	}
+	c.mu.Lock()
+	remaining := make([]string, 0, len(c.users))
 	for _, u := range c.users {
 		if !delSet[u] {
 			remaining = append(remaining, u)
 		}
 	}
 	c.users = remaining
+	observer := c.observer
+	c.mu.Unlock()
+	c.saveState()
+
+	if observer != nil {
+		for _, u := range removed {
+			observer.UserUntracked(u)
+		}
+	}
+}
+
+var defaultUserRe = regexp.MustCompile(DefaultUserPattern)
+
+// DeleteTestUsers streams up to `count` usernames matching
+// DefaultUserPattern from the paginated dashboard listing via
+// ListTestUsers, deletes them through a bounded worker pool with
+// per-delete retries (see cleanupUsers), and returns the usernames
+// deleted plus why any of the rest failed.
+func (c *Cleanup) DeleteTestUsers(ctx context.Context, count int) ([]string, map[string]FailureInfo) {
+	if count <= 0 {
+		return []string{}, map[string]FailureInfo{}
+	}
+
+	toDelete := collectUsers(ctx, c.ListTestUsers(ctx, DefaultUserPattern, 100), count)
+
+	// If the dashboard didn't return users, fall back to the tracked list.
+	if len(toDelete) == 0 {
+		for _, u := range c.GetTrackedUsers() {
+			if defaultUserRe.MatchString(u) {
+				toDelete = append(toDelete, u)
+				if len(toDelete) == count {
+					break
+				}
+			}
+		}
+	}
+
+	if len(toDelete) == 0 {
+		log.Printf("ℹ️ No test users found to delete (pattern %q)", DefaultUserPattern)
+		return []string{}, map[string]FailureInfo{}
+	}
+
+	deleted, failed := c.cleanupUsers(ctx, toDelete, 0)
+	c.untrack(deleted)
 
 	// Log failures for visibility
 	if len(failed) > 0 {
-		for u, code := range failed {
-			log.Printf("⚠️ Failed to delete %s: status=%d", u, code)
+		for u, info := range failed {
+			log.Printf("⚠️ Failed to delete %s: status=%d after %d attempts", u, info.Status, info.Attempts)
 		}
 	}
 
 	return deleted, failed
 }
 
-// DeleteRandomTestUsersConcurrent selects up to `count` test users (username prefix "user_")
-// and deletes them concurrently using up to `concurrency` goroutines.
-func (c *Cleanup) DeleteRandomTestUsersConcurrent(ctx context.Context, count int, concurrency int) ([]string, map[string]int) {
+// DeleteRandomTestUsersConcurrent selects up to `count` test users
+// matching DefaultUserPattern via reservoir sampling over the paginated
+// dashboard listing, and deletes them concurrently using up to
+// `concurrency` goroutines via cleanupUsers' worker pool.
+func (c *Cleanup) DeleteRandomTestUsersConcurrent(ctx context.Context, count int, concurrency int) ([]string, map[string]FailureInfo) {
 	if count <= 0 {
-		return []string{}, map[string]int{}
+		return []string{}, map[string]FailureInfo{}
 	}
 
-	// Fetch dashboard similar to DeleteTestUsers
-	dashboardURL := c.config.Services.UserService.BaseURL + "/api/users/dashboard"
-	req, _ := http.NewRequestWithContext(ctx, "GET", dashboardURL, nil)
-	resp, err := c.client.Do(req)
-	if err != nil {
-		log.Printf("⚠️ Failed to fetch dashboard: %v", err)
-		return []string{}, map[string]int{}
-	}
-	defer resp.Body.Close()
+	toDelete := reservoirSample(ctx, c.ListTestUsers(ctx, DefaultUserPattern, 100), count)
 
-	var data map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		log.Printf("⚠️ Failed to decode dashboard response: %v", err)
-		return []string{}, map[string]int{}
-	}
-
-	candidates := make([]string, 0)
-	if ulist, ok := data["users"]; ok {
-		if arr, ok := ulist.([]interface{}); ok {
-			for _, v := range arr {
-				switch it := v.(type) {
-				case string:
-					if strings.HasPrefix(it, "user_") {
-						candidates = append(candidates, it)
-					}
-				case map[string]interface{}:
-					if uname, ok := it["username"].(string); ok {
-						if strings.HasPrefix(uname, "user_") {
-							candidates = append(candidates, uname)
-						}
-					}
+	// If the dashboard didn't return users, fall back to the tracked list.
+	if len(toDelete) == 0 {
+		for _, u := range c.GetTrackedUsers() {
+			if defaultUserRe.MatchString(u) {
+				toDelete = append(toDelete, u)
+				if len(toDelete) == count {
+					break
 				}
 			}
 		}
 	}
 
-	// fallback to tracked users
-	if len(candidates) == 0 {
-		for _, u := range c.users {
-			if strings.HasPrefix(u, "user_") {
-				candidates = append(candidates, u)
-			}
+	if len(toDelete) == 0 {
+		log.Printf("ℹ️ No test users found to delete (pattern %q)", DefaultUserPattern)
+		return []string{}, map[string]FailureInfo{}
+	}
+
+	deleted, failed := c.cleanupUsers(ctx, toDelete, concurrency)
+	c.untrack(deleted)
+
+	if len(failed) > 0 {
+		for u, info := range failed {
+			log.Printf("⚠️ Failed to delete %s: status=%d after %d attempts", u, info.Status, info.Attempts)
 		}
 	}
 
-	if len(candidates) == 0 {
-		log.Printf("ℹ️ No test users found to delete (prefix 'user_')")
-		return []string{}, map[string]int{}
+	return deleted, failed
+}
+
+// DeleteUser deletes a single test user by username, retrying through
+// deleteUserWithRetry. Returns (deleted, httpStatus).
+func (c *Cleanup) DeleteUser(ctx context.Context, username string) (bool, int) {
+	if username == "" || !defaultUserRe.MatchString(username) {
+		return false, http.StatusBadRequest
+	}
+
+	ok, info := c.deleteUserWithRetry(ctx, username)
+	if ok {
+		c.untrack([]string{username})
+		return true, info.Status
 	}
+	return false, info.Status
+}
 
-	// dedupe and shuffle
-	uniq := make([]string, 0)
-	seen := map[string]bool{}
-	for _, s := range candidates {
-		if !seen[s] {
-			seen[s] = true
-			uniq = append(uniq, s)
+func (c *Cleanup) cleanupChatMessagesFromUsers(ctx context.Context, users []string) int {
+	return c.chatCleaner.deleteByUsernames(ctx, users, c.concurrency)
+}
+
+func (c *Cleanup) cleanupPostsFromUsers(ctx context.Context, users []string) int {
+	return c.postsCleaner.deleteByUsernames(ctx, users, c.concurrency)
+}
+
+// resourceCleaner removes chat messages or posts authored by a set of
+// usernames, preferring a bulk endpoint ("POST .../<resource>:bulkDeleteByUsername")
+// when one is available and falling back to a concurrent worker pool
+// over a per-user index built from a single pass over the resource
+// listing. The listing is cached between passes and revalidated with
+// If-None-Match, since cleanup runs repeatedly against a listing that
+// rarely changes between passes.
+type resourceCleaner struct {
+	name      string // "chat" or "posts", used for metrics labels
+	client    *httpx.Client
+	listURL   string
+	bulkURL   string
+	bulkMode  string // "auto" (default), "always", "never"
+	deleteURL func(id string) string
+
+	mu            sync.Mutex
+	cachedEntries []map[string]interface{}
+	cachedETag    string
+	bulkSupported *bool
+}
+
+// deleteByUsernames removes every entry authored by one of users.
+func (rc *resourceCleaner) deleteByUsernames(ctx context.Context, users []string, concurrency int) int {
+	if len(users) == 0 {
+		return 0
+	}
+
+	start := time.Now()
+	if rc.supportsBulkDelete(ctx) {
+		n, err := rc.bulkDelete(ctx, users)
+		metrics.CleanupDeleteDuration.WithLabelValues(rc.name, "bulk").Observe(time.Since(start).Seconds())
+		if err == nil {
+			metrics.CleanupDeletesTotal.WithLabelValues(rc.name, "bulk", "success").Add(float64(n))
+			rc.invalidateCache()
+			if n > 0 {
+				log.Printf("✅ Bulk-deleted %d %s entries from selected users", n, rc.name)
+			}
+			return n
 		}
+		metrics.CleanupDeletesTotal.WithLabelValues(rc.name, "bulk", "failure").Inc()
+		log.Printf("⚠️ %s bulk delete failed, falling back to worker pool: %v", rc.name, err)
+	}
+
+	entries, err := rc.fetchEntries(ctx)
+	if err != nil {
+		log.Printf("⚠️ Failed to fetch %s listing: %v", rc.name, err)
+		return 0
 	}
-	rand.Shuffle(len(uniq), func(i, j int) { uniq[i], uniq[j] = uniq[j], uniq[i] })
 
-	toDelete := uniq
-	if count < len(uniq) {
-		toDelete = uniq[:count]
+	userSet := make(map[string]bool, len(users))
+	for _, u := range users {
+		userSet[u] = true
+	}
+	ids := make([]string, 0)
+	for _, e := range entries {
+		username, _ := e["username"].(string)
+		if !userSet[username] {
+			continue
+		}
+		if id, ok := e["id"]; ok {
+			ids = append(ids, fmt.Sprintf("%v", id))
+		}
+	}
+	if len(ids) == 0 {
+		return 0
 	}
 
 	if concurrency <= 0 {
 		concurrency = 5
 	}
-	if concurrency > len(toDelete) {
-		concurrency = len(toDelete)
+	if concurrency > len(ids) {
+		concurrency = len(ids)
 	}
 
-	// concurrent deletion using DeleteUser which also cleans tracked list on success
-	var mu sync.Mutex
-	deleted := make([]string, 0)
-	failed := make(map[string]int)
-
-	sem := make(chan struct{}, concurrency)
+	var deleted int64
 	var wg sync.WaitGroup
-	for _, u := range toDelete {
-		select {
-		case <-ctx.Done():
-			break
-		default:
-		}
+	sem := make(chan struct{}, concurrency)
+	for _, id := range ids {
 		wg.Add(1)
 		sem <- struct{}{}
-		go func(username string) {
+		go func(id string) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			d, code := c.DeleteUser(ctx, username)
-			mu.Lock()
-			defer mu.Unlock()
-			if d {
-				deleted = append(deleted, username)
+			req, _ := http.NewRequestWithContext(ctx, "DELETE", rc.deleteURL(id), nil)
+			resp, err := rc.client.Do(req)
+			if err != nil {
+				metrics.CleanupDeletesTotal.WithLabelValues(rc.name, "worker", "failure").Inc()
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent {
+				atomic.AddInt64(&deleted, 1)
+				metrics.CleanupDeletesTotal.WithLabelValues(rc.name, "worker", "success").Inc()
 			} else {
-				failed[username] = code
+				metrics.CleanupDeletesTotal.WithLabelValues(rc.name, "worker", "failure").Inc()
 			}
-		}(u)
+		}(id)
 	}
 	wg.Wait()
+	metrics.CleanupDeleteDuration.WithLabelValues(rc.name, "worker").Observe(time.Since(start).Seconds())
 
-	// Log failures for visibility
-	if len(failed) > 0 {
-		for u, code := range failed {
-			log.Printf("⚠️ Failed to delete %s: status=%d", u, code)
-		}
-	}
+	// The entries we just deleted make the cached listing stale; drop it
+	// so the next pass refetches instead of re-attempting dead ids.
+	rc.invalidateCache()
 
-	return deleted, failed
+	if deleted > 0 {
+		log.Printf("✅ Cleaned up %d %s entries from selected users", deleted, rc.name)
+	}
+	return int(deleted)
 }
 
-// DeleteUser deletes a single test user by username. Returns (deleted, httpStatus).
-func (c *Cleanup) DeleteUser(ctx context.Context, username string) (bool, int) {
-	if username == "" || !strings.HasPrefix(username, "user_") {
-		return false, http.StatusBadRequest
+// supportsBulkDelete reports whether the bulk endpoint should be used,
+// honoring an explicit bulkMode override or else feature-detecting via
+// OPTIONS and caching the result for the life of this resourceCleaner.
+func (rc *resourceCleaner) supportsBulkDelete(ctx context.Context) bool {
+	switch rc.bulkMode {
+	case "always":
+		return true
+	case "never":
+		return false
 	}
 
-	deleted, failed := c.cleanupUsers(ctx, []string{username})
-	if len(deleted) == 1 {
-		// remove from tracked list if present
-		remaining := make([]string, 0)
-		for _, u := range c.users {
-			if u != username {
-				remaining = append(remaining, u)
-			}
-		}
-		c.users = remaining
-		return true, http.StatusOK
+	rc.mu.Lock()
+	if rc.bulkSupported != nil {
+		v := *rc.bulkSupported
+		rc.mu.Unlock()
+		return v
 	}
-	if code, ok := failed[username]; ok {
-		return false, code
+	rc.mu.Unlock()
+
+	req, _ := http.NewRequestWithContext(ctx, "OPTIONS", rc.bulkURL, nil)
+	supported := false
+	if resp, err := rc.client.Do(req); err == nil {
+		resp.Body.Close()
+		supported = resp.StatusCode < 400 && strings.Contains(resp.Header.Get("Allow"), "POST")
 	}
-	return false, 0
+
+	rc.mu.Lock()
+	rc.bulkSupported = &supported
+	rc.mu.Unlock()
+	return supported
 }
 
-func (c *Cleanup) cleanupChatMessagesFromUsers(ctx context.Context, users []string) int {
-	// Get all messages
-	req, _ := http.NewRequestWithContext(ctx, "GET", c.config.Services.ChatService.BaseURL+"/api/messages", nil)
-	resp, err := c.client.Do(req)
+func (rc *resourceCleaner) bulkDelete(ctx context.Context, users []string) (int, error) {
+	body, err := json.Marshal(map[string][]string{"usernames": users})
 	if err != nil {
-		return 0
+		return 0, err
 	}
-	defer resp.Body.Close()
+	req, err := http.NewRequestWithContext(ctx, "POST", rc.bulkURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-	var messages []map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&messages)
-
-	deletedCount := 0
-	for _, message := range messages {
-		if username, ok := message["username"].(string); ok {
-			for _, targetUser := range users {
-				if username == targetUser {
-					// Delete this message
-					if id, ok := message["id"]; ok {
-						deleteReq, _ := http.NewRequestWithContext(ctx, "DELETE",
-							fmt.Sprintf("%s/api/messages/%v", c.config.Services.ChatService.BaseURL, id), nil)
-						deleteResp, err := c.client.Do(deleteReq)
-						if err == nil {
-							deleteResp.Body.Close()
-							deletedCount++
-						}
-					}
-					break
-				}
-			}
-		}
+	resp, err := rc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return 0, fmt.Errorf("bulk delete returned status %d", resp.StatusCode)
 	}
 
-	if deletedCount > 0 {
-		log.Printf("✅ Cleaned up %d chat messages from selected users", deletedCount)
+	var result struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Deleted > 0 {
+		return result.Deleted, nil
 	}
-	return deletedCount
+	return len(users), nil
 }
 
-func (c *Cleanup) cleanupPostsFromUsers(ctx context.Context, users []string) int {
-	// Get all posts
-	req, _ := http.NewRequestWithContext(ctx, "GET", c.config.Services.PostsService.BaseURL+"/api/posts", nil)
-	resp, err := c.client.Do(req)
+// fetchEntries returns the resource listing, reusing the cached copy
+// when the server responds 304 Not Modified to an If-None-Match replay
+// of the last ETag seen.
+func (rc *resourceCleaner) fetchEntries(ctx context.Context) ([]map[string]interface{}, error) {
+	rc.mu.Lock()
+	etag := rc.cachedETag
+	rc.mu.Unlock()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", rc.listURL, nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := rc.client.Do(req)
 	if err != nil {
-		return 0
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var posts []map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&posts)
-
-	deletedCount := 0
-	for _, post := range posts {
-		if username, ok := post["username"].(string); ok {
-			for _, targetUser := range users {
-				if username == targetUser {
-					// Delete this post
-					if id, ok := post["id"]; ok {
-						deleteReq, _ := http.NewRequestWithContext(ctx, "DELETE",
-							fmt.Sprintf("%s/api/posts/%v", c.config.Services.PostsService.BaseURL, id), nil)
-						deleteResp, err := c.client.Do(deleteReq)
-						if err == nil {
-							deleteResp.Body.Close()
-							deletedCount++
-						}
-					}
-					break
-				}
-			}
-		}
+	if resp.StatusCode == http.StatusNotModified {
+		rc.mu.Lock()
+		cached := rc.cachedEntries
+		rc.mu.Unlock()
+		return cached, nil
 	}
 
-	if deletedCount > 0 {
-		log.Printf("✅ Cleaned up %d posts from selected users", deletedCount)
+	var entries []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decoding %s listing: %w", rc.name, err)
 	}
-	return deletedCount
+
+	rc.mu.Lock()
+	rc.cachedEntries = entries
+	rc.cachedETag = resp.Header.Get("ETag")
+	rc.mu.Unlock()
+	return entries, nil
+}
+
+func (rc *resourceCleaner) invalidateCache() {
+	rc.mu.Lock()
+	rc.cachedEntries = nil
+	rc.cachedETag = ""
+	rc.mu.Unlock()
 }
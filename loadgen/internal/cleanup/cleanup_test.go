@@ -1,83 +1,233 @@
 package cleanup
 
 import (
-    "context"
-    "encoding/json"
-    "net/http"
-    "net/http/httptest"
-    "testing"
-    "time"
-
-    "loadgen/internal/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"loadgen/internal/config"
 )
 
 func TestDeleteTestUsers_PartialFailures(t *testing.T) {
-    // Setup a test server that simulates the user-service
-    mux := http.NewServeMux()
-    mux.HandleFunc("/api/users/dashboard", func(w http.ResponseWriter, r *http.Request) {
-        resp := map[string]interface{}{"users": []string{"user_1", "user_2", "bob", "user_3"}}
-        w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(resp)
-    })
-
-    mux.HandleFunc("/api/users/user_1", func(w http.ResponseWriter, r *http.Request) {
-        if r.Method == "DELETE" {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(200)
-            json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_1"})
-            return
-        }
-        http.NotFound(w, r)
-    })
-
-    mux.HandleFunc("/api/users/user_2", func(w http.ResponseWriter, r *http.Request) {
-        if r.Method == "DELETE" {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(500)
-            json.NewEncoder(w).Encode(map[string]interface{}{"error": "internal"})
-            return
-        }
-        http.NotFound(w, r)
-    })
-
-    mux.HandleFunc("/api/users/user_3", func(w http.ResponseWriter, r *http.Request) {
-        if r.Method == "DELETE" {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(200)
-            json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_3"})
-            return
-        }
-        http.NotFound(w, r)
-    })
-
-    ts := httptest.NewServer(mux)
-    defer ts.Close()
-
-    cfg := &config.Config{}
-    cfg.Services.UserService.BaseURL = ts.URL
-
-    c := New(cfg)
-
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-
-    deleted, failed := c.DeleteTestUsers(ctx, 10)
-
-    // Check that user_1 and user_3 are in deleted, and user_2 is in failed
-    found1, found3 := false, false
-    for _, d := range deleted {
-        if d == "user_1" {
-            found1 = true
-        }
-        if d == "user_3" {
-            found3 = true
-        }
-    }
-    if !found1 || !found3 {
-        t.Fatalf("expected user_1 and user_3 to be deleted, got deleted=%v", deleted)
-    }
-
-    if code, ok := failed["user_2"]; !ok || code != 500 {
-        t.Fatalf("expected user_2 to fail with 500, got failed=%v", failed)
-    }
+	// Setup a test server that simulates the user-service
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]interface{}{"users": []string{"user_1", "user_2", "bob", "user_3"}}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	mux.HandleFunc("/api/users/user_1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(200)
+			json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_1"})
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	mux.HandleFunc("/api/users/user_2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(500)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "internal"})
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	mux.HandleFunc("/api/users/user_3", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(200)
+			json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_3"})
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg := &config.Config{}
+	cfg.Services.UserService.BaseURL = ts.URL
+
+	c := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deleted, failed := c.DeleteTestUsers(ctx, 10)
+
+	// Check that user_1 and user_3 are in deleted, and user_2 is in failed
+	found1, found3 := false, false
+	for _, d := range deleted {
+		if d == "user_1" {
+			found1 = true
+		}
+		if d == "user_3" {
+			found3 = true
+		}
+	}
+	if !found1 || !found3 {
+		t.Fatalf("expected user_1 and user_3 to be deleted, got deleted=%v", deleted)
+	}
+
+	// user_2 always returns 500, so every retry exhausts and it still
+	// ends up failed - but now with an attempt count attached.
+	if info, ok := failed["user_2"]; !ok || info.Status != 500 {
+		t.Fatalf("expected user_2 to fail with 500, got failed=%v", failed)
+	}
+	if info := failed["user_2"]; info.Attempts != deleteRetryMaxTries {
+		t.Fatalf("expected user_2 to be retried %d times, got %d", deleteRetryMaxTries, info.Attempts)
+	}
+}
+
+// TestDeleteTestUsers_RetryPromotesFlakyUserToDeleted checks that a user
+// whose delete only succeeds after a couple of 500s ends up in deleted,
+// not failed - the whole point of cleanupUsers retrying 5xx responses.
+func TestDeleteTestUsers_RetryPromotesFlakyUserToDeleted(t *testing.T) {
+	var attempts int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]interface{}{"users": []string{"user_1"}}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/api/users/user_1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(500)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "internal"})
+			return
+		}
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_1"})
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg := &config.Config{}
+	cfg.Services.UserService.BaseURL = ts.URL
+	c := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	deleted, failed := c.DeleteTestUsers(ctx, 10)
+
+	if len(deleted) != 1 || deleted[0] != "user_1" {
+		t.Fatalf("expected user_1 to be deleted after retries, got deleted=%v failed=%v", deleted, failed)
+	}
+	if len(failed) != 0 {
+		t.Fatalf("expected no failures, got %v", failed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected exactly 3 attempts, got %d", got)
+	}
+}
+
+// TestDeleteTestUsers_NeverDeletesNonMatchingUsername checks that a
+// non-test account like "bob" returned by the dashboard is never handed
+// to the user-service's DELETE endpoint.
+func TestDeleteTestUsers_NeverDeletesNonMatchingUsername(t *testing.T) {
+	var bobDeleteCalled int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/users/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		resp := map[string]interface{}{"users": []string{"bob", "user_1"}}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/api/users/bob", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&bobDeleteCalled, 1)
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "bob"})
+	})
+	mux.HandleFunc("/api/users/user_1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(200)
+			json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true, "username": "user_1"})
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg := &config.Config{}
+	cfg.Services.UserService.BaseURL = ts.URL
+	c := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deleted, _ := c.DeleteTestUsers(ctx, 10)
+
+	for _, d := range deleted {
+		if d == "bob" {
+			t.Fatalf("expected bob to never be deleted, got deleted=%v", deleted)
+		}
+	}
+	if atomic.LoadInt32(&bobDeleteCalled) != 0 {
+		t.Fatalf("expected DELETE /api/users/bob to never be called, got called %d time(s)", bobDeleteCalled)
+	}
+}
+
+// TestCleanupUsers_WorkerPoolRunsConcurrently checks that cleanupUsers
+// actually overlaps deletes instead of running them one at a time - each
+// handler blocks briefly and records how many were in flight at once.
+func TestCleanupUsers_WorkerPoolRunsConcurrently(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(200)
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": true})
+	}
+	for _, u := range []string{"user_1", "user_2", "user_3", "user_4"} {
+		mux.HandleFunc("/api/users/"+u, handler)
+	}
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	cfg := &config.Config{}
+	cfg.Services.UserService.BaseURL = ts.URL
+	c := New(cfg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deleted, failed := c.cleanupUsers(ctx, []string{"user_1", "user_2", "user_3", "user_4"}, 4)
+	if len(deleted) != 4 || len(failed) != 0 {
+		t.Fatalf("expected all 4 users deleted, got deleted=%v failed=%v", deleted, failed)
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got < 2 {
+		t.Fatalf("expected deletes to overlap (max in flight >= 2), got max in flight = %d", got)
+	}
 }
@@ -0,0 +1,51 @@
+// Package clock wraps time.Now for duration math behind a Clock
+// interface, the same wrapper shape cockroachdb/cockroach's util/timeutil
+// package uses: production code takes a Clock instead of calling time.Now
+// directly, so a test can substitute a Fake and advance it deterministically
+// instead of sleeping real wall-clock time.
+package clock
+
+import "time"
+
+// Clock is the sliver of the time package callers need for timestamping
+// and duration math. Now's result is only ever used for display and for
+// feeding back into Since/Sub - Go's time.Time already carries a
+// monotonic reading alongside its wall clock one precisely so that Sub
+// and Since aren't affected by NTP stepping the wall clock, which is what
+// makes a single Now() value safe to use for both.
+type Clock interface {
+	// Now returns the current time, wall-clock for display and carrying a
+	// monotonic reading for Since/Sub.
+	Now() time.Time
+	// Since returns the monotonic elapsed time since t (t must have come
+	// from this Clock's Now).
+	Since(t time.Time) time.Duration
+	// NewTimer behaves like time.NewTimer against this Clock's notion of
+	// time, firing once d has elapsed.
+	NewTimer(d time.Duration) *Timer
+}
+
+// Timer mirrors the subset of *time.Timer callers need: reading C and
+// stopping early. It's its own type, not *time.Timer, because Fake's
+// timers fire off a simulated clock with no real-time goroutine behind
+// them at all.
+type Timer struct {
+	C    <-chan time.Time
+	stop func() bool
+}
+
+// Stop prevents t from firing, returning false if it already fired or was
+// already stopped - the same contract as (*time.Timer).Stop.
+func (t *Timer) Stop() bool { return t.stop() }
+
+// Real is the production Clock, a thin pass-through to the time package.
+// Its zero value is ready to use.
+type Real struct{}
+
+func (Real) Now() time.Time                  { return time.Now() }
+func (Real) Since(t time.Time) time.Duration { return time.Since(t) }
+
+func (Real) NewTimer(d time.Duration) *Timer {
+	rt := time.NewTimer(d)
+	return &Timer{C: rt.C, stop: rt.Stop}
+}
@@ -0,0 +1,90 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock a test fully controls: it never advances on its own,
+// only when the test calls Advance, and every Timer it hands out fires
+// (synchronously, from within Advance) exactly when the simulated clock
+// crosses that timer's deadline.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFake returns a Fake whose clock starts at start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// Advance moves the Fake's clock forward by d, firing (in fireAt order)
+// every Timer whose deadline is now at or before the new time.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	now := f.now
+
+	var due []*fakeTimer
+	remaining := f.timers[:0]
+	for _, t := range f.timers {
+		if !now.Before(t.fireAt) {
+			due = append(due, t)
+		} else {
+			remaining = append(remaining, t)
+		}
+	}
+	f.timers = remaining
+	f.mu.Unlock()
+
+	for _, t := range due {
+		t.fire(now)
+	}
+}
+
+func (f *Fake) NewTimer(d time.Duration) *Timer {
+	f.mu.Lock()
+	ft := &fakeTimer{fireAt: f.now.Add(d), c: make(chan time.Time, 1)}
+	f.timers = append(f.timers, ft)
+	f.mu.Unlock()
+
+	return &Timer{C: ft.c, stop: func() bool { return f.stopTimer(ft) }}
+}
+
+// stopTimer removes ft from f.timers if it hasn't fired yet, returning
+// whether it was still pending - the same semantics as (*time.Timer).Stop.
+func (f *Fake) stopTimer(ft *fakeTimer) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, t := range f.timers {
+		if t == ft {
+			f.timers = append(f.timers[:i], f.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTimer struct {
+	fireAt time.Time
+	c      chan time.Time
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	select {
+	case t.c <- now:
+	default:
+	}
+}
@@ -1,17 +1,80 @@
 package config
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
 	"strconv"
 )
 
 type Config struct {
-	Services    Services `yaml:"services"`
-	Tracing     Tracing  `yaml:"tracing"`
-	Chaos       Chaos    `yaml:"chaos"`
-	WebPort     string   `yaml:"web_port"`
-	MetricsPort string   `yaml:"metrics_port"`
+	Services        Services         `yaml:"services"`
+	Tracing         Tracing          `yaml:"tracing"`
+	Chaos           Chaos            `yaml:"chaos"`
+	Scenarios       []Scenario       `yaml:"scenarios"`
+	SessionProfiles []SessionProfile `yaml:"session_profiles"`
+	ActionMarkov    ActionMarkov     `yaml:"action_markov"`
+	Logging         Logging          `yaml:"logging"`
+	Cleanup         Cleanup          `yaml:"cleanup"`
+	Metrics         Metrics          `yaml:"metrics"`
+	Reports         Reports          `yaml:"reports"`
+	Generator       Generator        `yaml:"generator"`
+	Schedules       Schedules        `yaml:"schedules"`
+	WebPort         string           `yaml:"web_port"`
+	MetricsPort     string           `yaml:"metrics_port"`
+}
+
+// Schedules selects internal/scheduler's backend for persisted
+// ScheduledTests, mirroring Reports' Backend/DSN shape.
+type Schedules struct {
+	Backend string `yaml:"backend"`
+	DSN     string `yaml:"dsn"`
+}
+
+// Generator configures internal/generator.NewClient's HTTP client
+// interceptor chain: the named, composable behaviors (redirect
+// following, cookie jar, decompression, response caching, retry,
+// logging) a simulated user's requests pass through on their way to the
+// base transport, mirroring how config.Chaos.Interceptors selects and
+// orders ChaosMiddleware's fault chain.
+type Generator struct {
+	// Interceptors names the chain, in order, e.g.
+	// ["redirect","cookies","gzip","cache","retry"]. Unset keeps
+	// NewClient's default full chain; unknown names are skipped.
+	Interceptors []string `yaml:"interceptors,omitempty"`
+
+	// MaxRedirects caps the "redirect" interceptor's hops before it gives
+	// up. Defaults to 10 if <= 0.
+	MaxRedirects int `yaml:"max_redirects"`
+	// CacheTTL is the fallback TTL the "cache" interceptor uses for a
+	// response whose Cache-Control has no max-age, e.g. "30s". Zero/unset
+	// means such responses aren't cached.
+	CacheTTL string `yaml:"cache_ttl"`
+	// RetryMax caps the "retry" interceptor's attempts per request.
+	// Defaults to 3 if <= 0.
+	RetryMax int `yaml:"retry_max"`
+	// RetryBaseDelay is the "retry" interceptor's exponential backoff
+	// base, e.g. "100ms". Defaults to 100ms if unset/invalid.
+	RetryBaseDelay string `yaml:"retry_base_delay"`
+}
+
+// Reports selects internal/reportstore's backend for internal/web's test
+// reports. Backend is one of "memory" (default), "sqlite", "postgres", or
+// "jsonl"; DSN is that backend's database/file path or connection string
+// (ignored for memory).
+type Reports struct {
+	Backend string `yaml:"backend"`
+	DSN     string `yaml:"dsn"`
+}
+
+// Metrics bounds internal/web's in-process time-series ring buffer (see
+// internal/query.Store), which backs the control panel's /api/query and
+// /api/query_range endpoints.
+type Metrics struct {
+	// RetentionPoints caps how many samples each ring buffer series
+	// keeps, oldest dropped first. Defaults to 1800 (30 minutes at the
+	// 1-sample/sec collection rate internal/web uses) if <= 0.
+	RetentionPoints int `yaml:"retention_points"`
 }
 
 type Services struct {
@@ -22,19 +85,250 @@ type Services struct {
 }
 
 type Service struct {
-	BaseURL string `yaml:"base_url"`
-	Timeout string `yaml:"timeout"`
+	BaseURL    string     `yaml:"base_url"`
+	Timeout    string     `yaml:"timeout"`
+	Resilience Resilience `yaml:"resilience"`
+	// TLS configures mTLS for this service's outbound connections (see
+	// internal/httpx.NewHTTPClient). Zero value disables it entirely.
+	TLS TLS `yaml:"tls"`
+	// Auth selects this service's internal/httpx.AuthProvider. Zero value
+	// means no gateway-level auth beyond whatever per-user token the
+	// relevant behaviors.*Behavior already attaches.
+	Auth ServiceAuth `yaml:"auth"`
+}
+
+// TLS configures mTLS for one Service's outbound connections, consumed by
+// internal/httpx.NewHTTPClient. CAFile/CertFile/KeyFile are PEM file
+// paths; leaving all of them (and ServerName, InsecureSkipVerify) unset
+// disables mTLS entirely and falls back to a plain TLS dial against the
+// system certificate pool. ServerName overrides SNI/hostname
+// verification, e.g. when BaseURL is a raw IP behind a gateway.
+// InsecureSkipVerify is for dev/staging environments with self-signed
+// certs - never enable it against a production target.
+type TLS struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// ServiceAuth selects internal/httpx's AuthProvider for a Service's
+// outbound requests. This is layered on top of, not instead of, the
+// per-simulated-user bearer token behaviors.AuthBehavior.Login already
+// attaches by hand in each behaviors.*Behavior call - it's for
+// authenticating the load generator itself to infrastructure in front of
+// the service (an mTLS gateway requiring a static API key, or an OAuth2
+// client-credentials token) that has nothing to do with the app's own
+// user sessions. Type is one of "" or "password" (default: no extra
+// auth - the existing per-user flow needs nothing here), "api_key", or
+// "oauth2_client_credentials" (the latter sets its own
+// X-Gateway-Authorization header, not Authorization, specifically so it
+// doesn't clobber the per-user token).
+type ServiceAuth struct {
+	Type string `yaml:"type"`
+
+	// APIKeyHeader/APIKeyValue are used when Type == "api_key", e.g.
+	// header "X-Api-Key". Don't point APIKeyHeader at "Authorization" -
+	// that's where the per-user bearer token already lives.
+	APIKeyHeader string `yaml:"api_key_header"`
+	APIKeyValue  string `yaml:"api_key_value"`
+
+	// TokenURL/ClientID/ClientSecret/Scopes are used when
+	// Type == "oauth2_client_credentials".
+	TokenURL     string   `yaml:"token_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	Scopes       []string `yaml:"scopes,omitempty"`
+}
+
+// Resilience configures internal/httpx's client-side protections for
+// requests to one downstream service: a token-bucket rate limit, retry
+// policy, and circuit breaker. Defaults (see httpx.New) keep the load
+// generator from hammering a service that's already degraded, without
+// requiring every Service entry to set these explicitly.
+type Resilience struct {
+	RatePerSec        float64 `yaml:"rate_per_sec"`
+	Burst             int     `yaml:"burst"`
+	MaxRetries        int     `yaml:"max_retries"`
+	RetryBaseDelay    string  `yaml:"retry_base_delay"`    // e.g. "100ms"
+	RetryMaxDelay     string  `yaml:"retry_max_delay"`     // backoff cap, e.g. "5s"
+	BreakerErrorRatio float64 `yaml:"breaker_error_ratio"` // fraction of failures in a window that trips the breaker
+	BreakerWindow     int     `yaml:"breaker_window"`      // requests considered per rolling window
+	BreakerCooldown   string  `yaml:"breaker_cooldown"`    // how long the breaker stays open before a half-open trial
 }
 
 type Tracing struct {
-	Endpoint string `yaml:"endpoint"`
-	Enabled  bool   `yaml:"enabled"`
+	Endpoint     string            `yaml:"endpoint"`
+	Enabled      bool              `yaml:"enabled"`
+	Protocol     string            `yaml:"protocol"` // otlp-http, otlp-grpc, jaeger
+	Headers      map[string]string `yaml:"headers"`  // e.g. auth for hosted collectors
+	SamplerRatio float64           `yaml:"sampler_ratio"`
 }
 
 type Chaos struct {
-	ErrorRate  float64 `yaml:"error_rate"`
-	DelayRate  float64 `yaml:"delay_rate"`
-	MaxDelayMs int     `yaml:"max_delay_ms"`
+	ErrorRate     float64     `yaml:"error_rate"`
+	DelayRate     float64     `yaml:"delay_rate"`
+	MaxDelayMs    int         `yaml:"max_delay_ms"`
+	RatePerSec    float64     `yaml:"rate_per_sec"` // token-bucket refill rate bounding fault injection
+	Burst         int         `yaml:"burst"`
+	Rules         []ChaosRule `yaml:"rules"`
+	Interceptors  []string    `yaml:"interceptors,omitempty"`  // chain order/subset by name, e.g. ["delay","status"]; unset keeps the default full chain
+	Seed          int64       `yaml:"seed,omitempty"`          // seeds the middleware's RNG; unset seeds from the current time
+	Deterministic bool        `yaml:"deterministic,omitempty"` // derive each request's fault RNG from a hash of method+path+X-Request-ID instead of the shared seeded RNG, so replaying the same traffic injects the same faults
+}
+
+// ChaosRule scopes fault injection to requests matching Service, an HTTP
+// Method, and a regex over the request path, overriding the top-level
+// rates when it matches. Method is blank by default, matching every verb.
+type ChaosRule struct {
+	ID                  string          `yaml:"id,omitempty" json:"id,omitempty"`
+	Service             string          `yaml:"service"`
+	Method              string          `yaml:"method,omitempty" json:"method,omitempty"`
+	PathPattern         string          `yaml:"path_pattern"`
+	DelayRate           float64         `yaml:"delay_rate"`
+	MeanDelayMs         float64         `yaml:"mean_delay_ms"` // exponential distribution mean
+	MinDelayMs          float64         `yaml:"min_delay_ms,omitempty" json:"min_delay_ms,omitempty"`
+	MaxDelayMs          float64         `yaml:"max_delay_ms,omitempty" json:"max_delay_ms,omitempty"` // with MinDelayMs, a uniform range instead of MeanDelayMs's exponential draw
+	ErrorRate           float64         `yaml:"error_rate"`
+	Status              int             `yaml:"status,omitempty" json:"status,omitempty"` // single status code; takes priority over StatusWeights when set
+	ErrorBody           string          `yaml:"error_body,omitempty" json:"error_body,omitempty"`
+	StatusWeights       map[int]float64 `yaml:"status_weights"`
+	ResetRate           float64         `yaml:"reset_rate"`
+	TruncateRate        float64         `yaml:"truncate_rate"`
+	DNSFailRate         float64         `yaml:"dns_fail_rate"`          // fraction of requests failed before dialing, as a DNS resolution failure
+	CorruptRate         float64         `yaml:"corrupt_rate"`           // fraction of request bodies with a single byte flipped in transit
+	ThrottleBytesPerSec float64         `yaml:"throttle_bytes_per_sec"` // caps response body read rate when set, simulating a slow link
+}
+
+// Scenario describes one weighted virtual-user journey: a named, ordered
+// list of behavior invocations (e.g. "auth.login", "posts.create") replayed
+// in a loop by each virtual user assigned to it, with think time between
+// steps and its own ramp profile controlling how many users run it and how
+// fast that pool grows. Scenarios that omit target_users share a single
+// ramp pool (sized by whichever scenario does specify one) and split it by
+// Weight, so "70% login+post, 30% chat" can be expressed without hand
+// computing per-scenario user counts.
+type Scenario struct {
+	Name      string    `yaml:"name"`
+	Weight    float64   `yaml:"weight"`
+	ThinkTime ThinkTime `yaml:"think_time"`
+	Ramp      Ramp      `yaml:"ramp"`
+	Steps     []string  `yaml:"steps"`
+}
+
+// ThinkTime bounds the pause between a scenario's steps. Distribution is
+// one of "constant" (always Min), "uniform" (even spread between Min and
+// Max), "exponential" (mean at the Min/Max midpoint, long tail toward
+// Max), or "log-normal" (mean at the midpoint like exponential, but with
+// a thinner tail - a closer fit to human-paced browsing/chatting think
+// time than a pure exponential) - mirroring the latency-injection
+// distribution used by the chaos package.
+type ThinkTime struct {
+	Min          string `yaml:"min"`
+	Max          string `yaml:"max"`
+	Distribution string `yaml:"distribution"`
+}
+
+// Ramp controls how a scenario's virtual-user pool grows over time.
+// Type is one of "linear" (steady trickle), "step" (four equal batches),
+// or "spike" (all users at once).
+type Ramp struct {
+	Type        string `yaml:"type"`
+	TargetUsers int    `yaml:"target_users"`
+	Duration    string `yaml:"duration"`
+}
+
+// SessionProfile describes a virtual-user journey as a Markov chain
+// instead of Scenario's fixed, looping Steps list: each SessionState has
+// weighted Transitions to possible next states, so behavior like
+// "LoggedOut -> Browsing -> Posting -> Chatting -> Liking -> Idle ->
+// LoggedOut" can branch and loop with realistic probabilities rather
+// than replaying one linear script. Compiled and driven by
+// internal/behaviors/session; see session.Session.ApplyProfile for how
+// these hot-reload without restarting the load generator.
+type SessionProfile struct {
+	Name         string         `yaml:"name"`
+	InitialState string         `yaml:"initial_state"`
+	States       []SessionState `yaml:"states"`
+}
+
+// SessionState is one node in a SessionProfile's Markov chain. Step names
+// the behavior invocation to perform on entering this state, using the
+// same "behavior.action" names as Scenario.Steps (e.g. "posts.create");
+// an empty Step performs no action, letting purely transitional states
+// like "Idle" exist. ThinkTime bounds the pause before transitioning.
+type SessionState struct {
+	Name        string              `yaml:"name"`
+	Step        string              `yaml:"step"`
+	ThinkTime   ThinkTime           `yaml:"think_time"`
+	Transitions []SessionTransition `yaml:"transitions"`
+}
+
+// SessionTransition is one weighted edge out of a SessionState. The next
+// state is chosen by weighted random selection among a state's
+// Transitions whose Guard passes (see session.evalGuard for the
+// supported expressions), the same weighting pattern used for Scenario.
+type SessionTransition struct {
+	To     string  `yaml:"to"`
+	Weight float64 `yaml:"weight"`
+	// Guard, if set, excludes this transition from selection unless it
+	// evaluates true against the vuser's current state - e.g.
+	// "has_token", "!has_token", "cycle_count % 4 == 0", or
+	// "service_used.chat == false". Empty always passes.
+	Guard string `yaml:"guard,omitempty"`
+}
+
+// ActionMarkov configures user.User's legacy (non-session-profile) action
+// loop as a first-order Markov chain over its seven actions ("viewPosts",
+// "createPost", "likeRandomPost", "sendChatMessage", "readChatMessages",
+// "updateProfile", "viewProfile"), replacing selectActionWeighted's
+// independent per-tick weighted picker. Matrix[lastAction] is the row of
+// weights for the next action given lastAction, walked the same
+// cumulative-weight way Scenario and SessionTransition already are; an
+// entry missing from a present row is weight 0, but a row entirely
+// missing from Matrix falls back to Initial instead (there's no
+// transition data for that action yet, so the chain restarts from the
+// stationary distribution rather than dead-ending). Initial also seeds
+// the very first pick, before there's a lastAction. An empty Initial
+// means no Markov chain is configured, so the legacy loop falls back to
+// its prior memoryless behavior.
+type ActionMarkov struct {
+	Initial map[string]float64            `yaml:"initial"`
+	Matrix  map[string]map[string]float64 `yaml:"matrix"`
+}
+
+// Logging configures the process-wide structured logger (see
+// internal/logging). Sampling drops that fraction of successful-request
+// log records to bound volume under high load; failures are always logged.
+type Logging struct {
+	Level    string  `yaml:"level"`
+	Format   string  `yaml:"format"` // json|text
+	Sampling float64 `yaml:"sampling"`
+}
+
+// Cleanup configures internal/cleanup's tracked-users persistence and
+// shutdown behavior. StateFile, if set, is where the tracked-users list
+// is saved after every create/delete and reloaded from on startup, so a
+// crashed or restarted loadgen instance can still clean up the accounts
+// it created rather than orphaning them. OnExit is the config-driven
+// equivalent of a "--cleanup-on-exit" flag: there's no cmd entrypoint in
+// this repo yet to parse one, so it's read from CLEANUP_ON_EXIT until a
+// main package exists to wire an actual flag to it.
+type Cleanup struct {
+	StateFile     string `yaml:"state_file"`
+	OnExit        bool   `yaml:"cleanup_on_exit"`
+	ShutdownGrace string `yaml:"shutdown_grace"` // e.g. "30s"
+
+	// Concurrency bounds the worker pool used to delete chat messages/
+	// posts when the target service doesn't support bulk deletion.
+	// Defaults to 5 if <= 0.
+	Concurrency int `yaml:"concurrency"`
+	// BulkDelete is one of "auto" (default: feature-detect the
+	// :bulkDeleteByUsername endpoint via OPTIONS and cache the result),
+	// "always" (assume it exists), or "never" (always use the worker
+	// pool fallback).
+	BulkDelete string `yaml:"bulk_delete"`
 }
 
 func Load(path string) (*Config, error) {
@@ -47,14 +341,20 @@ func Load(path string) (*Config, error) {
 			ProfileService: Service{BaseURL: "http://localhost:8081", Timeout: "10s"},
 		},
 		Tracing: Tracing{
-			Endpoint: "",
-			Enabled:  false,
+			Endpoint:     "",
+			Enabled:      false,
+			Protocol:     "otlp-http",
+			SamplerRatio: 1.0,
 		},
 		Chaos: Chaos{
 			ErrorRate:  0.1,
 			DelayRate:  0.15,
 			MaxDelayMs: 1000,
 		},
+		Logging: Logging{
+			Level:  "info",
+			Format: "json",
+		},
 		WebPort:     "3002",
 		MetricsPort: "9090",
 	}
@@ -62,8 +362,11 @@ func Load(path string) (*Config, error) {
 	// Load from YAML if exists
 	if _, err := os.Stat(path); err == nil {
 		data, err := os.ReadFile(path)
-		if err == nil {
-			yaml.Unmarshal(data, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("reading config %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config %s: %w", path, err)
 		}
 	}
 
@@ -107,6 +410,17 @@ func Load(path string) (*Config, error) {
 	if port := os.Getenv("METRICS_PORT"); port != "" {
 		cfg.MetricsPort = port
 	}
+	if path := os.Getenv("CLEANUP_STATE_FILE"); path != "" {
+		cfg.Cleanup.StateFile = path
+	}
+	if onExit := os.Getenv("CLEANUP_ON_EXIT"); onExit != "" {
+		if b, err := strconv.ParseBool(onExit); err == nil {
+			cfg.Cleanup.OnExit = b
+		}
+	}
+	if grace := os.Getenv("CLEANUP_SHUTDOWN_GRACE"); grace != "" {
+		cfg.Cleanup.ShutdownGrace = grace
+	}
 
 	return cfg, nil
 }
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher re-parses a config file on SIGHUP or filesystem change and
+// publishes each successfully reloaded Config to subscribers, so tunables
+// like chaos rates, service base URLs, and timeouts can be dialed in
+// without a process restart.
+type Watcher struct {
+	path string
+
+	mu   sync.Mutex
+	subs []chan *Config
+
+	fsWatcher *fsnotify.Watcher
+}
+
+// NewWatcher starts watching path for changes. If the filesystem can't be
+// watched (e.g. fsnotify unavailable or path not watchable), it falls back
+// to SIGHUP-only reload rather than failing outright.
+func NewWatcher(path string) *Watcher {
+	w := &Watcher{path: path}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("config: fsnotify unavailable (%v), falling back to SIGHUP-only reload", err)
+	} else if err := fw.Add(path); err != nil {
+		log.Printf("config: failed to watch %s (%v), falling back to SIGHUP-only reload", path, err)
+		fw.Close()
+		fw = nil
+	}
+	w.fsWatcher = fw
+
+	go w.run()
+	return w
+}
+
+// Subscribe returns a channel that receives every Config successfully
+// reloaded after this call. The channel is buffered by one; a subscriber
+// that hasn't drained the previous update loses it in favor of the latest,
+// since only the current config ever matters to a live consumer.
+func (w *Watcher) Subscribe() <-chan *Config {
+	ch := make(chan *Config, 1)
+	w.mu.Lock()
+	w.subs = append(w.subs, ch)
+	w.mu.Unlock()
+	return ch
+}
+
+func (w *Watcher) run() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	var fsEvents <-chan fsnotify.Event
+	var fsErrors <-chan error
+	if w.fsWatcher != nil {
+		fsEvents = w.fsWatcher.Events
+		fsErrors = w.fsWatcher.Errors
+	}
+
+	for {
+		select {
+		case <-sighup:
+			w.reload("SIGHUP")
+
+		case ev, ok := <-fsEvents:
+			if !ok {
+				fsEvents = nil
+				continue
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				w.reload("file change: " + ev.Name)
+			}
+
+		case err, ok := <-fsErrors:
+			if !ok {
+				fsErrors = nil
+				continue
+			}
+			log.Printf("config: watcher error: %v", err)
+		}
+	}
+}
+
+func (w *Watcher) reload(reason string) {
+	cfg, err := Load(w.path)
+	if err != nil {
+		log.Printf("config: reload from %s failed (%s): %v", w.path, reason, err)
+		return
+	}
+	log.Printf("config: reloaded from %s (%s)", w.path, reason)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subs {
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- cfg:
+		default:
+		}
+	}
+}
+
+// Close stops the underlying filesystem watcher, if one was started.
+// SIGHUP handling stays installed for the life of the process since it's
+// global and cheap to leave in place.
+func (w *Watcher) Close() error {
+	if w.fsWatcher != nil {
+		return w.fsWatcher.Close()
+	}
+	return nil
+}
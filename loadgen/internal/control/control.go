@@ -0,0 +1,231 @@
+// Package control implements the logic behind proto/control.proto's
+// LoadgenControl service - StartRun/StopRun/ScaleUsers/GetStats/
+// TriggerCleanup/StreamEvents - against plain Go request/response types,
+// the same "write the logic against hand-rolled mirror types now, swap
+// in generated ones once buf can run" split internal/coordinator and
+// internal/agent already use for proto/loadgen.proto (see
+// proto/loadgenpb's doc comment for why nothing is generated yet).
+//
+// StartRun/StopRun/ScaleUsers/StreamEvents all operate on whichever test
+// is currently running, state that today lives inside
+// internal/web.WebServer. Rather than reach into WebServer's internals
+// (or have this package import internal/web, which already imports
+// internal/cleanup and would need to import control back), Controller
+// depends on a small Hooks interface it expects its caller to satisfy -
+// the same inversion internal/cleanup.Observer already uses so Cleanup
+// doesn't need to import internal/web either.
+package control
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"loadgen/internal/cleanup"
+)
+
+// StartRunRequest mirrors control.proto's StartRunRequest.
+type StartRunRequest struct {
+	Users           int
+	Duration        string
+	Ramp            string
+	WorkloadProfile string
+	Labels          map[string]string
+}
+
+// ScaleUsersRequest mirrors control.proto's ScaleUsersRequest.
+type ScaleUsersRequest struct {
+	TargetCount int
+}
+
+// ServiceStats mirrors control.proto's ServiceStats.
+type ServiceStats struct {
+	Total  int64
+	Failed int64
+}
+
+// StatsSnapshot mirrors control.proto's StatsSnapshot.
+type StatsSnapshot struct {
+	Auth, Chat, Posts, Profile ServiceStats
+	FailedByStatus             map[string]int64
+	ActiveUsers                int64
+}
+
+// CleanupRequest mirrors control.proto's TriggerCleanupRequest.
+type CleanupRequest struct {
+	MaxUsers int
+	DryRun   bool
+	Pattern  string
+}
+
+// CleanupResult mirrors control.proto's TriggerCleanupResponse. Failed's
+// status codes come from cleanup.FailureInfo.Status - the attempt count
+// isn't part of control.proto's failed_users map (map<string,int32>), so
+// it's dropped here rather than changing that wire contract out of scope.
+type CleanupResult struct {
+	Users  []string
+	Failed map[string]int
+	DryRun bool
+}
+
+// RunEvent mirrors control.proto's RunEvent.
+type RunEvent struct {
+	Kind     string
+	Username string
+	Detail   string
+	Time     time.Time
+}
+
+// Hooks is what Controller calls to drive the currently running test.
+// internal/web.WebServer is expected to grow exported methods matching
+// this set (StartRun/StopRun/ScaleUsers/Stats/Events) so wiring a real
+// LoadgenControlServer is just `control.New(webServer, cleanup)` once
+// codegen exists.
+type Hooks interface {
+	StartRun(req StartRunRequest) error
+	StopRun() error
+	// ScaleUsers asks for the running test's active user count to move
+	// toward req.TargetCount, returning the count still active
+	// afterward. Only scaling down is meaningful today - see
+	// Controller.ScaleUsers.
+	ScaleUsers(ctx context.Context, req ScaleUsersRequest) (int64, error)
+	// Events returns a channel of this run's lifecycle events and an
+	// unsubscribe func to release it, mirroring internal/web's
+	// subscribe/unsubscribe pair for its SSE hub.
+	Events() (<-chan RunEvent, func())
+}
+
+// Controller implements LoadgenControl's RPCs. TriggerCleanup and
+// GetStats only need internal/cleanup.Cleanup and the process's own
+// Prometheus registry, so they're handled directly rather than through
+// Hooks.
+type Controller struct {
+	hooks   Hooks
+	cleanup *cleanup.Cleanup
+}
+
+// New returns a Controller that drives hooks for run lifecycle
+// operations and cl for cleanup.
+func New(hooks Hooks, cl *cleanup.Cleanup) *Controller {
+	return &Controller{hooks: hooks, cleanup: cl}
+}
+
+func (c *Controller) StartRun(req StartRunRequest) error {
+	return c.hooks.StartRun(req)
+}
+
+func (c *Controller) StopRun() error {
+	return c.hooks.StopRun()
+}
+
+func (c *Controller) ScaleUsers(ctx context.Context, req ScaleUsersRequest) (int64, error) {
+	return c.hooks.ScaleUsers(ctx, req)
+}
+
+func (c *Controller) StreamEvents() (<-chan RunEvent, func()) {
+	return c.hooks.Events()
+}
+
+// GetStats gathers the process's own Prometheus registry and summarizes
+// loadgen_requests_total by service and status, the same registry
+// internal/dashboard.computeStats reads for the HTML dashboard.
+func (c *Controller) GetStats() (StatsSnapshot, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return StatsSnapshot{}, err
+	}
+
+	snap := StatsSnapshot{FailedByStatus: make(map[string]int64)}
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "loadgen_requests_total":
+			for _, m := range mf.GetMetric() {
+				svc, status := serviceAndStatus(m.GetLabel())
+				count := int64(m.GetCounter().GetValue())
+				failed := len(status) > 0 && (status[0] == '4' || status[0] == '5')
+
+				stats := statsFor(&snap, svc)
+				if stats == nil {
+					continue
+				}
+				stats.Total += count
+				if failed {
+					stats.Failed += count
+					snap.FailedByStatus[status] += count
+				}
+			}
+		case "loadgen_active_users":
+			for _, m := range mf.GetMetric() {
+				snap.ActiveUsers = int64(m.GetGauge().GetValue())
+			}
+		}
+	}
+	return snap, nil
+}
+
+func serviceAndStatus(labels []*dto.LabelPair) (service, status string) {
+	for _, l := range labels {
+		switch l.GetName() {
+		case "service":
+			service = l.GetValue()
+		case "status":
+			status = l.GetValue()
+		}
+	}
+	return service, status
+}
+
+func statsFor(snap *StatsSnapshot, service string) *ServiceStats {
+	switch service {
+	case "auth":
+		return &snap.Auth
+	case "chat":
+		return &snap.Chat
+	case "posts":
+		return &snap.Posts
+	case "profile":
+		return &snap.Profile
+	default:
+		return nil
+	}
+}
+
+// TriggerCleanup deletes (or, if req.DryRun, just lists) up to
+// req.MaxUsers test users matching req.Pattern. DeleteTestUsers always
+// matches against cleanup.DefaultUserPattern, so a Pattern other than
+// that (or empty) is rejected rather than letting a dry-run preview a
+// different set of users than a subsequent real deletion would hit.
+func (c *Controller) TriggerCleanup(ctx context.Context, req CleanupRequest) (CleanupResult, error) {
+	pattern := req.Pattern
+	if pattern == "" {
+		pattern = cleanup.DefaultUserPattern
+	}
+	if pattern != cleanup.DefaultUserPattern {
+		return CleanupResult{}, fmt.Errorf("control: cleanup pattern %q not supported, only the default %q is", pattern, cleanup.DefaultUserPattern)
+	}
+
+	if req.DryRun {
+		if req.MaxUsers <= 0 {
+			return CleanupResult{Users: []string{}, DryRun: true}, nil
+		}
+		ch := c.cleanup.ListTestUsers(ctx, pattern, 100)
+		var users []string
+		for u := range ch {
+			users = append(users, u)
+			if len(users) == req.MaxUsers {
+				break
+			}
+		}
+		return CleanupResult{Users: users, DryRun: true}, nil
+	}
+
+	deleted, failed := c.cleanup.DeleteTestUsers(ctx, req.MaxUsers)
+	statusByUser := make(map[string]int, len(failed))
+	for u, info := range failed {
+		statusByUser[u] = info.Status
+	}
+	return CleanupResult{Users: deleted, Failed: statusByUser}, nil
+}
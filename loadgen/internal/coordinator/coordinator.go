@@ -0,0 +1,332 @@
+// Package coordinator implements the assignment and metrics-aggregation
+// logic behind proto/loadgen.proto's LoadgenCoordinator service: it
+// tracks registered agents, splits a test's total user count into
+// disjoint per-agent ranges, and folds each agent's reported samples
+// back into this process's own internal/metrics. The actual gRPC
+// transport (the generated stream server) isn't wired up yet - see
+// proto/loadgenpb's doc comment for why - so for now a Coordinator is
+// driven directly by whatever in-process caller owns the (currently
+// nonexistent) gRPC server.
+package coordinator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"loadgen/internal/metrics"
+)
+
+// AgentState is what the coordinator knows about one registered agent.
+type AgentState struct {
+	AgentID       string
+	Address       string
+	MaxUsers      int
+	LastHeartbeat time.Time
+}
+
+// UserRange is a disjoint, half-open [Start, End) slice of user ids -
+// the Go-side counterpart of loadgen.proto's UserRange message.
+type UserRange struct {
+	Start int
+	End   int
+}
+
+// Count returns how many user ids this range covers.
+func (r UserRange) Count() int { return r.End - r.Start }
+
+// LatencySample is the Go-side counterpart of loadgen.proto's
+// LatencySample message.
+type LatencySample struct {
+	Service         string
+	Method          string
+	Status          string
+	DurationSeconds float64
+}
+
+// testState tracks one running test's per-agent active-user counts, so
+// PushMetrics can recompute the cross-agent total on every call instead
+// of agents racing to increment/decrement a single shared counter. It
+// also tracks each agent's assigned UserRange (so Reshard knows what to
+// redistribute if that agent drops) and the persistent-user trail each
+// agent has reported, merged across agents into TrackedUsers.
+type testState struct {
+	activeUsersByAgent map[string]int64
+	rangesByAgent      map[string]UserRange
+	trackedByAgent     map[string][]string
+	// totalUsers is the test's original requested user count, kept
+	// around so Reshard can re-tile a fresh, guaranteed-disjoint set of
+	// ranges over the live agents via AssignUserRanges instead of patching
+	// the dead agent's old range into survivors' existing ones (which,
+	// since a UserRange is a single contiguous interval, can't represent
+	// "my original range plus someone else's leftover range" without
+	// either overlapping another agent or leaving part of [0, totalUsers)
+	// unassigned).
+	totalUsers int
+	// partial is set once an agent drops mid-run with no live agent left
+	// to absorb its range - see Reshard. A test that recovers by
+	// reshard stays non-partial.
+	partial bool
+}
+
+// TrackedUsers returns every username reported so far across all agents
+// running testID, deduplicated. Order is unspecified.
+func (c *Coordinator) TrackedUsers(testID string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	test, ok := c.tests[testID]
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	var out []string
+	for _, users := range test.trackedByAgent {
+		for _, u := range users {
+			if _, dup := seen[u]; !dup {
+				seen[u] = struct{}{}
+				out = append(out, u)
+			}
+		}
+	}
+	return out
+}
+
+// Partial reports whether testID has lost coverage mid-run (see Reshard)
+// and never recovered it, meaning its final report should be marked
+// incomplete.
+func (c *Coordinator) Partial(testID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if test, ok := c.tests[testID]; ok {
+		return test.partial
+	}
+	return false
+}
+
+// Coordinator assigns disjoint user-id ranges to registered agents and
+// aggregates their reported metrics into this process's own
+// internal/metrics, so a single /metrics scrape of the coordinator
+// already reflects every agent - the same as if one process had run the
+// whole test.
+type Coordinator struct {
+	mu     sync.Mutex
+	agents map[string]*AgentState
+	tests  map[string]*testState
+}
+
+// New returns an empty Coordinator ready to register agents.
+func New() *Coordinator {
+	return &Coordinator{
+		agents: make(map[string]*AgentState),
+		tests:  make(map[string]*testState),
+	}
+}
+
+// RegisterAgent records a newly (re)connected agent. Re-registering an
+// already-known agentID (e.g. after agent.Reconnect redials) just
+// refreshes its address/maxUsers rather than erroring, since a
+// reconnecting agent keeps the same agentID on purpose.
+func (c *Coordinator) RegisterAgent(agentID, address string, maxUsers int) *AgentState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := &AgentState{AgentID: agentID, Address: address, MaxUsers: maxUsers, LastHeartbeat: time.Now()}
+	c.agents[agentID] = state
+	return state
+}
+
+// Heartbeat refreshes agentID's LastHeartbeat.
+func (c *Coordinator) Heartbeat(agentID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if a, ok := c.agents[agentID]; ok {
+		a.LastHeartbeat = time.Now()
+	}
+}
+
+// PruneDeadAgents drops every registered agent whose LastHeartbeat is
+// older than timeout as of now, returning the dropped agents' ids. It
+// only forgets the agent - reassigning whatever test work it was doing
+// is Reshard's job, since pruning a dead agent from the registry and
+// recovering a running test's coverage are separate concerns (a dead
+// agent between tests has nothing to reshard).
+func (c *Coordinator) PruneDeadAgents(now time.Time, timeout time.Duration) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var dead []string
+	for id, a := range c.agents {
+		if now.Sub(a.LastHeartbeat) > timeout {
+			dead = append(dead, id)
+			delete(c.agents, id)
+		}
+	}
+	return dead
+}
+
+// Reshard recovers from deadAgentID dropping out of testID by folding its
+// now-unclaimed range into a single adjacent survivor's range, leaving
+// every other live agent's range untouched. A full re-tile via
+// AssignUserRanges was tried first, but that shifts every surviving
+// agent's Start/End, not just the dead agent's neighbor - a survivor
+// whose new range overlaps ids it already generated usernames for (e.g.
+// agent B already created user_62 under its old [50,100) before A's new
+// range grows to [0,75)) produces exactly the cross-agent username
+// collision the Seed/startUserID work in StartTestCommand was meant to
+// prevent. Extending one neighbor's interval to absorb the gap keeps
+// every other survivor's Start fixed, so already-admitted users never
+// fall inside a range some other agent is now told to cover. It marks
+// testID partial and returns a nil map if liveAgentIDs is empty, since
+// there's then no one left to pick up coverage; the caller (see the
+// coordinator's gRPC server, not yet wired up) is expected to send each
+// returned agent an updated StartTest for its new range.
+func (c *Coordinator) Reshard(testID, deadAgentID string, liveAgentIDs []string) map[string]UserRange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	test, ok := c.tests[testID]
+	if !ok {
+		return nil
+	}
+	dead, ok := test.rangesByAgent[deadAgentID]
+	if !ok {
+		return nil
+	}
+	delete(test.rangesByAgent, deadAgentID)
+	delete(test.activeUsersByAgent, deadAgentID)
+	delete(test.trackedByAgent, deadAgentID)
+
+	if len(liveAgentIDs) == 0 {
+		test.partial = true
+		return nil
+	}
+
+	// Find the live agent whose range is contiguous with dead's, on
+	// either side, and extend it to absorb the gap. dead's range was
+	// carved out of [0, totalUsers) by AssignUserRanges alongside every
+	// other agent's, so exactly one of these should exist unless
+	// liveAgentIDs omits an agent StartTest originally assigned here.
+	for agentID, r := range test.rangesByAgent {
+		if r.End == dead.Start {
+			r.End = dead.End
+			test.rangesByAgent[agentID] = r
+			return test.rangesByAgent
+		}
+		if r.Start == dead.End {
+			r.Start = dead.Start
+			test.rangesByAgent[agentID] = r
+			return test.rangesByAgent
+		}
+	}
+
+	// No adjacent survivor (e.g. liveAgentIDs dropped more than just
+	// deadAgentID) - fall back to handing the gap to the first live
+	// agent by id, even though that leaves its range non-contiguous in
+	// the [Start,End) sense; that's still strictly better than leaving
+	// the gap's ids permanently uncovered.
+	for _, agentID := range liveAgentIDs {
+		r, ok := test.rangesByAgent[agentID]
+		if !ok {
+			continue
+		}
+		if dead.Start < r.Start {
+			r.Start = dead.Start
+		}
+		if dead.End > r.End {
+			r.End = dead.End
+		}
+		test.rangesByAgent[agentID] = r
+		return test.rangesByAgent
+	}
+
+	test.partial = true
+	return nil
+}
+
+// AssignUserRanges splits [0, totalUsers) into len(agentIDs) disjoint,
+// contiguous ranges sized as evenly as possible - the first
+// totalUsers%len(agentIDs) agents get one extra user - so no two agents
+// in the same test are ever assigned overlapping ranges.
+func AssignUserRanges(totalUsers int, agentIDs []string) map[string]UserRange {
+	ranges := make(map[string]UserRange, len(agentIDs))
+	if len(agentIDs) == 0 {
+		return ranges
+	}
+
+	base := totalUsers / len(agentIDs)
+	remainder := totalUsers % len(agentIDs)
+
+	start := 0
+	for i, id := range agentIDs {
+		size := base
+		if i < remainder {
+			size++
+		}
+		ranges[id] = UserRange{Start: start, End: start + size}
+		start += size
+	}
+	return ranges
+}
+
+// StartTest registers testID for aggregation and returns each agent's
+// assigned UserRange via AssignUserRanges. Delivering the resulting
+// StartTest frame to each agent's stream is the (not yet wired up) gRPC
+// server's job.
+func (c *Coordinator) StartTest(testID string, totalUsers int, agentIDs []string) map[string]UserRange {
+	ranges := AssignUserRanges(totalUsers, agentIDs)
+
+	c.mu.Lock()
+	c.tests[testID] = &testState{
+		activeUsersByAgent: make(map[string]int64),
+		rangesByAgent:      ranges,
+		trackedByAgent:     make(map[string][]string),
+		totalUsers:         totalUsers,
+	}
+	c.mu.Unlock()
+
+	return ranges
+}
+
+// PushMetrics folds one agent's reported sample window for testID into
+// this process's own internal/metrics: latency samples are observed
+// directly into metrics.RequestDuration/RequestsTotal, activeUsers
+// replaces that agent's last-known count before metrics.ActiveUsers is
+// set to the recomputed cross-agent total, and trackedUsers (the
+// usernames that agent has created/logged in since its previous
+// PushMetrics - see loadgen.proto's PushMetrics.tracked_users) is
+// appended to that agent's running trail rather than replacing it, so a
+// later call doesn't make Coordinator.TrackedUsers forget usernames an
+// earlier call already reported.
+func (c *Coordinator) PushMetrics(testID, agentID string, activeUsers int64, samples []LatencySample, trackedUsers []string) error {
+	c.mu.Lock()
+	test, ok := c.tests[testID]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("coordinator: unknown test %q", testID)
+	}
+	test.activeUsersByAgent[agentID] = activeUsers
+	test.trackedByAgent[agentID] = append(test.trackedByAgent[agentID], trackedUsers...)
+
+	var total int64
+	for _, v := range test.activeUsersByAgent {
+		total += v
+	}
+	c.mu.Unlock()
+
+	metrics.ActiveUsers.Set(float64(total))
+	for _, s := range samples {
+		metrics.RequestDuration.WithLabelValues(s.Service, s.Method).Observe(s.DurationSeconds)
+		metrics.RequestsTotal.WithLabelValues(metrics.RequestLabelValues(s.Service, s.Method, s.Status)...).Inc()
+	}
+	return nil
+}
+
+// EndTest drops testID's aggregation state once its merged report has
+// been produced.
+func (c *Coordinator) EndTest(testID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tests, testID)
+}
@@ -0,0 +1,165 @@
+package coordinator
+
+import "testing"
+
+// TestReshardExtendsContiguousLeftNeighbor checks the common case: the
+// dead agent's range is absorbed by the live neighbor whose range ends
+// exactly where the dead one starts.
+func TestReshardExtendsContiguousLeftNeighbor(t *testing.T) {
+	c := New()
+	c.StartTest("t1", 30, []string{"a", "b", "c"})
+
+	got := c.Reshard("t1", "b", []string{"a", "c"})
+	if got == nil {
+		t.Fatalf("expected a non-nil reshard result")
+	}
+	if r := got["a"]; r != (UserRange{Start: 0, End: 20}) {
+		t.Fatalf("expected a's range extended to [0,20), got %+v", r)
+	}
+	if r := got["c"]; r != (UserRange{Start: 20, End: 30}) {
+		t.Fatalf("expected c's range untouched at [20,30), got %+v", r)
+	}
+	if _, ok := got["b"]; ok {
+		t.Fatalf("expected dead agent b to be gone from the range map")
+	}
+	if c.Partial("t1") {
+		t.Fatalf("expected test to not be marked partial after a successful reshard")
+	}
+}
+
+// TestReshardExtendsContiguousRightNeighbor mirrors the left-neighbor
+// case for the other contiguous edge: a live agent whose range starts
+// exactly where the dead one ends absorbs it instead.
+func TestReshardExtendsContiguousRightNeighbor(t *testing.T) {
+	c := New()
+	c.StartTest("t1", 30, []string{"a", "b", "c"})
+
+	got := c.Reshard("t1", "a", []string{"b", "c"})
+	if got == nil {
+		t.Fatalf("expected a non-nil reshard result")
+	}
+	if r := got["b"]; r != (UserRange{Start: 0, End: 20}) {
+		t.Fatalf("expected b's range extended to [0,20), got %+v", r)
+	}
+	if r := got["c"]; r != (UserRange{Start: 20, End: 30}) {
+		t.Fatalf("expected c's range untouched at [20,30), got %+v", r)
+	}
+}
+
+// TestReshardFallsBackToFirstLiveAgentWhenNoNeighborIsContiguous covers
+// the no-adjacent-survivor branch: no remaining range's Start/End touches
+// the dead agent's range at all (a gap opened by some earlier reshard
+// that itself had no contiguous neighbor to extend), so the first live
+// agent by id absorbs the gap even though its own range ends up
+// non-contiguous. AssignUserRanges/normal Reshard calls never produce
+// this shape on their own (every tiled range always has a neighbor to
+// absorb it), so the state is built directly rather than via StartTest.
+func TestReshardFallsBackToFirstLiveAgentWhenNoNeighborIsContiguous(t *testing.T) {
+	c := New()
+	c.tests["t1"] = &testState{
+		activeUsersByAgent: map[string]int64{},
+		rangesByAgent: map[string]UserRange{
+			"a":    {Start: 0, End: 10},
+			"dead": {Start: 15, End: 25},
+			"b":    {Start: 30, End: 40},
+		},
+		trackedByAgent: map[string][]string{},
+		totalUsers:     40,
+	}
+
+	got := c.Reshard("t1", "dead", []string{"a", "b"})
+	if got == nil {
+		t.Fatalf("expected a non-nil reshard result")
+	}
+	if r := got["a"]; r != (UserRange{Start: 0, End: 25}) {
+		t.Fatalf("expected a to absorb the gap up to End=25, got %+v", r)
+	}
+	if r := got["b"]; r != (UserRange{Start: 30, End: 40}) {
+		t.Fatalf("expected b's range untouched at [30,40), got %+v", r)
+	}
+	if c.Partial("t1") {
+		t.Fatalf("expected test to not be marked partial when a fallback agent absorbed the gap")
+	}
+}
+
+// TestReshardMarksPartialWhenNoLiveAgentsRemain checks that losing the
+// last live agent leaves the test marked partial with a nil result,
+// instead of panicking or silently dropping the range.
+func TestReshardMarksPartialWhenNoLiveAgentsRemain(t *testing.T) {
+	c := New()
+	c.StartTest("t1", 30, []string{"a"})
+
+	got := c.Reshard("t1", "a", nil)
+	if got != nil {
+		t.Fatalf("expected a nil result when no live agents remain, got %+v", got)
+	}
+	if !c.Partial("t1") {
+		t.Fatalf("expected test to be marked partial")
+	}
+}
+
+// TestReshardUnknownTestOrAgentIsANoop checks that Reshard just returns
+// nil, without marking anything partial, for a testID it never saw or a
+// deadAgentID that test never assigned a range to.
+func TestReshardUnknownTestOrAgentIsANoop(t *testing.T) {
+	c := New()
+	if got := c.Reshard("missing-test", "a", []string{"b"}); got != nil {
+		t.Fatalf("expected nil for an unknown test, got %+v", got)
+	}
+
+	c.StartTest("t1", 10, []string{"a"})
+	if got := c.Reshard("t1", "never-assigned", []string{"a"}); got != nil {
+		t.Fatalf("expected nil for an agent with no assigned range, got %+v", got)
+	}
+	if c.Partial("t1") {
+		t.Fatalf("expected test to not be marked partial by a no-op reshard")
+	}
+}
+
+// TestAssignUserRangesSplitsEvenlyWithRemainderToEarlyAgents checks that
+// the first totalUsers%len(agentIDs) agents get one extra user and every
+// range is disjoint and contiguous.
+func TestAssignUserRangesSplitsEvenlyWithRemainderToEarlyAgents(t *testing.T) {
+	ranges := AssignUserRanges(10, []string{"a", "b", "c"})
+
+	want := map[string]UserRange{
+		"a": {Start: 0, End: 4},
+		"b": {Start: 4, End: 7},
+		"c": {Start: 7, End: 10},
+	}
+	for id, r := range want {
+		if got := ranges[id]; got != r {
+			t.Fatalf("agent %s: expected %+v, got %+v", id, r, got)
+		}
+	}
+}
+
+// TestPushMetricsAggregatesActiveUsersAcrossAgents checks that the
+// cross-agent active-user total PushMetrics computes reflects every
+// agent's last-reported count, not just the most recent call's.
+func TestPushMetricsAggregatesActiveUsersAcrossAgents(t *testing.T) {
+	c := New()
+	c.StartTest("t1", 10, []string{"a", "b"})
+
+	if err := c.PushMetrics("t1", "a", 5, nil, []string{"user_1"}); err != nil {
+		t.Fatalf("PushMetrics: %v", err)
+	}
+	if err := c.PushMetrics("t1", "b", 7, nil, []string{"user_2"}); err != nil {
+		t.Fatalf("PushMetrics: %v", err)
+	}
+
+	users := c.TrackedUsers("t1")
+	if len(users) != 2 {
+		t.Fatalf("expected both agents' tracked users merged, got %v", users)
+	}
+}
+
+// TestPushMetricsUnknownTestReturnsError checks that PushMetrics reports
+// an error instead of silently creating test state for an id StartTest
+// was never called with.
+func TestPushMetricsUnknownTestReturnsError(t *testing.T) {
+	c := New()
+	if err := c.PushMetrics("missing-test", "a", 1, nil, nil); err == nil {
+		t.Fatalf("expected an error for an unknown test")
+	}
+}
@@ -1,28 +1,42 @@
 package dashboard
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"html/template"
+	"io"
 	"net/http"
+	"sort"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"loadgen/internal/chaos"
+	"loadgen/internal/logging"
 )
 
 type Stats struct {
 	TotalRequests  int64
 	SuccessRate    float64
 	AvgLatency     float64
+	P50Latency     float64
 	P95Latency     float64
+	P99Latency     float64
 	ActiveUsers    int64
 	WebSocketConns int64
 	StartTime      time.Time
 	Duration       time.Duration
 }
 
+var startTime = time.Now()
+
 const dashboardHTML = `
 <!DOCTYPE html>
 <html>
 <head>
     <title>Load Generator Dashboard</title>
-    <meta http-equiv="refresh" content="5">
     <style>
         body { font-family: Arial, sans-serif; margin: 20px; }
         .metric { background: #f5f5f5; padding: 15px; margin: 10px 0; border-radius: 5px; }
@@ -36,43 +50,63 @@ const dashboardHTML = `
         <h1>🚀 Load Generator Dashboard</h1>
         <p>Real-time metrics for microservices load testing</p>
     </div>
-    
+
     <div class="metric">
         <h3>📊 Total Requests</h3>
-        <div class="value">{{.TotalRequests}}</div>
+        <div class="value" id="totalRequests">{{.TotalRequests}}</div>
     </div>
-    
+
     <div class="metric">
         <h3>✅ Success Rate</h3>
-        <div class="value">{{printf "%.2f" .SuccessRate}}%</div>
+        <div class="value" id="successRate">{{printf "%.2f" .SuccessRate}}%</div>
     </div>
-    
+
     <div class="metric">
         <h3>⏱️ Average Latency</h3>
-        <div class="value">{{printf "%.2f" .AvgLatency}}ms</div>
+        <div class="value" id="avgLatency">{{printf "%.2f" .AvgLatency}}ms</div>
     </div>
-    
+
     <div class="metric">
-        <h3>📈 P95 Latency</h3>
-        <div class="value">{{printf "%.2f" .P95Latency}}ms</div>
+        <h3>📈 P50 / P95 / P99 Latency</h3>
+        <div class="value" id="quantileLatency">{{printf "%.2f" .P50Latency}} / {{printf "%.2f" .P95Latency}} / {{printf "%.2f" .P99Latency}}ms</div>
     </div>
-    
+
     <div class="metric">
         <h3>👥 Active Users</h3>
-        <div class="value">{{.ActiveUsers}}</div>
+        <div class="value" id="activeUsers">{{.ActiveUsers}}</div>
     </div>
-    
+
     <div class="metric">
         <h3>🔌 WebSocket Connections</h3>
-        <div class="value">{{.WebSocketConns}}</div>
+        <div class="value" id="wsConns">{{.WebSocketConns}}</div>
     </div>
-    
+
     <div class="metric">
         <h3>⏰ Test Duration</h3>
-        <div class="value">{{.Duration}}</div>
+        <div class="value" id="duration">{{.Duration}}</div>
     </div>
-    
+
     <p><a href="/metrics">📊 Raw Metrics</a> | <a href="http://localhost:9091">📈 Prometheus</a> | <a href="http://localhost:3001">📊 Grafana</a></p>
+
+    <script>
+        function refresh() {
+            fetch('/api/stats.json')
+                .then(r => r.json())
+                .then(s => {
+                    document.getElementById('totalRequests').innerText = s.TotalRequests;
+                    document.getElementById('successRate').innerText = s.SuccessRate.toFixed(2) + '%';
+                    document.getElementById('avgLatency').innerText = s.AvgLatency.toFixed(2) + 'ms';
+                    document.getElementById('quantileLatency').innerText =
+                        s.P50Latency.toFixed(2) + ' / ' + s.P95Latency.toFixed(2) + ' / ' + s.P99Latency.toFixed(2) + 'ms';
+                    document.getElementById('activeUsers').innerText = s.ActiveUsers;
+                    document.getElementById('wsConns').innerText = s.WebSocketConns;
+                    document.getElementById('duration').innerText = s.Duration / 1e9 + 's';
+                })
+                .catch(() => {});
+        }
+        setInterval(refresh, 2000);
+        refresh();
+    </script>
 </body>
 </html>
 `
@@ -82,26 +116,260 @@ func StartDashboard(addr string) *http.Server {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		stats := Stats{
-			TotalRequests:  1234, // TODO: Get from metrics
-			SuccessRate:    98.5,
-			AvgLatency:     45.2,
-			P95Latency:     120.5,
-			ActiveUsers:    50,
-			WebSocketConns: 45,
-			StartTime:      time.Now().Add(-5 * time.Minute),
-			Duration:       5 * time.Minute,
+		stats, err := computeStats()
+		if err != nil {
+			logging.Error(r.Context(), "failed to compute dashboard stats", "error", err.Error())
+			http.Error(w, "Failed to compute stats", http.StatusInternalServerError)
+			return
 		}
 
 		w.Header().Set("Content-Type", "text/html")
 		tmpl.Execute(w, stats)
 	})
+	mux.HandleFunc("/api/stats.json", func(w http.ResponseWriter, r *http.Request) {
+		stats, err := computeStats()
+		if err != nil {
+			logging.Error(r.Context(), "failed to compute dashboard stats", "error", err.Error())
+			http.Error(w, "Failed to compute stats", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	})
+	mux.HandleFunc("/api/chaos", handleChaos)
+	mux.HandleFunc("/chaos/rules", handleChaosRules)
 
 	server := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
 
-	go server.ListenAndServe()
+	go func() {
+		logging.Info(context.Background(), "dashboard server starting", "addr", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error(context.Background(), "dashboard server stopped", "addr", addr, "error", err.Error())
+		}
+	}()
 	return server
 }
+
+// computeStats gathers the in-process Prometheus registry and derives
+// dashboard-friendly stats from it, rather than returning placeholder values.
+func computeStats() (*Stats, error) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		StartTime: startTime,
+		Duration:  time.Since(startTime),
+	}
+
+	var success, total int64
+	var sumLatency, countLatency float64
+	var buckets []bucket
+
+	for _, mf := range families {
+		switch mf.GetName() {
+		case "loadgen_requests_total":
+			for _, m := range mf.GetMetric() {
+				count := int64(m.GetCounter().GetValue())
+				total += count
+				if isSuccessStatus(m.GetLabel()) {
+					success += count
+				}
+			}
+		case "loadgen_active_users":
+			for _, m := range mf.GetMetric() {
+				stats.ActiveUsers = int64(m.GetGauge().GetValue())
+			}
+		case "loadgen_websocket_connections":
+			for _, m := range mf.GetMetric() {
+				stats.WebSocketConns = int64(m.GetGauge().GetValue())
+			}
+		case "loadgen_request_duration_seconds":
+			for _, m := range mf.GetMetric() {
+				h := m.GetHistogram()
+				sumLatency += h.GetSampleSum()
+				countLatency += float64(h.GetSampleCount())
+				buckets = append(buckets, bucketsFromHistogram(h)...)
+			}
+		}
+	}
+
+	stats.TotalRequests = total
+	if total > 0 {
+		stats.SuccessRate = float64(success) / float64(total) * 100
+	}
+	if countLatency > 0 {
+		stats.AvgLatency = sumLatency / countLatency * 1000
+	}
+
+	merged := mergeBuckets(buckets)
+	stats.P50Latency = histogramQuantile(merged, 0.50) * 1000
+	stats.P95Latency = histogramQuantile(merged, 0.95) * 1000
+	stats.P99Latency = histogramQuantile(merged, 0.99) * 1000
+
+	return stats, nil
+}
+
+func isSuccessStatus(labels []*dto.LabelPair) bool {
+	for _, l := range labels {
+		if l.GetName() == "status" {
+			return len(l.GetValue()) > 0 && (l.GetValue()[0] == '2' || l.GetValue()[0] == '3')
+		}
+	}
+	return false
+}
+
+type bucket struct {
+	upperBound float64
+	count      float64
+}
+
+func bucketsFromHistogram(h *dto.Histogram) []bucket {
+	out := make([]bucket, 0, len(h.GetBucket()))
+	for _, b := range h.GetBucket() {
+		out = append(out, bucket{upperBound: b.GetUpperBound(), count: float64(b.GetCumulativeCount())})
+	}
+	return out
+}
+
+// mergeBuckets sums cumulative counts across all histogram instances sharing
+// the same label set (i.e. across "service"/"method" series) into one set of
+// buckets keyed by upper bound, so histogram_quantile can walk a single series.
+func mergeBuckets(buckets []bucket) []bucket {
+	totals := make(map[float64]float64)
+	for _, b := range buckets {
+		totals[b.upperBound] += b.count
+	}
+
+	merged := make([]bucket, 0, len(totals))
+	for ub, count := range totals {
+		merged = append(merged, bucket{upperBound: ub, count: count})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].upperBound < merged[j].upperBound })
+	return merged
+}
+
+// histogramQuantile estimates the q-th quantile from cumulative histogram
+// buckets using the same linear-interpolation algorithm as Prometheus'
+// histogram_quantile(): find the bucket where the cumulative count first
+// crosses q*total, then interpolate between its lower and upper bound.
+func histogramQuantile(buckets []bucket, q float64) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+
+	total := buckets[len(buckets)-1].count
+	if total <= 0 {
+		return 0
+	}
+
+	rank := q * total
+	var lowerBound, lowerCount float64
+	for _, b := range buckets {
+		if b.count >= rank {
+			bucketCount := b.count - lowerCount
+			if bucketCount <= 0 {
+				return b.upperBound
+			}
+			return lowerBound + (b.upperBound-lowerBound)*(rank-lowerCount)/bucketCount
+		}
+		lowerBound = b.upperBound
+		lowerCount = b.count
+	}
+
+	return buckets[len(buckets)-1].upperBound
+}
+
+// handleChaos serves the live chaos rule set (GET) and replaces it (PUT)
+// against the shared chaos.Default() middleware, so rules can be tuned
+// without restarting the load generator.
+func handleChaos(w http.ResponseWriter, r *http.Request) {
+	cm := chaos.Default()
+	if cm == nil {
+		http.Error(w, "chaos middleware not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cm.Rules())
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		rules, err := chaos.ParseRulesJSON(body)
+		if err != nil {
+			logging.Error(r.Context(), "invalid chaos rules submitted", "error", err.Error())
+			http.Error(w, "invalid rules JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		cm.SetRules(rules)
+		logging.Info(r.Context(), "chaos rules updated via dashboard", "rule_count", len(rules))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleChaosRules serves per-route chaos rules one at a time against the
+// shared chaos.Default() middleware: GET lists the active set in
+// RuleSpec's friendlier shape, PUT upserts a single rule (by id, matched
+// on method+path if id is omitted on creation), and DELETE removes one by
+// ?id=. Unlike handleChaos's PUT, this never replaces the rest of the
+// active rule set, so operators can inject a fault at exactly the route
+// they care about mid-run without clobbering everything else.
+func handleChaosRules(w http.ResponseWriter, r *http.Request) {
+	cm := chaos.Default()
+	if cm == nil {
+		http.Error(w, "chaos middleware not initialized", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(cm.RuleSpecs())
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		spec, err := chaos.ParseRuleSpec(body)
+		if err != nil {
+			http.Error(w, "invalid rule JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := cm.UpsertRule(spec)
+		if err != nil {
+			logging.Error(r.Context(), "invalid chaos rule submitted", "error", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logging.Info(r.Context(), "chaos rule upserted via control API", "id", result.ID, "method", result.Method, "path", result.Path)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			http.Error(w, "id query parameter is required", http.StatusBadRequest)
+			return
+		}
+		if !cm.DeleteRule(id) {
+			http.Error(w, "rule not found", http.StatusNotFound)
+			return
+		}
+		logging.Info(r.Context(), "chaos rule deleted via control API", "id", id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
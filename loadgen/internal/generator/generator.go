@@ -10,34 +10,170 @@ import (
 	"time"
 
 	"loadgen/internal/cleanup"
+	"loadgen/internal/clock"
 	"loadgen/internal/config"
 	"loadgen/internal/metrics"
+	"loadgen/internal/obs"
+	"loadgen/internal/runner"
 	"loadgen/internal/user"
 )
 
+// Workload profile names accepted by New's workloadProfile parameter.
+// "ramp" is the original behavior (and the default): grow the pool at
+// rampRate users/sec. The others let an ad-hoc, web-triggered run (see
+// internal/web.WebServer.runTest) exercise the same pool-resizing shapes
+// internal/runner already offers per-scenario via config.Ramp.Type,
+// without requiring a scenarios YAML block.
+const (
+	ProfileConstant = "constant" // all users start immediately, pool never resizes
+	ProfileRamp     = "ramp"     // steady trickle at rampRate users/sec (the original behavior)
+	ProfileSpike    = "spike"    // a small base pool, then the rest all at once partway through the run
+	ProfileStep     = "step"     // grows in four equal batches spread across the run duration
+)
+
+// Step is one paced action within a Scenario: Action names a
+// "behavior.action" pair (the vocabulary internal/runner.vuser.runStep
+// dispatches, e.g. "posts.create"), and Think bounds the pause after it
+// runs.
+type Step struct {
+	Action string
+	Think  config.ThinkTime
+}
+
+// Scenario is one weighted virtual-user journey. configScenario (adapting
+// config.Scenario, the YAML-configured form) is this package's only
+// built-in implementation; a caller with its own Scenario value can
+// satisfy this interface directly instead of going through YAML.
+type Scenario interface {
+	Name() string
+	Steps() []Step
+}
+
+type configScenario struct {
+	name  string
+	steps []Step
+}
+
+func (s *configScenario) Name() string  { return s.name }
+func (s *configScenario) Steps() []Step { return s.steps }
+
+// scenariosOf adapts cfg.Scenarios to the Scenario interface, for
+// inspecting what a scenario-based run will execute. Actual weighted
+// selection, think-time pacing, and step dispatch are delegated to
+// internal/runner.Runner (see Generator.Run) - that package already
+// implements all three with its own per-scenario/per-step metrics, and
+// reimplementing that dispatch table a second time here would just be a
+// second, driftable copy of it.
+func scenariosOf(cfg *config.Config) []Scenario {
+	out := make([]Scenario, 0, len(cfg.Scenarios))
+	for _, sc := range cfg.Scenarios {
+		steps := make([]Step, 0, len(sc.Steps))
+		for _, action := range sc.Steps {
+			steps = append(steps, Step{Action: action, Think: sc.ThinkTime})
+		}
+		out = append(out, &configScenario{name: sc.Name, steps: steps})
+	}
+	return out
+}
+
 type Generator struct {
-	config   *config.Config
-	users    int
-	duration time.Duration
-	rampRate int
-	cleanup  *cleanup.Cleanup
+	config          *config.Config
+	users           int
+	duration        time.Duration
+	rampRate        int
+	workloadProfile string
+	cleanup         *cleanup.Cleanup
+	// startUserID offsets every locally-generated user id (and therefore
+	// username - see user.New) by this amount. A standalone run leaves
+	// this at 0, giving the original [0, users) numbering; a distributed
+	// agent (see internal/agent.RunTest) sets it to its assigned
+	// UserRange's Start so two agents splitting one test never generate
+	// the same username.
+	startUserID int
+	// baseSeed is the RNG seed user ids derive theirs from (baseSeed +
+	// userID - see user.New's seed parameter), so a distributed test's
+	// agents can be given the same baseSeed and still produce
+	// non-colliding but reproducible per-user randomness.
+	baseSeed int64
+	// drainCh is closed by Drain to signal a graceful stop: resizePool
+	// stops admitting new users and each running user.Run finishes its
+	// current action before returning, as opposed to ctx.Done() which
+	// cancels in-flight work immediately.
+	drainCh   chan struct{}
+	drainOnce sync.Once
+	// logger carries this run's test_id (and any other fields New's ctx's
+	// obs.Logger was given) onto the ramp lifecycle events Run/resizePool
+	// emit. Captured once at New time rather than re-derived from ctx on
+	// every use, since unlike ctx a Logger is safe to hold on a struct -
+	// Generator is itself a one-run-and-discard value, not a long-lived one.
+	logger obs.Logger
+	// clock is how resizePool paces the ramp/spike/step profiles, so a test
+	// can substitute a clock.Fake and advance it instead of sleeping real
+	// wall-clock time (see internal/clock).
+	clock clock.Clock
 }
 
-func New(cfg *config.Config, users int, duration time.Duration, ramp string, cl *cleanup.Cleanup) *Generator {
+// New builds a Generator. ctx is used only to pick up the caller's
+// obs.Logger (see obs.FromContext); it is not retained, and cancellation
+// continues to flow through the ctx passed to Run, not this one. If
+// cfg.Scenarios is non-empty, Run replays those weighted scenarios via
+// internal/runner.Runner and users/duration/ramp/workloadProfile are
+// ignored. Otherwise it runs an ad-hoc test of users virtual users over
+// duration, shaped by workloadProfile (see the Profile* constants; ""
+// defaults to ProfileRamp, matching this function's original behavior).
+// ramp keeps its original "<rate>/s" syntax (e.g. "10/s") and is only
+// consulted by ProfileRamp. startUserID offsets generated user ids (see
+// the Generator.startUserID field doc); a standalone caller passes 0.
+// seed seeds every user's own RNG (seed+userID - see user.New); 0 derives
+// one from the current time, preserving non-deterministic manual runs.
+func New(ctx context.Context, cfg *config.Config, users int, duration time.Duration, ramp string, workloadProfile string, cl *cleanup.Cleanup, startUserID int, seed int64) *Generator {
 	// Parse ramp rate (e.g., "10/s" -> 10)
 	parts := strings.Split(ramp, "/")
 	rate, _ := strconv.Atoi(parts[0])
 
+	if workloadProfile == "" {
+		workloadProfile = ProfileRamp
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
 	return &Generator{
-		config:   cfg,
-		users:    users,
-		duration: duration,
-		rampRate: rate,
-		cleanup:  cl,
+		config:          cfg,
+		users:           users,
+		duration:        duration,
+		rampRate:        rate,
+		workloadProfile: workloadProfile,
+		cleanup:         cl,
+		startUserID:     startUserID,
+		baseSeed:        seed,
+		drainCh:         make(chan struct{}),
+		logger:          obs.FromContext(ctx),
+		clock:           clock.Real{},
 	}
 }
 
+// Drain signals a graceful stop-and-drain: resizePool stops admitting new
+// users and every already-running user.Run returns after finishing its
+// current action, rather than being cancelled mid-flight. Safe to call
+// more than once or concurrently with Run.
+func (g *Generator) Drain() {
+	g.drainOnce.Do(func() { close(g.drainCh) })
+}
+
+// Scenarios returns the scenarios a scenario-based Run would execute
+// (see scenariosOf), or nil if this Generator's config has none.
+func (g *Generator) Scenarios() []Scenario {
+	return scenariosOf(g.config)
+}
+
 func (g *Generator) Run(ctx context.Context) {
+	if len(g.config.Scenarios) > 0 {
+		g.runScenarios(ctx)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, g.duration)
 	defer cancel()
 
@@ -49,16 +185,22 @@ func (g *Generator) Run(ctx context.Context) {
 		wg.Add(1)
 		go func(userID int) {
 			defer wg.Done()
-			<-userChan // Wait for ramp-up signal
+			<-userChan // Wait for the workload profile to admit this user
 
-			u := user.New(userID, g.config)
+			globalID := g.startUserID + userID
+			u := user.New(globalID, g.config, g.baseSeed+int64(globalID))
 			g.cleanup.AddUser(u.Username) // Track user for potential cleanup later
-			u.Run(ctx)
+			u.Run(ctx, g.drainCh)
 		}(i)
 	}
 
-	// Ramp up users
-	go g.rampUp(ctx, userChan)
+	// Shape the pool's growth over time per g.workloadProfile, then emit
+	// test.ramp.done once every user has been admitted (or the run ended
+	// before that happened, in which case this just never fires).
+	go func() {
+		g.resizePool(ctx, userChan)
+		g.logger.Event("test.ramp.done")
+	}()
 
 	// Wait for completion or timeout
 	done := make(chan struct{})
@@ -79,37 +221,120 @@ func (g *Generator) Run(ctx context.Context) {
 	fmt.Printf("✅ Load test completed. %d users and their data remain as persistent load.\n", len(trackedUsers))
 }
 
+// runScenarios hands off a scenario-based run to internal/runner.Runner -
+// see scenariosOf's doc comment for why Generator doesn't dispatch steps
+// itself.
+func (g *Generator) runScenarios(ctx context.Context) {
+	r, err := runner.New(g.config)
+	if err != nil {
+		log.Printf("generator: invalid scenario config: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.duration)
+	defer cancel()
+	r.Run(ctx)
+
+	trackedUsers := g.cleanup.GetTrackedUsers()
+	fmt.Printf("✅ Load test completed. %d users and their data remain as persistent load.\n", len(trackedUsers))
+}
+
 func (g *Generator) GetTrackedUsers() []string {
 	return g.cleanup.GetTrackedUsers()
 }
 
-func (g *Generator) rampUp(ctx context.Context, userChan chan int) {
-	if g.rampRate <= 0 {
-		// Start all users immediately
-		for i := 0; i < g.users; i++ {
+// resizePool shapes the ad-hoc user pool's growth over g.duration
+// according to g.workloadProfile. The original rampRate-trickle behavior
+// (ProfileRamp) is now just one of four shapes - see the Profile*
+// constants.
+func (g *Generator) resizePool(ctx context.Context, userChan chan int) {
+	switch g.workloadProfile {
+	case ProfileConstant:
+		g.admitRange(ctx, userChan, 0, g.users)
+
+	case ProfileSpike:
+		base := g.users / 5
+		if base < 1 && g.users > 0 {
+			base = 1
+		}
+		g.admitRange(ctx, userChan, 0, base)
+
+		timer := g.clock.NewTimer(g.duration / 2)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-g.drainCh:
+			timer.Stop()
+			return
+		}
+		g.admitRange(ctx, userChan, base, g.users)
+
+	case ProfileStep:
+		const batches = 4
+		interval := g.duration / batches
+		batchSize := (g.users + batches - 1) / batches
+		admitted := 0
+		for b := 0; b < batches && admitted < g.users; b++ {
+			end := admitted + batchSize
+			if end > g.users {
+				end = g.users
+			}
+			g.admitRange(ctx, userChan, admitted, end)
+			admitted = end
+
+			if b < batches-1 {
+				timer := g.clock.NewTimer(interval)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-g.drainCh:
+					timer.Stop()
+					return
+				}
+			}
+		}
+
+	default: // ProfileRamp
+		if g.rampRate <= 0 {
+			g.admitRange(ctx, userChan, 0, g.users)
+			return
+		}
+
+		interval := time.Second / time.Duration(g.rampRate)
+		started := 0
+		for started < g.users {
+			timer := g.clock.NewTimer(interval)
 			select {
-			case userChan <- i:
+			case <-timer.C:
+				userChan <- started
 				metrics.ActiveUsers.Inc()
+				started++
+				log.Printf("Started user %d/%d", started, g.users)
 			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-g.drainCh:
+				timer.Stop()
 				return
 			}
 		}
-		return
 	}
+}
 
-	ticker := time.NewTicker(time.Second / time.Duration(g.rampRate))
-	defer ticker.Stop()
-
-	started := 0
-	for started < g.users {
+// admitRange sends user ids [from, to) onto userChan immediately.
+func (g *Generator) admitRange(ctx context.Context, userChan chan int, from, to int) {
+	for i := from; i < to; i++ {
 		select {
-		case <-ticker.C:
-			userChan <- started
+		case userChan <- i:
 			metrics.ActiveUsers.Inc()
-			started++
-			log.Printf("Started user %d/%d", started, g.users)
 		case <-ctx.Done():
 			return
+		case <-g.drainCh:
+			return
 		}
 	}
 }
@@ -0,0 +1,466 @@
+package generator
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"loadgen/internal/config"
+)
+
+// RoundTrip is the single-method shape of http.RoundTripper as a plain
+// function value, so an Interceptor's closure can stand in for "the rest
+// of the chain" without needing its own type.
+type RoundTrip func(*http.Request) (*http.Response, error)
+
+// Interceptor wraps next with additional client-side behavior (redirect
+// following, cookies, decompression, caching, retry, logging), composing
+// like net/http middleware but over RoundTrip instead of http.Handler.
+// This is deliberately the same shape of idea as internal/chaos's
+// interceptor chain, but scoped to a single simulated user's *http.Client
+// rather than chaos's shared fault-injection middleware - a user session
+// wants its own cookie jar and cache, not one shared across every user.
+type Interceptor func(next RoundTrip) RoundTrip
+
+// interceptorRegistry maps the names accepted by
+// config.Generator.Interceptors to the Interceptor they build, so a
+// config can select/reorder the chain without the package exporting a
+// constructor per behavior.
+var interceptorRegistry = map[string]func(cfg *config.Generator) Interceptor{
+	"redirect": redirectInterceptor,
+	"cookies":  cookieInterceptor,
+	"gzip":     decompressInterceptor,
+	"cache":    cacheInterceptor,
+	"retry":    retryInterceptor,
+	"log":      loggingInterceptor,
+}
+
+// defaultInterceptorOrder is the chain NewClient builds when
+// cfg.Interceptors is unset: a real browser's request lifecycle,
+// outermost (logging) to innermost (retry, closest to the wire).
+var defaultInterceptorOrder = []string{"log", "redirect", "cookies", "gzip", "cache", "retry"}
+
+// NewClient builds an *http.Client for one simulated user whose transport
+// is base (http.DefaultTransport if nil) wrapped in cfg.Generator's
+// interceptor chain, replacing the scattered ad-hoc http.Get/Post calls
+// behaviors used to make directly. names overrides cfg.Generator.Interceptors
+// for this call when non-empty; pass nil to use the config's chain (or
+// defaultInterceptorOrder if that's unset too). Unknown names are
+// skipped, matching config.Chaos.Interceptors' tolerance of a typo over
+// refusing to start. The client's own redirect following is disabled
+// (CheckRedirect always stops at the first response) so only the
+// "redirect" interceptor - or, if that's omitted, none at all - decides
+// whether to follow one.
+func NewClient(cfg *config.Config, base http.RoundTripper, names ...string) *http.Client {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if len(names) == 0 {
+		names = cfg.Generator.Interceptors
+	}
+	if len(names) == 0 {
+		names = defaultInterceptorOrder
+	}
+
+	rt := RoundTrip(base.RoundTrip)
+	for i := len(names) - 1; i >= 0; i-- {
+		build, ok := interceptorRegistry[names[i]]
+		if !ok {
+			continue
+		}
+		rt = build(&cfg.Generator)(rt)
+	}
+
+	return &http.Client{
+		Transport: roundTripFunc(rt),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// roundTripFunc adapts a RoundTrip back into an http.RoundTripper, the
+// mirror image of RoundTrip(base.RoundTrip) in NewClient.
+type roundTripFunc RoundTrip
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// redirectInterceptor follows 3xx responses itself (RoundTrippers, unlike
+// http.Client.Do, don't follow redirects), bounded by cfg.MaxRedirects and
+// with loop detection so a misbehaving service can't spin a simulated
+// user forever.
+func redirectInterceptor(cfg *config.Generator) Interceptor {
+	max := cfg.MaxRedirects
+	if max <= 0 {
+		max = 10
+	}
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			seen := make(map[string]bool)
+			for hops := 0; ; hops++ {
+				resp, err := next(req)
+				if err != nil || resp == nil || !isRedirectStatus(resp.StatusCode) {
+					return resp, err
+				}
+				loc := resp.Header.Get("Location")
+				if loc == "" {
+					return resp, nil
+				}
+				if hops >= max {
+					resp.Body.Close()
+					return nil, fmt.Errorf("generator: stopped after %d redirects from %s", max, req.URL)
+				}
+
+				target, perr := req.URL.Parse(loc)
+				if perr != nil {
+					resp.Body.Close()
+					return nil, fmt.Errorf("generator: invalid redirect location %q: %w", loc, perr)
+				}
+				if seen[target.String()] {
+					resp.Body.Close()
+					return nil, fmt.Errorf("generator: redirect loop detected at %s", target)
+				}
+				seen[target.String()] = true
+				resp.Body.Close()
+
+				nreq := req.Clone(req.Context())
+				nreq.URL = target
+				nreq.Host = ""
+				if resp.StatusCode == http.StatusSeeOther && req.Method != http.MethodGet && req.Method != http.MethodHead {
+					nreq.Method = http.MethodGet
+					nreq.Body = nil
+					nreq.ContentLength = 0
+				}
+				req = nreq
+			}
+		}
+	}
+}
+
+// cookieInterceptor gives one client its own cookie jar, attaching stored
+// cookies to outgoing requests and learning new ones from Set-Cookie, so
+// a simulated user's login session persists across its requests the way
+// a real browser's would.
+func cookieInterceptor(cfg *config.Generator) Interceptor {
+	jar, _ := cookiejar.New(nil)
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			for _, c := range jar.Cookies(req.URL) {
+				req.AddCookie(c)
+			}
+			resp, err := next(req)
+			if err == nil && resp != nil {
+				if cookies := resp.Cookies(); len(cookies) > 0 {
+					jar.SetCookies(req.URL, cookies)
+				}
+			}
+			return resp, err
+		}
+	}
+}
+
+// gzipDeflateReadCloser wraps a compress/{gzip,flate} reader with the
+// original response body's Closer, so closing the decompressed body still
+// releases the underlying connection.
+type gzipDeflateReadCloser struct {
+	io.Reader
+	underlying io.Closer
+}
+
+func (r *gzipDeflateReadCloser) Close() error { return r.underlying.Close() }
+
+// decompressInterceptor advertises gzip/deflate support and transparently
+// decompresses a matching Content-Encoding, so every later interceptor
+// and the caller always see a plain body.
+func decompressInterceptor(cfg *config.Generator) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Accept-Encoding") == "" {
+				req.Header.Set("Accept-Encoding", "gzip, deflate")
+			}
+			resp, err := next(req)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+
+			switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+			case "gzip":
+				zr, zerr := gzip.NewReader(resp.Body)
+				if zerr != nil {
+					return resp, nil
+				}
+				resp.Body = &gzipDeflateReadCloser{Reader: zr, underlying: resp.Body}
+			case "deflate":
+				resp.Body = &gzipDeflateReadCloser{Reader: flate.NewReader(resp.Body), underlying: resp.Body}
+			default:
+				return resp, nil
+			}
+			resp.Header.Del("Content-Encoding")
+			resp.Header.Del("Content-Length")
+			resp.ContentLength = -1
+			return resp, nil
+		}
+	}
+}
+
+// cachedResponse is the subset of an *http.Response cacheInterceptor
+// keeps: status/header/body, enough to replay without re-reading the
+// original (already-consumed) body.
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (c cachedResponse) toResponse(req *http.Request) *http.Response {
+	return &http.Response{
+		StatusCode:    c.status,
+		Status:        strconv.Itoa(c.status) + " " + http.StatusText(c.status),
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}
+}
+
+// cacheEntry is one cached response plus the request headers the
+// response's Vary named, captured at cache time so a later request can be
+// checked against them.
+type cacheEntry struct {
+	resp       cachedResponse
+	expires    time.Time
+	vary       []string
+	varyValues map[string]string
+}
+
+func varyHeaderNames(vary string) []string {
+	if vary == "" {
+		return nil
+	}
+	parts := strings.Split(vary, ",")
+	names := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+func snapshotHeaders(h http.Header, names []string) map[string]string {
+	out := make(map[string]string, len(names))
+	for _, n := range names {
+		out[n] = h.Get(n)
+	}
+	return out
+}
+
+func varyMatches(vary []string, cached map[string]string, reqHeader http.Header) bool {
+	for _, n := range vary {
+		if cached[n] != reqHeader.Get(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheControlMaxAge extracts max-age from a Cache-Control value, honoring
+// no-store/no-cache as "don't cache" (ok=false).
+func cacheControlMaxAge(cacheControl string) (ttl time.Duration, ok bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(strings.ToLower(directive))
+		if directive == "no-store" || directive == "no-cache" {
+			return 0, false
+		}
+		if rest, found := strings.CutPrefix(directive, "max-age="); found {
+			if secs, err := strconv.Atoi(rest); err == nil && secs > 0 {
+				return time.Duration(secs) * time.Second, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// cacheInterceptor caches GET/HEAD responses keyed by method+URL, storing
+// the subset of request headers the response's Vary lists so a later
+// request with a different Accept-Language (say) correctly misses. TTL
+// comes from the response's Cache-Control max-age, falling back to
+// cfg.CacheTTL when that's absent; a response with neither, or with
+// no-store/no-cache, is never cached.
+func cacheInterceptor(cfg *config.Generator) Interceptor {
+	var fallbackTTL time.Duration
+	if d, err := time.ParseDuration(cfg.CacheTTL); err == nil {
+		fallbackTTL = d
+	}
+
+	var mu sync.Mutex
+	entries := make(map[string]*cacheEntry)
+
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet && req.Method != http.MethodHead {
+				return next(req)
+			}
+			key := req.Method + " " + req.URL.String()
+
+			mu.Lock()
+			entry, hit := entries[key]
+			if hit && (time.Now().After(entry.expires) || !varyMatches(entry.vary, entry.varyValues, req.Header)) {
+				hit = false
+			}
+			mu.Unlock()
+			if hit {
+				return entry.resp.toResponse(req), nil
+			}
+
+			resp, err := next(req)
+			if err != nil || resp == nil || resp.StatusCode != http.StatusOK {
+				return resp, err
+			}
+
+			ttl, ok := cacheControlMaxAge(resp.Header.Get("Cache-Control"))
+			if !ok {
+				if fallbackTTL <= 0 {
+					return resp, nil
+				}
+				ttl = fallbackTTL
+			}
+
+			body, rerr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if rerr != nil {
+				return resp, rerr
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			vary := varyHeaderNames(resp.Header.Get("Vary"))
+			mu.Lock()
+			entries[key] = &cacheEntry{
+				resp:       cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body},
+				expires:    time.Now().Add(ttl),
+				vary:       vary,
+				varyValues: snapshotHeaders(req.Header, vary),
+			}
+			mu.Unlock()
+
+			return resp, nil
+		}
+	}
+}
+
+// retryInterceptor retries a request against the same host on a failed
+// round trip or a 5xx/429 response, honoring Retry-After when the
+// response sets one and otherwise backing off exponentially from
+// cfg.RetryBaseDelay with up to 50% jitter, so many simulated users
+// retrying the same outage don't all retry in lockstep.
+func retryInterceptor(cfg *config.Generator) Interceptor {
+	max := cfg.RetryMax
+	if max <= 0 {
+		max = 3
+	}
+	base, err := time.ParseDuration(cfg.RetryBaseDelay)
+	if err != nil || base <= 0 {
+		base = 100 * time.Millisecond
+	}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			var resp *http.Response
+			var err error
+			for attempt := 0; ; attempt++ {
+				resp, err = next(req)
+				retryable := err != nil || (resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500))
+				if !retryable || attempt >= max {
+					return resp, err
+				}
+
+				delay := retryAfter(resp)
+				if delay <= 0 {
+					delay = backoffWithJitter(base, attempt)
+				}
+				if resp != nil {
+					resp.Body.Close()
+				}
+
+				select {
+				case <-time.After(delay):
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			}
+		}
+	}
+}
+
+// retryAfter returns the delay resp's Retry-After header requests, or 0
+// if resp is nil or has none/an unparseable one.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// backoffWithJitter doubles base per attempt and adds up to 50% random
+// jitter, capped at 30s so a long run of failures doesn't stall a
+// simulated user for minutes between tries.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	const maxDelay = 30 * time.Second
+	d := base << attempt
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	jitter := time.Duration(rand.Int63n(int64(d)/2 + 1))
+	return d + jitter
+}
+
+// loggingInterceptor records method, URL, status, and elapsed time for
+// every request this client makes, the generator-side equivalent of
+// internal/chaos's ChaosEvent audit trail.
+func loggingInterceptor(cfg *config.Generator) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			elapsed := time.Since(start)
+			if err != nil {
+				log.Printf("generator: %s %s -> error: %v (%s)", req.Method, req.URL, err, elapsed)
+			} else {
+				log.Printf("generator: %s %s -> %d (%s)", req.Method, req.URL, resp.StatusCode, elapsed)
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"loadgen/internal/config"
+)
+
+// AuthProvider attaches whatever credential a Service's front-line
+// infrastructure expects onto an outbound request. authTransport applies
+// it to every request a NewHTTPClient-built client sends, on top of
+// (never instead of) the per-simulated-user bearer token
+// behaviors.AuthBehavior.Login already sets by hand - see
+// config.ServiceAuth for why these are two different concerns.
+type AuthProvider interface {
+	Apply(ctx context.Context, req *http.Request) error
+}
+
+// NewAuthProvider builds the AuthProvider cfg.Type selects.
+func NewAuthProvider(cfg config.ServiceAuth) (AuthProvider, error) {
+	switch cfg.Type {
+	case "", "password":
+		// The existing username/password flow is handled entirely by
+		// behaviors.AuthBehavior; this layer has nothing to add.
+		return noopAuthProvider{}, nil
+	case "api_key":
+		if cfg.APIKeyHeader == "" || cfg.APIKeyValue == "" {
+			return nil, fmt.Errorf("httpx: auth type %q requires api_key_header and api_key_value", cfg.Type)
+		}
+		if strings.EqualFold(cfg.APIKeyHeader, "Authorization") {
+			return nil, fmt.Errorf("httpx: auth type %q: api_key_header must not be %q, that's where the per-user bearer token goes", cfg.Type, cfg.APIKeyHeader)
+		}
+		return &apiKeyAuthProvider{header: cfg.APIKeyHeader, value: cfg.APIKeyValue}, nil
+	case "oauth2_client_credentials":
+		if cfg.TokenURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("httpx: auth type %q requires token_url, client_id, and client_secret", cfg.Type)
+		}
+		return newOAuth2ClientCredentialsProvider(cfg), nil
+	default:
+		return nil, fmt.Errorf("httpx: unrecognized auth type %q", cfg.Type)
+	}
+}
+
+// noopAuthProvider applies nothing - config.ServiceAuth's default, since
+// the per-user password flow needs nothing from this layer.
+type noopAuthProvider struct{}
+
+func (noopAuthProvider) Apply(context.Context, *http.Request) error { return nil }
+
+// apiKeyAuthProvider sets one static header on every request, e.g. an
+// API gateway's "X-Api-Key".
+type apiKeyAuthProvider struct {
+	header string
+	value  string
+}
+
+// Apply sets header to value. NewAuthProvider already rejects header ==
+// "Authorization" (that's where the per-user bearer token goes), so p
+// itself doesn't need to guard against it here.
+func (p *apiKeyAuthProvider) Apply(_ context.Context, req *http.Request) error {
+	req.Header.Set(p.header, p.value)
+	return nil
+}
+
+// gatewayAuthHeader carries the gateway-level OAuth2 token
+// oauth2ClientCredentialsProvider applies, instead of "Authorization"
+// (already used for the per-user bearer token) or the standard
+// "Proxy-Authorization" (sharedTransport honors Proxy from the
+// environment, so that header has a real meaning to an actual forward
+// proxy a run might be sitting behind - it isn't a safe place to stash
+// an unrelated application-level credential).
+const gatewayAuthHeader = "X-Gateway-Authorization"
+
+// oauth2ClientCredentialsProvider fetches and caches a bearer token via
+// the OAuth2 client-credentials grant, refreshing it shortly before
+// expiry instead of on every request, and applies it via
+// gatewayAuthHeader rather than Authorization so it doesn't clobber the
+// per-user bearer token behaviors.*Behavior already sets on Authorization
+// for the app's own login flow. Its token fetch uses its own plain
+// http.Client rather than going back through NewHTTPClient/httpx.Client -
+// it's a low-volume, process-wide call (not one per simulated user), so
+// it doesn't need rate limiting, retries, or a circuit breaker of its own.
+type oauth2ClientCredentialsProvider struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newOAuth2ClientCredentialsProvider(cfg config.ServiceAuth) *oauth2ClientCredentialsProvider {
+	return &oauth2ClientCredentialsProvider{
+		tokenURL:     cfg.TokenURL,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		scopes:       cfg.Scopes,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *oauth2ClientCredentialsProvider) Apply(ctx context.Context, req *http.Request) error {
+	token, err := p.tokenFor(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(gatewayAuthHeader, "Bearer "+token)
+	return nil
+}
+
+// tokenFor returns a cached token good for at least 30 more seconds,
+// fetching and caching a fresh one otherwise.
+func (p *oauth2ClientCredentialsProvider) tokenFor(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Until(p.expires) > 30*time.Second {
+		return p.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+	}
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("httpx: building oauth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("httpx: oauth2 token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("httpx: oauth2 token endpoint returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("httpx: decoding oauth2 token response: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("httpx: oauth2 token response missing access_token")
+	}
+
+	expiresIn := body.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = 300
+	}
+
+	p.token = body.AccessToken
+	p.expires = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return p.token, nil
+}
@@ -0,0 +1,154 @@
+package httpx
+
+import (
+	"sync"
+	"time"
+
+	"loadgen/internal/config"
+	"loadgen/internal/metrics"
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// breaker is a per-host circuit breaker: it tracks a rolling window of
+// success/failure outcomes per host and trips to open once the failure
+// ratio within a window crosses errorRatio, short-circuiting further
+// requests to that host until cooldown elapses, then allows a single
+// half-open trial request to decide whether to close again.
+type breaker struct {
+	mu      sync.Mutex
+	hosts   map[string]*hostState
+	service string
+
+	errorRatio float64
+	window     int
+	cooldown   time.Duration
+}
+
+type hostState struct {
+	state      breakerState
+	successes  int
+	failures   int
+	openedAt   time.Time
+	trialInUse bool
+}
+
+func newBreaker(service string, cfg config.Resilience) *breaker {
+	errorRatio := cfg.BreakerErrorRatio
+	if errorRatio <= 0 {
+		errorRatio = 0.5
+	}
+	window := cfg.BreakerWindow
+	if window <= 0 {
+		window = 20
+	}
+	cooldown := 30 * time.Second
+	if d, err := time.ParseDuration(cfg.BreakerCooldown); err == nil && d > 0 {
+		cooldown = d
+	}
+
+	return &breaker{
+		hosts:      make(map[string]*hostState),
+		service:    service,
+		errorRatio: errorRatio,
+		window:     window,
+		cooldown:   cooldown,
+	}
+}
+
+// Allow reports whether a request to host may proceed: always true when
+// closed, false while open, and true for exactly one concurrent trial
+// request per host once the cooldown has elapsed (half-open).
+func (b *breaker) Allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hs := b.hostState(host)
+	switch hs.state {
+	case stateOpen:
+		if time.Since(hs.openedAt) < b.cooldown {
+			return false
+		}
+		hs.state = stateHalfOpen
+		hs.trialInUse = true
+		b.setGauge(host, stateHalfOpen)
+		return true
+	case stateHalfOpen:
+		if hs.trialInUse {
+			return false
+		}
+		hs.trialInUse = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record reports the outcome of a request to host: success means it
+// completed without a transport error or 5xx status.
+func (b *breaker) Record(host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hs := b.hostState(host)
+
+	if hs.state == stateHalfOpen {
+		hs.trialInUse = false
+		if success {
+			b.reset(hs)
+			b.setGauge(host, stateClosed)
+		} else {
+			b.trip(host, hs)
+		}
+		return
+	}
+
+	if success {
+		hs.successes++
+	} else {
+		hs.failures++
+	}
+
+	total := hs.successes + hs.failures
+	if total < b.window {
+		return
+	}
+
+	if float64(hs.failures)/float64(total) >= b.errorRatio {
+		b.trip(host, hs)
+	} else {
+		hs.successes, hs.failures = 0, 0
+	}
+}
+
+func (b *breaker) trip(host string, hs *hostState) {
+	hs.state = stateOpen
+	hs.openedAt = time.Now()
+	hs.successes, hs.failures = 0, 0
+	b.setGauge(host, stateOpen)
+	metrics.CircuitBreakerTripsTotal.WithLabelValues(b.service, host).Inc()
+}
+
+func (b *breaker) reset(hs *hostState) {
+	hs.state = stateClosed
+	hs.successes, hs.failures = 0, 0
+}
+
+func (b *breaker) setGauge(host string, state breakerState) {
+	metrics.CircuitBreakerState.WithLabelValues(b.service, host).Set(float64(state))
+}
+
+func (b *breaker) hostState(host string) *hostState {
+	hs, ok := b.hosts[host]
+	if !ok {
+		hs = &hostState{}
+		b.hosts[host] = hs
+	}
+	return hs
+}
@@ -0,0 +1,263 @@
+// Package httpx wraps http.Client with the client-side protections every
+// outbound call from cleanup and the request behaviors needs against a
+// downstream service under test: a token-bucket rate limit, exponential
+// backoff retries with jitter for idempotent verbs and 5xx/429
+// responses (honoring Retry-After), and a circuit breaker that trips
+// per-host after a configurable error ratio and short-circuits requests
+// while open. The goal is that the load generator itself never
+// accidentally DoSes a partly-degraded service.
+//
+// NewHTTPClient (transport.go) builds the underlying *http.Client this
+// Client wraps: mTLS from config.Service.TLS, a pluggable AuthProvider
+// from config.Service.Auth (auth.go) for gateway-level credentials, and a
+// process-wide pooled transport so a run of thousands of simulated users
+// doesn't each hold its own dialer.
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"loadgen/internal/config"
+	"loadgen/internal/metrics"
+)
+
+// Client wraps an *http.Client (already carrying whatever transport
+// chain a caller built, e.g. chaos.WrapTransport + otelhttp.NewTransport)
+// with rate limiting, retries, and circuit breaking scoped to one
+// downstream service.
+type Client struct {
+	service string
+	http    *http.Client
+	limiter *tokenBucket
+	breaker *breaker
+	cfg     retryConfig
+}
+
+type retryConfig struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// New wraps httpClient with resilience behavior configured by cfg.
+// service names the downstream service for metrics labels (e.g. "chat",
+// "posts", "user"). Zero-valued fields in cfg fall back to conservative
+// defaults rather than disabling the corresponding protection.
+func New(service string, cfg config.Resilience, httpClient *http.Client) *Client {
+	return &Client{
+		service: service,
+		http:    httpClient,
+		limiter: bucketFromConfig(cfg),
+		breaker: newBreaker(service, cfg),
+		cfg:     retryConfigFromConfig(cfg),
+	}
+}
+
+func retryConfigFromConfig(cfg config.Resilience) retryConfig {
+	rc := retryConfig{maxRetries: cfg.MaxRetries}
+	if rc.maxRetries <= 0 {
+		rc.maxRetries = 3
+	}
+
+	rc.baseDelay = 100 * time.Millisecond
+	if d, err := time.ParseDuration(cfg.RetryBaseDelay); err == nil && d > 0 {
+		rc.baseDelay = d
+	}
+
+	rc.maxDelay = 5 * time.Second
+	if d, err := time.ParseDuration(cfg.RetryMaxDelay); err == nil && d > 0 {
+		rc.maxDelay = d
+	}
+
+	return rc
+}
+
+// Do sends req, retrying idempotent methods on 5xx/429 responses or
+// transport errors with exponential backoff and jitter (honoring a
+// Retry-After response header when present), unless this service's
+// circuit breaker is currently open for req's host. req.GetBody is used
+// to replay a non-nil body across retries, the same mechanism
+// http.NewRequest sets up for bytes.Buffer/bytes.Reader/strings.Reader
+// bodies.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	if !c.breaker.Allow(host) {
+		return nil, fmt.Errorf("httpx: circuit breaker open for %s (%s)", c.service, host)
+	}
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, gerr := req.GetBody()
+			if gerr == nil {
+				req.Body = body
+			}
+		}
+
+		if werr := c.limiter.Wait(req.Context()); werr != nil {
+			return nil, werr
+		}
+
+		resp, err = c.http.Do(req)
+		c.breaker.Record(host, err == nil && !isServerError(resp))
+
+		if !shouldRetry(req.Method, resp, err) || attempt >= c.cfg.maxRetries {
+			return resp, err
+		}
+
+		wait := retryAfter(resp)
+		if wait <= 0 {
+			wait = backoffDelay(c.cfg.baseDelay, c.cfg.maxDelay, attempt)
+		}
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		metrics.HTTPRetriesTotal.WithLabelValues(c.service).Inc()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func isServerError(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode >= 500
+}
+
+// shouldRetry reports whether a request is both using an idempotent
+// method and failed in a retryable way (transport error, 429, or 5xx).
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if !isIdempotent(method) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	if resp == nil {
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a 429/503 response's Retry-After header, supporting
+// both the delay-seconds and HTTP-date forms. Returns 0 if absent,
+// unparsable, or not applicable to resp's status.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// backoffDelay computes an exponentially growing delay capped at max,
+// then applies full jitter (a random duration in [0, delay]) so a burst
+// of retrying clients doesn't all retry in lockstep.
+func backoffDelay(base, max time.Duration, attempt int) time.Duration {
+	delay := base * time.Duration(1<<uint(attempt))
+	if delay <= 0 || delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// tokenBucket is a minimal token-bucket rate limiter, the same pattern
+// as chaos.tokenBucket, but blocking (Wait) rather than advisory
+// (Allow) - client-side pacing should slow requests down, not drop them.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	capacity   float64
+	refillRate float64
+	lastRefill time.Time
+}
+
+func bucketFromConfig(cfg config.Resilience) *tokenBucket {
+	rate := cfg.RatePerSec
+	if rate <= 0 {
+		rate = 1000 // effectively unbounded unless configured
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return &tokenBucket{
+		tokens:     float64(burst),
+		capacity:   float64(burst),
+		refillRate: rate,
+		lastRefill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available or ctx is cancelled.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		if b.take() {
+			return nil
+		}
+		select {
+		case <-time.After(5 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *tokenBucket) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	b.lastRefill = now
+
+	b.tokens += elapsed * b.refillRate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
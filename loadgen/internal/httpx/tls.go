@@ -0,0 +1,50 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"loadgen/internal/config"
+)
+
+// tlsConfigFromFiles builds a *tls.Config for mTLS from cfg's PEM file
+// paths. Returns (nil, nil) for a zero-value cfg, meaning: dial with
+// plain TLS against the system certificate pool, same as an unconfigured
+// http.Transport - a Service not needing mTLS isn't an error.
+func tlsConfigFromFiles(cfg config.TLS) (*tls.Config, error) {
+	if cfg == (config.TLS{}) {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("httpx: reading ca_file %s: %w", cfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("httpx: no certificates found in ca_file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("httpx: cert_file and key_file must both be set for mTLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("httpx: loading client cert/key: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
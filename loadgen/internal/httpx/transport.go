@@ -0,0 +1,143 @@
+package httpx
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"loadgen/internal/config"
+)
+
+// sharedTransport is the connection-pooled base every NewHTTPClient call
+// dials through, process-wide rather than one per Service or (worse) one
+// per simulated user: thousands of Users each holding their own
+// *http.Transport would each keep their own dial/TLS-handshake pool
+// against the same handful of downstream hosts, exhausting file
+// descriptors and defeating keep-alive reuse for no benefit. Only the
+// dialer and connection pool are shared here - TLS and auth still vary
+// per Service (see NewHTTPClient).
+var (
+	sharedTransportOnce sync.Once
+	sharedTransportVal  *http.Transport
+)
+
+func sharedTransport() *http.Transport {
+	sharedTransportOnce.Do(func() {
+		sharedTransportVal = &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        1000,
+			MaxIdleConnsPerHost: 200,
+			IdleConnTimeout:     90 * time.Second,
+		}
+	})
+	return sharedTransportVal
+}
+
+// perServiceState caches the pieces of NewHTTPClient's output that must
+// stay process-wide per label rather than be rebuilt on every call: the
+// TLS-configured *http.Transport (its own connection pool, same reason
+// sharedTransport itself is shared) and the AuthProvider (an
+// oauth2ClientCredentialsProvider's cached token is worthless rebuilt
+// from scratch every time). NewHTTPClient is called once per simulated
+// user (behaviors.NewAuth/NewChat/NewPosts/NewProfile all run from
+// user.New), so without this cache a run of thousands of users would
+// give each one its own dial pool and its own independent OAuth2 token
+// fetch - exactly what sharedTransport's own doc comment says to avoid.
+//
+// Caching by label alone assumes every NewHTTPClient call site uses one
+// fixed label for one fixed config.Service for the life of the process -
+// true of every current caller (each behaviors.New*/cleanup.New passes
+// its own service's fixed label, e.g. "chat" always means
+// cfg.Services.ChatService). A caller that reused an existing label for
+// a different Service would silently get that label's cached transport/
+// auth instead of its own; there's nothing here to catch that.
+var (
+	perServiceMu    sync.Mutex
+	perServiceCache = map[string]*perServiceState{}
+)
+
+type perServiceState struct {
+	transport http.RoundTripper
+	auth      AuthProvider
+}
+
+// perServiceStateFor returns label's cached transport/auth pair,
+// building and caching it on first use. A Service's TLS/Auth config is
+// set once at process start and never changes (config.Watcher only
+// updates BaseURL on a reload, via each behavior's UpdateConfig), so
+// caching by label alone - without comparing svcCfg - is safe; it also
+// means a misconfigured TLS or Auth block only logs its warning once
+// instead of once per user.
+func perServiceStateFor(label string, svcCfg config.Service) *perServiceState {
+	perServiceMu.Lock()
+	defer perServiceMu.Unlock()
+
+	if s, ok := perServiceCache[label]; ok {
+		return s
+	}
+
+	rt := http.RoundTripper(sharedTransport())
+	if tlsCfg, err := tlsConfigFromFiles(svcCfg.TLS); err != nil {
+		log.Printf("⚠️ Invalid TLS config for %s service, connecting without mTLS: %v", label, err)
+	} else if tlsCfg != nil {
+		cloned := sharedTransport().Clone()
+		cloned.TLSClientConfig = tlsCfg
+		rt = cloned
+	}
+
+	auth, err := NewAuthProvider(svcCfg.Auth)
+	if err != nil {
+		log.Printf("⚠️ Invalid auth config for %s service, sending requests without it: %v", label, err)
+		auth = noopAuthProvider{}
+	}
+
+	s := &perServiceState{transport: rt, auth: auth}
+	perServiceCache[label] = s
+	return s
+}
+
+// NewHTTPClient builds the *http.Client a behaviors.New*/cleanup.New
+// constructor wraps the same way it wrapped a bare &http.Client{} before
+// this existed (chaos.Shared(...).WrapTransport then
+// otelhttp.NewTransport, for the behaviors that use those): mTLS from
+// svcCfg.TLS layered onto the process-wide sharedTransport, and
+// svcCfg.Auth's AuthProvider applied to every outbound request this
+// client sends - both cached per label by perServiceStateFor, not
+// rebuilt per call. timeout is this client's per-request deadline, the
+// same 10*time.Second every constructor already hardcoded. Each call
+// still returns its own *http.Client (only the underlying transport/auth
+// are shared), since every caller goes on to overwrite client.Transport
+// with its own chaos/otel wrapping - sharing the *http.Client itself
+// would have callers stomp on each other's wrapping instead.
+func NewHTTPClient(label string, svcCfg config.Service, timeout time.Duration) *http.Client {
+	s := perServiceStateFor(label, svcCfg)
+
+	rt := s.transport
+	if _, ok := s.auth.(noopAuthProvider); !ok {
+		rt = &authTransport{base: rt, auth: s.auth}
+	}
+
+	return &http.Client{Timeout: timeout, Transport: rt}
+}
+
+// authTransport applies an AuthProvider to every outbound request before
+// delegating to base, the RoundTripper-level hook NewHTTPClient uses to
+// keep AuthProvider invisible to code built on the resulting *http.Client.
+type authTransport struct {
+	base http.RoundTripper
+	auth AuthProvider
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := t.auth.Apply(req.Context(), req); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,140 @@
+// Package logging provides the process-wide structured logger used across
+// behaviors, metrics, otel, and dashboard, replacing emoji-prefixed
+// log.Printf calls with JSON records that carry fields a log aggregator
+// can index: service, operation, status, duration_ms, user_id, trace_id,
+// span_id.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"loadgen/internal/config"
+)
+
+var (
+	mu   sync.Mutex
+	base = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+	// sampling holds the fraction (0-1) of successful-request records to
+	// drop; failures are always logged regardless of this setting.
+	sampling atomic.Value
+)
+
+func init() {
+	sampling.Store(0.0)
+}
+
+// Init reconfigures the process-wide logger from cfg. Called at startup
+// and again by a config.Watcher subscriber on hot-reload.
+func Init(cfg config.Logging) error {
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	default: // "json" and unset
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+
+	mu.Lock()
+	base = slog.New(handler)
+	mu.Unlock()
+
+	sampling.Store(cfg.Sampling)
+	return nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("logging: unknown level %q", level)
+	}
+}
+
+func logger() *slog.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	return base
+}
+
+// Request logs one behavior invocation as a single structured record,
+// pulling trace_id/span_id from ctx's active span so logs join traces in
+// Loki/Elastic. A nil err logs at Info and is subject to the configured
+// sampling rate; a non-nil err always logs at Error.
+func Request(ctx context.Context, service, operation, status string, duration time.Duration, userID string, err error) {
+	if err == nil && shouldDrop() {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("service", service),
+		slog.String("operation", operation),
+		slog.String("status", status),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	}
+	if userID != "" {
+		attrs = append(attrs, slog.String("user_id", userID))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	level := slog.LevelInfo
+	msg := operation + " succeeded"
+	if err != nil {
+		level = slog.LevelError
+		msg = operation + " failed"
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	logger().LogAttrs(ctx, level, msg, attrs...)
+}
+
+func shouldDrop() bool {
+	rate, _ := sampling.Load().(float64)
+	if rate <= 0 {
+		return false
+	}
+	return rand.Float64() < rate
+}
+
+// Info and Error log process-level events (not tied to a single
+// request/operation) - server startup, config reload, rule changes - at
+// the appropriate level with arbitrary key/value attributes.
+func Info(ctx context.Context, msg string, args ...any) {
+	logger().InfoContext(ctx, msg, args...)
+}
+
+func Debug(ctx context.Context, msg string, args ...any) {
+	logger().DebugContext(ctx, msg, args...)
+}
+
+func Error(ctx context.Context, msg string, args ...any) {
+	logger().ErrorContext(ctx, msg, args...)
+}
@@ -1,18 +1,31 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"loadgen/internal/logging"
 )
 
 var (
+	// RequestsTotal carries scenario/env/build in addition to the usual
+	// service/method/status, so a request recorded during a test run that
+	// set custom labels (see SetRunLabels) can be segmented by them -
+	// loadgen_requests_total{scenario="checkout",env="staging",...}. The
+	// vec memoizes its per-label-combination child counter internally
+	// (WithLabelValues), so RunLabel's bounded cardinality cap is what
+	// keeps this from growing unbounded, not anything on the vec itself.
 	RequestsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "loadgen_requests_total",
 			Help: "Total number of requests made",
 		},
-		[]string{"service", "method", "status"},
+		[]string{"service", "method", "status", "scenario", "env", "build"},
 	)
 
 	RequestDuration = prometheus.NewHistogramVec(
@@ -37,21 +50,187 @@ var (
 			Help: "Number of active WebSocket connections",
 		},
 	)
+
+	ChaosInjectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_chaos_injected_total",
+			Help: "Total number of chaos faults injected, by interceptor kind and the request they hit",
+		},
+		[]string{"kind", "method", "route"},
+	)
+
+	ChaosDelaySeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "loadgen_chaos_delay_seconds",
+			Help:    "Latency injected by the chaos delay interceptor, by route",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"route"},
+	)
+
+	// ChaosErrorStatus counts responses whose status the chaos status
+	// interceptor overrode, by the status code it injected - distinct from
+	// ChaosInjectedTotal's generic per-kind count, so "which status codes
+	// are we actually injecting" doesn't require cross-referencing logs.
+	ChaosErrorStatus = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_chaos_error_status",
+			Help: "Total number of responses whose status was overridden by chaos, by the status code injected",
+		},
+		[]string{"status"},
+	)
+
+	SessionStateVisits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_session_state_visits_total",
+			Help: "Total number of times a Markov-chain session profile entered a given state",
+		},
+		[]string{"profile", "state"},
+	)
+
+	SessionTransitionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_session_transitions_total",
+			Help: "Total number of Markov-chain session profile transitions between states",
+		},
+		[]string{"profile", "from", "to"},
+	)
+
+	ActionTransitionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_action_transitions_total",
+			Help: "Total number of Markov-chain action transitions taken by user.User.selectActionMarkov, by previous and next action",
+		},
+		[]string{"from", "to"},
+	)
+
+	HTTPRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_http_retries_total",
+			Help: "Total number of internal/httpx retry attempts against a downstream service",
+		},
+		[]string{"service"},
+	)
+
+	CircuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "loadgen_circuit_breaker_state",
+			Help: "Current internal/httpx circuit breaker state per service/host (0=closed, 1=open, 2=half_open)",
+		},
+		[]string{"service", "host"},
+	)
+
+	CircuitBreakerTripsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_circuit_breaker_trips_total",
+			Help: "Total number of times an internal/httpx circuit breaker tripped open for a service/host",
+		},
+		[]string{"service", "host"},
+	)
+
+	CleanupDeletesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_cleanup_deletes_total",
+			Help: "Total number of chat/posts entries deleted (or attempted) during cleanup, by resource, method, and outcome",
+		},
+		[]string{"resource", "method", "outcome"}, // method: bulk|worker; outcome: success|failure
+	)
+
+	CleanupDeleteDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "loadgen_cleanup_delete_duration_seconds",
+			Help:    "Duration of a chat/posts cleanup delete pass, by resource and method",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"resource", "method"},
+	)
+
+	ScenarioStepsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "loadgen_scenario_steps_total",
+			Help: "Total number of scenario steps executed, by scenario, step, and outcome",
+		},
+		[]string{"scenario", "step", "outcome"},
+	)
+
+	ScenarioStepDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "loadgen_scenario_step_duration_seconds",
+			Help:    "Duration of one scenario step execution, by scenario and step",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"scenario", "step"},
+	)
 )
 
+var (
+	runLabelsMu sync.RWMutex
+	runLabels   map[string]string
+)
+
+// SetRunLabels installs the current test run's custom segmentation labels
+// (e.g. scenario/env/build - see internal/web's TestRun.Labels), so
+// RequestsTotal's extra dimensions attribute to the run that produced
+// them. This process runs at most one load test at a time (see
+// internal/web.WebServer.currentTest), so a single package-level value is
+// enough; ClearRunLabels resets it once the run ends.
+func SetRunLabels(labels map[string]string) {
+	runLabelsMu.Lock()
+	defer runLabelsMu.Unlock()
+	runLabels = labels
+}
+
+// ClearRunLabels resets the labels SetRunLabels installed, so a request
+// recorded after a run ends (or by a run that set no labels) isn't
+// misattributed to the previous one.
+func ClearRunLabels() {
+	runLabelsMu.Lock()
+	defer runLabelsMu.Unlock()
+	runLabels = nil
+}
+
+// RunLabel returns the current test run's value for key ("scenario",
+// "env", or "build"), or "" if unset.
+func RunLabel(key string) string {
+	runLabelsMu.RLock()
+	defer runLabelsMu.RUnlock()
+	return runLabels[key]
+}
+
+// RequestLabelValues appends the current run's scenario/env/build values
+// to service/method/status, in RequestsTotal's label order - every
+// RequestsTotal.WithLabelValues call site uses this instead of passing
+// its three labels directly, so they stay in sync with RequestsTotal's
+// label schema.
+func RequestLabelValues(service, method, status string) []string {
+	return []string{service, method, status, RunLabel("scenario"), RunLabel("env"), RunLabel("build")}
+}
+
 func init() {
-	prometheus.MustRegister(RequestsTotal, RequestDuration, ActiveUsers, WebSocketConnections)
+	prometheus.MustRegister(
+		RequestsTotal, RequestDuration, ActiveUsers, WebSocketConnections, ChaosInjectedTotal,
+		ChaosDelaySeconds, ChaosErrorStatus,
+		SessionStateVisits, SessionTransitionsTotal, ActionTransitionsTotal,
+		HTTPRetriesTotal, CircuitBreakerState, CircuitBreakerTripsTotal,
+		CleanupDeletesTotal, CleanupDeleteDuration,
+		ScenarioStepsTotal, ScenarioStepDuration,
+	)
 }
 
 func StartServer(addr string) *http.Server {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.Handler())
-	
+
 	server := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
-	
-	go server.ListenAndServe()
+
+	go func() {
+		logging.Info(context.Background(), "metrics server starting", "addr", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error(context.Background(), "metrics server stopped", "addr", addr, "error", err.Error())
+		}
+	}()
 	return server
 }
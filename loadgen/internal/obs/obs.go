@@ -0,0 +1,147 @@
+// Package obs provides a small logger interface, carried through a
+// context.Context alongside cancellation, so a single per-run value picks
+// up fields (test_id, user_id, iteration, ...) as it's threaded deeper into
+// a call chain (runTest -> generator.Run -> user.Run -> behaviors), and
+// every log line along the way carries all of them without each layer
+// having to know about the ones set above it. This mirrors the
+// logger-lives-on-the-context migration linuxboot/contest did away from
+// passing a bare context.Context everywhere: the context still carries
+// cancellation exactly as before (see context.Context itself), it just also
+// carries a Logger now.
+//
+// Two adapters are provided - NewSlog, wrapping this process's existing
+// internal/logging-style slog.Logger, and NewZerolog, for callers who'd
+// rather emit zerolog's line-oriented JSON - so call sites depend only on
+// the Logger interface, never on a specific logging library.
+package obs
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// Logger emits structured log lines and named lifecycle events, carrying a
+// fixed set of fields set by With. Implementations must be safe for
+// concurrent use.
+type Logger interface {
+	// With returns a Logger that includes fields on every subsequent call,
+	// in addition to any fields already carried.
+	With(fields map[string]interface{}) Logger
+	// Info logs msg at info level with this Logger's fields.
+	Info(msg string)
+	// Error logs msg at error level with this Logger's fields plus err.
+	Error(msg string, err error)
+	// Event logs a named lifecycle event (e.g. "test.started") that
+	// downstream tooling can key on, distinct from free-form Info/Error
+	// messages.
+	Event(name string)
+}
+
+type ctxKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable via
+// FromContext. Cancellation still flows through ctx exactly as before -
+// this only attaches a Logger alongside it.
+func WithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext returns the Logger attached by the nearest enclosing
+// WithLogger call, or a no-op Logger if ctx carries none - so a call site
+// deep in a chain that forgot to plumb a logger degrades silently instead
+// of panicking.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+	return noop{}
+}
+
+type noop struct{}
+
+func (noop) With(map[string]interface{}) Logger { return noop{} }
+func (noop) Info(string)                        {}
+func (noop) Error(string, error)                {}
+func (noop) Event(string)                       {}
+
+type slogLogger struct {
+	base   *slog.Logger
+	fields map[string]interface{}
+}
+
+// NewSlog adapts base to Logger. Fields attached via With are kept as a
+// plain map and replayed as slog attributes on every call, rather than
+// using slog.Logger.With, so Logger.With can be called repeatedly without
+// accumulating nested slog handlers.
+func NewSlog(base *slog.Logger) Logger {
+	return slogLogger{base: base}
+}
+
+func (l slogLogger) With(fields map[string]interface{}) Logger {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return slogLogger{base: l.base, fields: merged}
+}
+
+func (l slogLogger) args() []any {
+	args := make([]any, 0, len(l.fields)*2)
+	for k, v := range l.fields {
+		args = append(args, k, v)
+	}
+	return args
+}
+
+func (l slogLogger) Info(msg string) {
+	l.base.Info(msg, l.args()...)
+}
+
+func (l slogLogger) Error(msg string, err error) {
+	args := l.args()
+	if err != nil {
+		args = append(args, "error", err.Error())
+	}
+	l.base.Error(msg, args...)
+}
+
+func (l slogLogger) Event(name string) {
+	args := append(l.args(), "event", name)
+	l.base.Info(name, args...)
+}
+
+type zerologLogger struct {
+	base zerolog.Logger
+}
+
+// NewZerolog adapts base to Logger. Fields attached via With become
+// zerolog context fields (base.With()...Logger()), matching zerolog's own
+// idiom for a logger that always carries a fixed set of fields.
+func NewZerolog(base zerolog.Logger) Logger {
+	return zerologLogger{base: base}
+}
+
+func (l zerologLogger) With(fields map[string]interface{}) Logger {
+	ctx := l.base.With()
+	for k, v := range fields {
+		ctx = ctx.Interface(k, v)
+	}
+	return zerologLogger{base: ctx.Logger()}
+}
+
+func (l zerologLogger) Info(msg string) {
+	l.base.Info().Msg(msg)
+}
+
+func (l zerologLogger) Error(msg string, err error) {
+	l.base.Error().Err(err).Msg(msg)
+}
+
+func (l zerologLogger) Event(name string) {
+	l.base.Info().Str("event", name).Msg(name)
+}
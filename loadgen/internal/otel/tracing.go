@@ -2,21 +2,62 @@ package otel
 
 import (
 	"context"
+	"fmt"
+
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+
+	"loadgen/internal/config"
+	"loadgen/internal/logging"
 )
 
-func InitTracing(endpoint string) (func(), error) {
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(endpoint)))
+// InitTracing wires up the global TracerProvider and propagator according to
+// cfg.Protocol ("otlp-http", "otlp-grpc", or "jaeger"), and installs a
+// composite W3C TraceContext + Baggage propagator so outgoing behavior
+// requests carry traceparent/tracestate headers that downstream services
+// can join.
+func InitTracing(cfg config.Tracing) (func(), error) {
+	ctx := context.Background()
+
+	var exp trace.SpanExporter
+	var err error
+
+	switch cfg.Protocol {
+	case "otlp-grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint), otlptracegrpc.WithInsecure()}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+		}
+		exp, err = otlptracegrpc.New(ctx, opts...)
+	case "jaeger":
+		exp, err = jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(cfg.Endpoint)))
+	case "otlp-http", "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+		if len(cfg.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+		}
+		exp, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing protocol %q", cfg.Protocol)
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	ratio := cfg.SamplerRatio
+	if ratio <= 0 {
+		ratio = 1.0
+	}
+
 	tp := trace.NewTracerProvider(
 		trace.WithBatcher(exp),
+		trace.WithSampler(trace.TraceIDRatioBased(ratio)),
 		trace.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
 			semconv.ServiceName("loadgen"),
@@ -25,8 +66,16 @@ func InitTracing(endpoint string) (func(), error) {
 	)
 
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	logging.Info(ctx, "tracing initialized", "protocol", cfg.Protocol, "endpoint", cfg.Endpoint, "sampler_ratio", ratio)
 
 	return func() {
-		tp.Shutdown(context.Background())
+		if err := tp.Shutdown(context.Background()); err != nil {
+			logging.Error(context.Background(), "tracer provider shutdown failed", "error", err.Error())
+		}
 	}, nil
 }
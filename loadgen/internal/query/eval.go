@@ -0,0 +1,73 @@
+package query
+
+import "time"
+
+// Instant evaluates expr as of t, returning its value at (or just before,
+// for a bare selector) t. For a rate() expr it's the rate over
+// [t-window, t]. ok is false if no matching series has data covering t.
+func (s *Store) Instant(expr *Expr, t time.Time) (v float64, ok bool) {
+	matches := s.Match(expr.Metric, expr.Labels)
+	if len(matches) == 0 {
+		return 0, false
+	}
+
+	// Multiple series can match a label-less or partially-filtered
+	// selector (e.g. two routes' chaos counters); an instant query
+	// reports the first match, same as this grammar's only caller
+	// (internal/web's single-series dashboard metrics) expects.
+	sr := matches[0]
+	if expr.Rate {
+		return rateAt(sr.Points, t, expr.Window)
+	}
+	sample, ok := nearest(sr.Points, t)
+	if !ok {
+		return 0, false
+	}
+	return sample.V, true
+}
+
+// MatrixResult is one series' worth of range-query output, shaped to
+// match the Prometheus HTTP API's query_range result entries.
+type MatrixResult struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]float64      `json:"values"`
+}
+
+// Range evaluates expr at every step from start to end inclusive,
+// returning one MatrixResult per matching series (several if expr's
+// selector has no/partial label filter and more than one series
+// matches).
+func (s *Store) Range(expr *Expr, start, end time.Time, step time.Duration) []MatrixResult {
+	if step <= 0 {
+		step = time.Second
+	}
+
+	matches := s.Match(expr.Metric, expr.Labels)
+	results := make([]MatrixResult, 0, len(matches))
+	for _, sr := range matches {
+		values := make([][2]float64, 0)
+		for t := start; !t.After(end); t = t.Add(step) {
+			var (
+				v  float64
+				ok bool
+			)
+			if expr.Rate {
+				v, ok = rateAt(sr.Points, t, expr.Window)
+			} else {
+				var sample Sample
+				sample, ok = nearest(sr.Points, t)
+				v = sample.V
+			}
+			if !ok {
+				continue
+			}
+			values = append(values, [2]float64{float64(t.Unix()), v})
+		}
+		metric := map[string]string{"__name__": sr.Name}
+		for k, v := range sr.Labels {
+			metric[k] = v
+		}
+		results = append(results, MatrixResult{Metric: metric, Values: values})
+	}
+	return results
+}
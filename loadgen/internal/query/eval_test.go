@@ -0,0 +1,115 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreRangeRateWindow(t *testing.T) {
+	s := NewStore(100)
+	base := time.Unix(1000, 0)
+
+	// A counter climbing by 10 every 10s: rate() over a 30s window
+	// anchored at each step should read back 1/sec.
+	for i := int64(0); i <= 6; i++ {
+		s.Record("http_requests_total", nil, base.Add(time.Duration(i*10)*time.Second), float64(i*100))
+	}
+
+	expr, err := Parse("rate(http_requests_total[30s])")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	results := s.Range(expr, base.Add(30*time.Second), base.Add(60*time.Second), 10*time.Second)
+	if len(results) != 1 {
+		t.Fatalf("expected one matching series, got %d", len(results))
+	}
+	values := results[0].Values
+	if len(values) == 0 {
+		t.Fatalf("expected at least one rate point")
+	}
+	for _, v := range values {
+		if got := v[1]; got < 9 || got > 11 {
+			t.Fatalf("expected rate near 10/sec, got %v", got)
+		}
+	}
+}
+
+func TestStoreRangeSkipsStepsWithoutEnoughPointsForRate(t *testing.T) {
+	s := NewStore(100)
+	base := time.Unix(1000, 0)
+	s.Record("active_users", nil, base, 5)
+
+	expr, err := Parse("rate(active_users[10s])")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	// Only one sample ever recorded, so rateAt never has two points in any
+	// window - every step should be skipped rather than reporting a rate.
+	results := s.Range(expr, base, base.Add(20*time.Second), 10*time.Second)
+	if len(results) != 1 {
+		t.Fatalf("expected one matching series, got %d", len(results))
+	}
+	if len(results[0].Values) != 0 {
+		t.Fatalf("expected no values with a single sample, got %v", results[0].Values)
+	}
+}
+
+func TestStoreRangeBareSelectorUsesNearestSample(t *testing.T) {
+	s := NewStore(100)
+	base := time.Unix(1000, 0)
+	s.Record("active_users", nil, base, 5)
+	s.Record("active_users", nil, base.Add(10*time.Second), 9)
+
+	expr, err := Parse("active_users")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	results := s.Range(expr, base, base.Add(15*time.Second), 5*time.Second)
+	if len(results) != 1 {
+		t.Fatalf("expected one matching series, got %d", len(results))
+	}
+	values := results[0].Values
+	if len(values) != 4 {
+		t.Fatalf("expected a value at each of 4 steps, got %d: %v", len(values), values)
+	}
+	if values[0][1] != 5 {
+		t.Fatalf("expected the first step to use the nearest prior sample (5), got %v", values[0][1])
+	}
+	if values[len(values)-1][1] != 9 {
+		t.Fatalf("expected the last step to use the newer sample (9), got %v", values[len(values)-1][1])
+	}
+}
+
+func TestStoreInstantRateOverWindow(t *testing.T) {
+	s := NewStore(100)
+	base := time.Unix(1000, 0)
+	s.Record("http_requests_total", nil, base, 0)
+	s.Record("http_requests_total", nil, base.Add(10*time.Second), 100)
+
+	expr, err := Parse("rate(http_requests_total[30s])")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	v, ok := s.Instant(expr, base.Add(10*time.Second))
+	if !ok {
+		t.Fatalf("expected a rate result")
+	}
+	if v != 10 {
+		t.Fatalf("expected rate 100/10s = 10/sec, got %v", v)
+	}
+}
+
+func TestStoreInstantNoMatchingSeries(t *testing.T) {
+	s := NewStore(100)
+	expr, err := Parse("nonexistent_metric")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, ok := s.Instant(expr, time.Now()); ok {
+		t.Fatalf("expected no match for a metric nothing ever recorded")
+	}
+}
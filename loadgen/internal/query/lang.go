@@ -0,0 +1,263 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Expr is a parsed query: a metric selector (name plus an optional label
+// filter), optionally wrapped in rate(...[window]). Supported grammar:
+//
+//	metric
+//	metric{label="value", ...}
+//	rate(metric[5m])
+//	rate(metric{label="value"}[5m])
+type Expr struct {
+	Metric string
+	Labels map[string]string
+	Rate   bool
+	Window time.Duration
+}
+
+// Parse reads q per Expr's grammar. It's a small hand-written
+// lexer/parser rather than a generated one - the grammar is three
+// productions, so a parser generator would be more code than it saves.
+func Parse(q string) (*Expr, error) {
+	toks, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEOF() {
+		return nil, fmt.Errorf("query: unexpected trailing input at %q", p.peek().text)
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokString
+	tokDuration
+	tokLParen
+	tokRParen
+	tokLBrace
+	tokRBrace
+	tokLBracket
+	tokRBracket
+	tokEquals
+	tokComma
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// lex tokenizes q. The grammar only needs identifiers, quoted strings,
+// durations (digits immediately followed by a unit, only meaningful
+// inside [...]), and the punctuation ( ) { } = ,.
+func lex(q string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(q) {
+		c := q[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "("})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")"})
+			i++
+		case c == '{':
+			toks = append(toks, token{tokLBrace, "{"})
+			i++
+		case c == '}':
+			toks = append(toks, token{tokRBrace, "}"})
+			i++
+		case c == '[':
+			j := strings.IndexByte(q[i:], ']')
+			if j < 0 {
+				return nil, fmt.Errorf("query: unterminated '[' in %q", q)
+			}
+			toks = append(toks, token{tokDuration, q[i+1 : i+j]})
+			i += j + 1
+		case c == ']':
+			return nil, fmt.Errorf("query: unexpected ']' in %q", q)
+		case c == '=':
+			toks = append(toks, token{tokEquals, "="})
+			i++
+		case c == ',':
+			toks = append(toks, token{tokComma, ","})
+			i++
+		case c == '"':
+			j := strings.IndexByte(q[i+1:], '"')
+			if j < 0 {
+				return nil, fmt.Errorf("query: unterminated string in %q", q)
+			}
+			toks = append(toks, token{tokString, q[i+1 : i+1+j]})
+			i += j + 2
+		case isIdentByte(c):
+			j := i
+			for j < len(q) && isIdentByte(q[j]) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, q[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("query: unexpected character %q in %q", c, q)
+		}
+	}
+	toks = append(toks, token{tokEOF, ""})
+	return toks, nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+func (p *parser) atEOF() bool { return p.peek().kind == tokEOF }
+func (p *parser) advance() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(k tokenKind, what string) (token, error) {
+	if p.peek().kind != k {
+		return token{}, fmt.Errorf("query: expected %s, got %q", what, p.peek().text)
+	}
+	return p.advance(), nil
+}
+
+// parseExpr parses `rate(selector[window])` or a bare selector.
+func (p *parser) parseExpr() (*Expr, error) {
+	if p.peek().kind == tokIdent && p.peek().text == "rate" {
+		p.advance()
+		if _, err := p.expect(tokLParen, "'('"); err != nil {
+			return nil, err
+		}
+		name, labels, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		winTok, err := p.expect(tokDuration, "[duration]")
+		if err != nil {
+			return nil, err
+		}
+		win, err := time.ParseDuration(winTok.text)
+		if err != nil {
+			return nil, fmt.Errorf("query: invalid rate() window %q: %w", winTok.text, err)
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return &Expr{Metric: name, Labels: labels, Rate: true, Window: win}, nil
+	}
+
+	name, labels, err := p.parseSelector()
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{Metric: name, Labels: labels}, nil
+}
+
+// parseSelector parses `name` or `name{label="value", ...}`.
+func (p *parser) parseSelector() (string, map[string]string, error) {
+	nameTok, err := p.expect(tokIdent, "metric name")
+	if err != nil {
+		return "", nil, err
+	}
+
+	if p.peek().kind != tokLBrace {
+		return nameTok.text, nil, nil
+	}
+	p.advance()
+
+	labels := make(map[string]string)
+	for {
+		keyTok, err := p.expect(tokIdent, "label name")
+		if err != nil {
+			return "", nil, err
+		}
+		if _, err := p.expect(tokEquals, "'='"); err != nil {
+			return "", nil, err
+		}
+		valTok, err := p.expect(tokString, "quoted label value")
+		if err != nil {
+			return "", nil, err
+		}
+		labels[keyTok.text] = valTok.text
+
+		if p.peek().kind == tokComma {
+			p.advance()
+			continue
+		}
+		break
+	}
+	if _, err := p.expect(tokRBrace, "'}'"); err != nil {
+		return "", nil, err
+	}
+	return nameTok.text, labels, nil
+}
+
+// rateAt computes (v_end - v_start) / seconds over points in
+// [at-window, at], the same semantics as Prometheus's rate() for a
+// counter series. It returns false if there aren't at least two points in
+// the window.
+func rateAt(points []Sample, at time.Time, window time.Duration) (float64, bool) {
+	lo := at.Add(-window).Unix()
+	hi := at.Unix()
+
+	var first, last *Sample
+	for i := range points {
+		pt := points[i]
+		if pt.T < lo || pt.T > hi {
+			continue
+		}
+		if first == nil {
+			first = &points[i]
+		}
+		last = &points[i]
+	}
+	if first == nil || last == nil || first.T == last.T {
+		return 0, false
+	}
+	return (last.V - first.V) / float64(last.T-first.T), true
+}
+
+// nearest returns the sample closest to (and not after) at, or false if
+// points has none at or before at.
+func nearest(points []Sample, at time.Time) (Sample, bool) {
+	target := at.Unix()
+	var best *Sample
+	for i := range points {
+		if points[i].T > target {
+			continue
+		}
+		if best == nil || points[i].T > best.T {
+			best = &points[i]
+		}
+	}
+	if best == nil {
+		return Sample{}, false
+	}
+	return *best, true
+}
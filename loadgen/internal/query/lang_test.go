@@ -0,0 +1,85 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBareSelector(t *testing.T) {
+	expr, err := Parse("http_requests_total")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if expr.Metric != "http_requests_total" || expr.Rate || len(expr.Labels) != 0 {
+		t.Fatalf("unexpected expr: %+v", expr)
+	}
+}
+
+func TestParseSelectorWithLabels(t *testing.T) {
+	expr, err := Parse(`http_requests_total{service="chat", status="200"}`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]string{"service": "chat", "status": "200"}
+	if expr.Metric != "http_requests_total" || len(expr.Labels) != len(want) {
+		t.Fatalf("unexpected expr: %+v", expr)
+	}
+	for k, v := range want {
+		if expr.Labels[k] != v {
+			t.Fatalf("label %s: expected %q, got %q", k, v, expr.Labels[k])
+		}
+	}
+}
+
+func TestParseRateWithWindow(t *testing.T) {
+	expr, err := Parse(`rate(http_requests_total{status="500"}[5m])`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !expr.Rate || expr.Window != 5*time.Minute {
+		t.Fatalf("unexpected expr: %+v", expr)
+	}
+	if expr.Metric != "http_requests_total" || expr.Labels["status"] != "500" {
+		t.Fatalf("unexpected expr: %+v", expr)
+	}
+}
+
+func TestParseRateWithoutLabels(t *testing.T) {
+	expr, err := Parse("rate(active_users[1m])")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !expr.Rate || expr.Window != time.Minute || len(expr.Labels) != 0 {
+		t.Fatalf("unexpected expr: %+v", expr)
+	}
+}
+
+func TestParseRejectsMissingWindow(t *testing.T) {
+	if _, err := Parse("rate(active_users)"); err == nil {
+		t.Fatalf("expected an error for rate() with no [window]")
+	}
+}
+
+func TestParseRejectsInvalidDuration(t *testing.T) {
+	if _, err := Parse("rate(active_users[bogus])"); err == nil {
+		t.Fatalf("expected an error for an unparsable duration")
+	}
+}
+
+func TestParseRejectsTrailingInput(t *testing.T) {
+	if _, err := Parse("active_users extra"); err == nil {
+		t.Fatalf("expected an error for unexpected trailing input")
+	}
+}
+
+func TestParseRejectsUnterminatedLabelSet(t *testing.T) {
+	if _, err := Parse(`active_users{status="200"`); err == nil {
+		t.Fatalf("expected an error for an unterminated '{'")
+	}
+}
+
+func TestParseRejectsUnterminatedString(t *testing.T) {
+	if _, err := Parse(`active_users{status="200}`); err == nil {
+		t.Fatalf("expected an error for an unterminated quoted string")
+	}
+}
@@ -0,0 +1,139 @@
+// Package query implements a small in-process time-series store and a
+// Prometheus-flavored instant/range query language over it, so
+// internal/web can chart a load test's metrics over time without standing
+// up a real TSDB. See Store for the ring buffer and Parse/Expr for the
+// expression grammar.
+package query
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultRetentionPoints bounds a series when config.Metrics.RetentionPoints
+// is unset; at internal/web's 1-sample/sec collection rate this is 30
+// minutes of history.
+const defaultRetentionPoints = 1800
+
+// Sample is one (timestamp, value) point. T is a Unix second timestamp,
+// matching the Prometheus HTTP API's [ts, value] pair shape.
+type Sample struct {
+	T int64
+	V float64
+}
+
+// Series is one named, labeled time series snapshot: a metric name, its
+// label set (e.g. {"status": "200"}), and its points in time order.
+type Series struct {
+	Name   string
+	Labels map[string]string
+	Points []Sample
+}
+
+// Store is a bounded in-memory ring buffer of samples per (name, labels)
+// series. A value is appended roughly once per second while a test runs
+// (see internal/web.WebServer.runTest) and the oldest point is dropped
+// once a series reaches retention. Store is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	retention int
+	series    map[string]*series
+}
+
+type series struct {
+	name   string
+	labels map[string]string
+	points []Sample
+}
+
+// NewStore builds a Store retaining up to retention points per series
+// (defaultRetentionPoints if retention <= 0).
+func NewStore(retention int) *Store {
+	if retention <= 0 {
+		retention = defaultRetentionPoints
+	}
+	return &Store{retention: retention, series: make(map[string]*series)}
+}
+
+// Record appends one sample to the named series, creating it (with
+// labels) on first use. labels may be nil for an unlabeled series.
+func (s *Store) Record(name string, labels map[string]string, t time.Time, v float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := seriesKey(name, labels)
+	sr, ok := s.series[k]
+	if !ok {
+		sr = &series{name: name, labels: labels}
+		s.series[k] = sr
+	}
+
+	sr.points = append(sr.points, Sample{T: t.Unix(), V: v})
+	if over := len(sr.points) - s.retention; over > 0 {
+		sr.points = sr.points[over:]
+	}
+}
+
+// Match returns every series named name whose labels are a superset of
+// filter (filter may be nil/empty to match any labels).
+func (s *Store) Match(name string, filter map[string]string) []Series {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Series
+	for _, sr := range s.series {
+		if sr.name != name || !labelsMatch(sr.labels, filter) {
+			continue
+		}
+		points := make([]Sample, len(sr.points))
+		copy(points, sr.points)
+		out = append(out, Series{Name: sr.name, Labels: sr.labels, Points: points})
+	}
+	return out
+}
+
+// Snapshot returns a deep copy of every series currently held, keyed by
+// the same internal key Record uses. internal/web persists this into
+// TestReport.Series when a run finishes, so a report stays queryable
+// after the process that produced it keeps running other tests.
+func (s *Store) Snapshot() map[string]Series {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make(map[string]Series, len(s.series))
+	for k, sr := range s.series {
+		points := make([]Sample, len(sr.points))
+		copy(points, sr.points)
+		out[k] = Series{Name: sr.name, Labels: sr.labels, Points: points}
+	}
+	return out
+}
+
+func labelsMatch(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// seriesKey builds a stable map key from a metric name and its labels so
+// two Records with the same name/labels update one series.
+func seriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	k := name
+	for _, lk := range keys {
+		k += "," + lk + "=" + labels[lk]
+	}
+	return k
+}
@@ -0,0 +1,150 @@
+package reportstore
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// JSONLStore append-logs one JSON report per line to a file, the same
+// read-whole-file/rewrite-whole-file approach internal/cleanup uses for
+// its state file. It trades List/Delete cost (always O(n), since there's
+// no index) for needing nothing beyond the standard library.
+type JSONLStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONL opens (creating if needed) the append-log at path.
+func NewJSONL(path string) (*JSONLStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("reportstore: jsonl backend requires a dsn (file path)")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("reportstore: open %s: %w", path, err)
+	}
+	f.Close()
+	return &JSONLStore{path: path}, nil
+}
+
+// readAll loads every report currently in the log. Callers must hold s.mu.
+func (s *JSONLStore) readAll() ([]Report, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var reports []Report
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r Report
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("reportstore: corrupt line in %s: %w", s.path, err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, scanner.Err()
+}
+
+// rewrite replaces the log's contents with reports. Callers must hold s.mu.
+func (s *JSONLStore) rewrite(reports []Report) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range reports {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *JSONLStore) Save(ctx context.Context, r Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(r)
+}
+
+func (s *JSONLStore) List(ctx context.Context, opts ListOpts) ([]Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Report, 0, len(all))
+	for _, r := range all {
+		if matches(r, opts) {
+			out = append(out, r)
+		}
+	}
+	return sortAndPage(out, opts), nil
+}
+
+func (s *JSONLStore) Get(ctx context.Context, id int) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return Report{}, err
+	}
+	for _, r := range all {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return Report{}, ErrNotFound
+}
+
+func (s *JSONLStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return err
+	}
+
+	kept := all[:0]
+	found := false
+	for _, r := range all {
+		if r.ID == id {
+			found = true
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return s.rewrite(kept)
+}
@@ -0,0 +1,61 @@
+package reportstore
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is the original in-process behavior: reports live only as
+// long as the process does. It's the default backend and what tests
+// should use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	reports map[int]Report
+}
+
+// NewMemory builds an empty MemoryStore.
+func NewMemory() *MemoryStore {
+	return &MemoryStore{reports: make(map[int]Report)}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, r Report) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[r.ID] = r
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, opts ListOpts) ([]Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]Report, 0, len(s.reports))
+	for _, r := range s.reports {
+		if matches(r, opts) {
+			out = append(out, r)
+		}
+	}
+	return sortAndPage(out, opts), nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id int) (Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.reports[id]
+	if !ok {
+		return Report{}, ErrNotFound
+	}
+	return r, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reports[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.reports, id)
+	return nil
+}
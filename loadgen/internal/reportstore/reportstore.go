@@ -0,0 +1,152 @@
+// Package reportstore persists internal/web's TestReport history behind a
+// pluggable Store, so a report survives a loadgen restart and can be
+// listed/filtered without the caller knowing whether it's backed by
+// memory, SQLite, or a JSON append-log.
+package reportstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"loadgen/internal/config"
+	"loadgen/internal/query"
+)
+
+// ErrNotFound is returned by Get/Delete when id doesn't match a saved
+// report.
+var ErrNotFound = errors.New("reportstore: report not found")
+
+// Report is one completed or stopped load test run. It's the same shape
+// internal/web has always returned from /api/reports; internal/web
+// aliases its TestReport type to this one so call sites there didn't need
+// to change field-by-field.
+type Report struct {
+	ID              int                     `json:"id"`
+	Users           int                     `json:"users"`
+	Duration        string                  `json:"duration"`
+	Ramp            string                  `json:"ramp"`
+	WorkloadProfile string                  `json:"workload_profile,omitempty"`
+	StartTime       time.Time               `json:"start_time"`
+	EndTime         time.Time               `json:"end_time"`
+	Status          string                  `json:"status"`
+	Metrics         map[string]interface{}  `json:"metrics"`
+	TrackedUsers    []string                `json:"tracked_users"`
+	Series          map[string]query.Series `json:"series,omitempty"`
+	// Labels are the run's custom segmented-reporting properties (e.g.
+	// env=staging, scenario=checkout), set via the start form/POST
+	// /api/start body and validated by internal/web before the run ever
+	// starts (see web.validateLabels).
+	Labels map[string]string `json:"labels,omitempty"`
+	// Partial is true when this report comes from a distributed run (see
+	// internal/coordinator) that lost an agent mid-test with no other
+	// agent able to absorb its range (coordinator.Coordinator.Partial) -
+	// its metrics and TrackedUsers undercount the requested Users.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// ListOpts filters and paginates List, mirroring the filter-options shape
+// this codebase's alert-list APIs use: a time window, an exact-match
+// field, a numeric range, then limit/offset/sort.
+type ListOpts struct {
+	Since, Until       time.Time
+	Status             string
+	MinUsers, MaxUsers int
+	Limit, Offset      int
+	SortBy             string // "start_time" (default), "id", or "users"; always descending
+	// Labels filters to reports whose Labels contain every key/value pair
+	// here (e.g. {"scenario": "checkout"} for ?label.scenario=checkout).
+	// Unlike the other filters, sqlite can't push this into SQL - Labels
+	// live in its JSON payload column, not an indexed column - so
+	// SQLiteStore.List applies it in Go after decoding each row.
+	Labels map[string]string
+}
+
+// Store is the persistence boundary for test reports. Save assigns
+// nothing - callers set Report.ID before calling Save (see
+// internal/web.WebServer.nextReportID) - so every backend can use the ID
+// as its primary key without coordinating ID generation itself.
+type Store interface {
+	Save(ctx context.Context, r Report) error
+	List(ctx context.Context, opts ListOpts) ([]Report, error)
+	Get(ctx context.Context, id int) (Report, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// New builds the Store cfg.Backend selects ("memory" if empty), wiring
+// cfg.DSN through to the sqlite/postgres/jsonl backends.
+func New(cfg config.Reports) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemory(), nil
+	case "sqlite":
+		return NewSQLite(cfg.DSN)
+	case "postgres":
+		return NewPostgres(cfg.DSN)
+	case "jsonl":
+		return NewJSONL(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("reportstore: unknown backend %q", cfg.Backend)
+	}
+}
+
+// matches reports whether r satisfies opts' filters, shared by the
+// memory/jsonl backends (sqlite pushes the same filters into SQL).
+func matches(r Report, opts ListOpts) bool {
+	if !opts.Since.IsZero() && r.StartTime.Before(opts.Since) {
+		return false
+	}
+	if !opts.Until.IsZero() && r.StartTime.After(opts.Until) {
+		return false
+	}
+	if opts.Status != "" && r.Status != opts.Status {
+		return false
+	}
+	if opts.MinUsers > 0 && r.Users < opts.MinUsers {
+		return false
+	}
+	if opts.MaxUsers > 0 && r.Users > opts.MaxUsers {
+		return false
+	}
+	return matchesLabels(r, opts.Labels)
+}
+
+// matchesLabels reports whether r's Labels contain every key/value pair in
+// want.
+func matchesLabels(r Report, want map[string]string) bool {
+	for k, v := range want {
+		if r.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// sortAndPage sorts the already-filtered reports per opts.SortBy
+// (descending) and applies Offset/Limit, shared by the memory/jsonl
+// backends.
+func sortAndPage(reports []Report, opts ListOpts) []Report {
+	sort.SliceStable(reports, func(i, j int) bool {
+		switch opts.SortBy {
+		case "id":
+			return reports[i].ID > reports[j].ID
+		case "users":
+			return reports[i].Users > reports[j].Users
+		default:
+			return reports[i].StartTime.After(reports[j].StartTime)
+		}
+	})
+
+	if opts.Offset > 0 {
+		if opts.Offset >= len(reports) {
+			return []Report{}
+		}
+		reports = reports[opts.Offset:]
+	}
+	if opts.Limit > 0 && opts.Limit < len(reports) {
+		reports = reports[:opts.Limit]
+	}
+	return reports
+}
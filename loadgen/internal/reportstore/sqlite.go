@@ -0,0 +1,193 @@
+package reportstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// modernc.org/sqlite is a CGO-free driver, so this backend doesn't
+	// force the rest of the binary to build with cgo enabled just to get
+	// a persisted report store.
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore persists reports in a SQLite database. Users/Status/
+// StartTime get their own indexed columns so List's filters translate
+// straight into a WHERE clause; everything else (metrics, tracked users,
+// series) is stored as one JSON blob, since none of it is queried on.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLite opens (creating if needed) the SQLite database at dsn and
+// ensures its schema exists.
+func NewSQLite(dsn string) (*SQLiteStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("reportstore: sqlite backend requires a dsn")
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("reportstore: open %s: %w", dsn, err)
+	}
+
+	const schema = `
+CREATE TABLE IF NOT EXISTS reports (
+	id          INTEGER PRIMARY KEY,
+	users       INTEGER NOT NULL,
+	status      TEXT NOT NULL,
+	start_time  INTEGER NOT NULL,
+	end_time    INTEGER NOT NULL,
+	duration    TEXT NOT NULL,
+	ramp        TEXT NOT NULL,
+	workload    TEXT NOT NULL,
+	payload     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS reports_start_time_idx ON reports(start_time);
+CREATE INDEX IF NOT EXISTS reports_status_idx ON reports(status);
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reportstore: create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, r Report) error {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO reports (id, users, status, start_time, end_time, duration, ramp, workload, payload)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	users=excluded.users, status=excluded.status, start_time=excluded.start_time,
+	end_time=excluded.end_time, duration=excluded.duration, ramp=excluded.ramp,
+	workload=excluded.workload, payload=excluded.payload`,
+		r.ID, r.Users, r.Status, r.StartTime.Unix(), r.EndTime.Unix(), r.Duration, r.Ramp, r.WorkloadProfile, payload)
+	return err
+}
+
+func (s *SQLiteStore) List(ctx context.Context, opts ListOpts) ([]Report, error) {
+	var where []string
+	var args []interface{}
+
+	if !opts.Since.IsZero() {
+		where = append(where, "start_time >= ?")
+		args = append(args, opts.Since.Unix())
+	}
+	if !opts.Until.IsZero() {
+		where = append(where, "start_time <= ?")
+		args = append(args, opts.Until.Unix())
+	}
+	if opts.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, opts.Status)
+	}
+	if opts.MinUsers > 0 {
+		where = append(where, "users >= ?")
+		args = append(args, opts.MinUsers)
+	}
+	if opts.MaxUsers > 0 {
+		where = append(where, "users <= ?")
+		args = append(args, opts.MaxUsers)
+	}
+
+	// Labels live in the JSON payload column, not an indexed one, so they
+	// can't be pushed into the WHERE clause - filter for them in Go below
+	// instead, which means LIMIT/OFFSET have to move there too.
+	filterLabels := len(opts.Labels) > 0
+
+	query := "SELECT payload FROM reports"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + sortColumn(opts.SortBy) + " DESC"
+	if !filterLabels {
+		if opts.Limit > 0 {
+			query += " LIMIT ?"
+			args = append(args, opts.Limit)
+			if opts.Offset > 0 {
+				query += " OFFSET ?"
+				args = append(args, opts.Offset)
+			}
+		} else if opts.Offset > 0 {
+			// SQLite requires a LIMIT to use OFFSET; -1 means "no limit".
+			query += " LIMIT -1 OFFSET ?"
+			args = append(args, opts.Offset)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Report
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var r Report
+		if err := json.Unmarshal([]byte(payload), &r); err != nil {
+			return nil, err
+		}
+		if filterLabels && !matchesLabels(r, opts.Labels) {
+			continue
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if filterLabels {
+		out = sortAndPage(out, ListOpts{SortBy: opts.SortBy, Limit: opts.Limit, Offset: opts.Offset})
+	}
+	return out, nil
+}
+
+func sortColumn(sortBy string) string {
+	switch sortBy {
+	case "id":
+		return "id"
+	case "users":
+		return "users"
+	default:
+		return "start_time"
+	}
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id int) (Report, error) {
+	var payload string
+	err := s.db.QueryRowContext(ctx, "SELECT payload FROM reports WHERE id = ?", id).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return Report{}, ErrNotFound
+	}
+	if err != nil {
+		return Report{}, err
+	}
+	var r Report
+	if err := json.Unmarshal([]byte(payload), &r); err != nil {
+		return Report{}, err
+	}
+	return r, nil
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM reports WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+// Package runner drives the weighted virtual-user journeys described by a
+// config.Config's Scenarios block, replacing the hand-coded goroutine
+// launches in internal/generator with a config-driven scheduler so load
+// shapes can be tuned without recompiling.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"loadgen/internal/behaviors"
+	"loadgen/internal/config"
+	"loadgen/internal/metrics"
+)
+
+// Runner schedules virtual users across one or more compiled scenarios,
+// ramping each scenario's user pool and honoring context cancellation on
+// shutdown. cfg and scenarios can be swapped at runtime via ApplyConfig, so
+// a config.Watcher subscriber can hot-reload scenario tunables; only
+// virtual users spawned after the swap see the new definitions, since
+// in-flight journeys already hold their own compiledScenario value.
+type Runner struct {
+	mu        sync.RWMutex
+	cfg       *config.Config
+	scenarios []compiledScenario
+}
+
+type compiledScenario struct {
+	config.Scenario
+	thinkMin time.Duration
+	thinkMax time.Duration
+	rampDur  time.Duration
+}
+
+// New compiles cfg.Scenarios, parsing think-time and ramp durations up
+// front so malformed config surfaces immediately rather than mid-run.
+func New(cfg *config.Config) (*Runner, error) {
+	compiled, err := compileScenarios(cfg.Scenarios)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{cfg: cfg, scenarios: compiled}, nil
+}
+
+// ApplyConfig recompiles cfg.Scenarios and swaps it in, used by the config
+// package's Watcher to apply hot-reloaded scenario and service tunables
+// without restarting the process.
+func (r *Runner) ApplyConfig(cfg *config.Config) error {
+	compiled, err := compileScenarios(cfg.Scenarios)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cfg = cfg
+	r.scenarios = compiled
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Runner) snapshot() (*config.Config, []compiledScenario) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cfg, r.scenarios
+}
+
+func compileScenarios(scenarios []config.Scenario) ([]compiledScenario, error) {
+	compiled := make([]compiledScenario, 0, len(scenarios))
+	for _, sc := range scenarios {
+		cs := compiledScenario{Scenario: sc}
+
+		if sc.ThinkTime.Min != "" {
+			d, err := time.ParseDuration(sc.ThinkTime.Min)
+			if err != nil {
+				return nil, fmt.Errorf("scenario %q: invalid think_time.min: %w", sc.Name, err)
+			}
+			cs.thinkMin = d
+		}
+		cs.thinkMax = cs.thinkMin
+		if sc.ThinkTime.Max != "" {
+			d, err := time.ParseDuration(sc.ThinkTime.Max)
+			if err != nil {
+				return nil, fmt.Errorf("scenario %q: invalid think_time.max: %w", sc.Name, err)
+			}
+			cs.thinkMax = d
+		}
+
+		if sc.Ramp.Duration != "" {
+			d, err := time.ParseDuration(sc.Ramp.Duration)
+			if err != nil {
+				return nil, fmt.Errorf("scenario %q: invalid ramp.duration: %w", sc.Name, err)
+			}
+			cs.rampDur = d
+		}
+
+		compiled = append(compiled, cs)
+	}
+	return compiled, nil
+}
+
+// Run ramps every scenario's user pool concurrently and blocks until ctx is
+// cancelled and all spawned virtual users have exited.
+func (r *Runner) Run(ctx context.Context) {
+	_, scenarios := r.snapshot()
+	if len(scenarios) == 0 {
+		log.Println("runner: no scenarios configured, nothing to run")
+		return
+	}
+
+	owned, shared := splitByTargetUsers(scenarios)
+
+	var wg sync.WaitGroup
+	for _, sc := range owned {
+		wg.Add(1)
+		go func(sc compiledScenario) {
+			defer wg.Done()
+			r.rampScenario(ctx, sc, sc.Ramp.TargetUsers, func(id int) { r.runVUser(ctx, sc, id) })
+		}(sc)
+	}
+
+	if len(shared) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runSharedPool(ctx, shared)
+		}()
+	}
+
+	wg.Wait()
+	log.Println("runner: all scenarios completed")
+}
+
+// splitByTargetUsers separates scenarios that define their own ramp target
+// from ones that don't - the latter share a single ramp pool sized by
+// whichever owned scenario has the largest target, split by weight.
+func splitByTargetUsers(scenarios []compiledScenario) (owned, shared []compiledScenario) {
+	for _, sc := range scenarios {
+		if sc.Ramp.TargetUsers > 0 {
+			owned = append(owned, sc)
+		} else {
+			shared = append(shared, sc)
+		}
+	}
+	return owned, shared
+}
+
+// runSharedPool ramps a single pool (sized and paced by the largest owned
+// scenario, or a conservative default if every scenario omitted a target)
+// and assigns each newly spawned virtual user to one of the weight-only
+// scenarios via weighted random pick.
+func (r *Runner) runSharedPool(ctx context.Context, weightOnly []compiledScenario) {
+	_, scenarios := r.snapshot()
+	target, rampType, rampDur := 100, "linear", 60*time.Second
+	for _, sc := range scenarios {
+		if sc.Ramp.TargetUsers > target {
+			target = sc.Ramp.TargetUsers
+			rampType = sc.Ramp.Type
+			rampDur = sc.rampDur
+		}
+	}
+
+	sized := compiledScenario{
+		Scenario: config.Scenario{Ramp: config.Ramp{Type: rampType, TargetUsers: target, Duration: rampDur.String()}},
+		rampDur:  rampDur,
+	}
+
+	r.rampScenario(ctx, sized, target, func(id int) {
+		sc := pickWeighted(weightOnly)
+		r.runVUser(ctx, sc, id)
+	})
+}
+
+// pickWeighted chooses a scenario by weighted random selection, the same
+// pattern used by chaos.weightedStatus and user.selectActionWeighted.
+func pickWeighted(scenarios []compiledScenario) compiledScenario {
+	var total float64
+	for _, sc := range scenarios {
+		total += sc.Weight
+	}
+	if total <= 0 {
+		return scenarios[rand.Intn(len(scenarios))]
+	}
+
+	r := rand.Float64() * total
+	for _, sc := range scenarios {
+		r -= sc.Weight
+		if r <= 0 {
+			return sc
+		}
+	}
+	return scenarios[len(scenarios)-1]
+}
+
+// rampScenario grows a pool of target virtual users according to sc.Ramp.Type,
+// invoking spawn(id) for each and tracking metrics.ActiveUsers.
+func (r *Runner) rampScenario(ctx context.Context, sc compiledScenario, target int, spawn func(id int)) {
+	if target <= 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	launch := func(id int) {
+		wg.Add(1)
+		metrics.ActiveUsers.Inc()
+		go func() {
+			defer wg.Done()
+			defer metrics.ActiveUsers.Dec()
+			spawn(id)
+		}()
+	}
+
+	switch sc.Ramp.Type {
+	case "spike":
+		for i := 0; i < target; i++ {
+			launch(i)
+		}
+
+	case "step":
+		const batches = 4
+		interval := sc.rampDur / batches
+		batchSize := (target + batches - 1) / batches
+		id := 0
+		for b := 0; b < batches && id < target; b++ {
+			for i := 0; i < batchSize && id < target; i++ {
+				launch(id)
+				id++
+			}
+			if b < batches-1 {
+				select {
+				case <-time.After(interval):
+				case <-ctx.Done():
+					wg.Wait()
+					return
+				}
+			}
+		}
+
+	default: // "linear" and unrecognized types fall back to a steady trickle
+		interval := sc.rampDur / time.Duration(target)
+		if interval <= 0 {
+			interval = time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for id := 0; id < target; id++ {
+			select {
+			case <-ticker.C:
+				launch(id)
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+		}
+	}
+
+	wg.Wait()
+}
+
+// vuser is a single scenario's virtual user: one set of behavior clients
+// replaying sc.Steps in a loop until ctx is cancelled.
+type vuser struct {
+	id      int
+	token   string
+	userID  string
+	auth    *behaviors.AuthBehavior
+	chat    *behaviors.ChatBehavior
+	posts   *behaviors.PostsBehavior
+	profile *behaviors.ProfileBehavior
+}
+
+func (r *Runner) runVUser(ctx context.Context, sc compiledScenario, id int) {
+	cfg, _ := r.snapshot()
+	v := &vuser{
+		id:      id,
+		userID:  strconv.Itoa(id),
+		auth:    behaviors.NewAuth(cfg),
+		chat:    behaviors.NewChat(cfg),
+		posts:   behaviors.NewPosts(cfg),
+		profile: behaviors.NewProfile(cfg),
+	}
+
+	if len(sc.Steps) == 0 {
+		return
+	}
+
+	for {
+		for _, step := range sc.Steps {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			stepStart := time.Now()
+			outcome := v.runStep(ctx, step)
+			metrics.ScenarioStepsTotal.WithLabelValues(sc.Name, step, outcome).Inc()
+			metrics.ScenarioStepDuration.WithLabelValues(sc.Name, step).Observe(time.Since(stepStart).Seconds())
+
+			r.thinkFor(ctx, sc)
+		}
+	}
+}
+
+// thinkFor pauses between steps according to sc's think-time distribution,
+// returning early if ctx is cancelled mid-pause.
+func (r *Runner) thinkFor(ctx context.Context, sc compiledScenario) {
+	d := thinkDuration(sc)
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+}
+
+func thinkDuration(sc compiledScenario) time.Duration {
+	switch sc.ThinkTime.Distribution {
+	case "exponential":
+		mean := (sc.thinkMin + sc.thinkMax) / 2
+		if mean <= 0 {
+			return 0
+		}
+		d := time.Duration(rand.ExpFloat64() * float64(mean))
+		if sc.thinkMax > 0 && d > sc.thinkMax {
+			d = sc.thinkMax
+		}
+		return d
+	case "uniform":
+		if sc.thinkMax <= sc.thinkMin {
+			return sc.thinkMin
+		}
+		return sc.thinkMin + time.Duration(rand.Int63n(int64(sc.thinkMax-sc.thinkMin)))
+	default: // "constant"
+		return sc.thinkMin
+	}
+}
+
+// runStep dispatches a single "behavior.action" step name to the matching
+// behaviors call, returning an outcome label for metrics.ScenarioStepsTotal.
+// Unknown steps are logged and skipped so a typo in a scenario config
+// doesn't take down the whole run.
+func (v *vuser) runStep(ctx context.Context, step string) string {
+	switch step {
+	case "auth.login":
+		v.login(ctx)
+	case "auth.register":
+		v.auth.Register(ctx, v.username(), v.username()+"@example.com", "password123")
+	case "profile.update":
+		v.profile.UpdateProfile(ctx, v.token, v.userID)
+	case "profile.view":
+		v.profile.GetProfile(ctx, v.token, v.userID)
+	case "posts.create":
+		v.posts.CreatePost(ctx, v.token, fmt.Sprintf("scenario post from %s", v.username()))
+	case "posts.like":
+		if posts := v.posts.GetPosts(ctx, v.token); len(posts) > 0 {
+			v.posts.LikePost(ctx, v.token, posts[rand.Intn(len(posts))].ID)
+		}
+	case "posts.view":
+		v.posts.GetPosts(ctx, v.token)
+	case "chat.connect":
+		go v.chat.Connect(ctx, v.token)
+	case "chat.send":
+		v.chat.SendMessage(ctx, fmt.Sprintf("scenario message from %s", v.username()))
+	case "chat.read":
+		v.chat.GetMessages(ctx)
+	default:
+		log.Printf("runner: unknown scenario step %q, skipping", step)
+		return "unknown_step"
+	}
+	return "ok"
+}
+
+func (v *vuser) username() string {
+	return fmt.Sprintf("scenario_user_%d", v.id)
+}
+
+func (v *vuser) login(ctx context.Context) {
+	token, err := v.auth.Login(ctx, v.username(), "password123")
+	if err != nil {
+		if err := v.auth.Register(ctx, v.username(), v.username()+"@example.com", "password123"); err != nil {
+			log.Printf("runner: user %s register failed: %v", v.username(), err)
+			return
+		}
+		token, err = v.auth.Login(ctx, v.username(), "password123")
+		if err != nil {
+			log.Printf("runner: user %s login after register failed: %v", v.username(), err)
+			return
+		}
+	}
+	v.token = token
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronExpr is a parsed 5-field cron expression (minute hour
+// day-of-month month day-of-week), supporting "*", "*/n", "a-b", and
+// "a,b,c" per field - the subset this package's schedules need, without
+// pulling in an external cron dependency. Unlike a full cron
+// implementation, a restricted day-of-month and day-of-week are ANDed
+// together rather than ORed; that's simpler to reason about and every
+// schedule this control panel creates restricts at most one of the two.
+type CronExpr struct {
+	raw    string
+	minute fieldMatcher
+	hour   fieldMatcher
+	dom    fieldMatcher
+	month  fieldMatcher
+	dow    fieldMatcher
+}
+
+type fieldMatcher func(v int) bool
+
+// cronFieldRanges bounds minute, hour, day-of-month, month, and
+// day-of-week respectively.
+var cronFieldRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// Parse parses a 5-field cron expression.
+func Parse(expr string) (CronExpr, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return CronExpr{}, fmt.Errorf("scheduler: cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	matchers := make([]fieldMatcher, 5)
+	for i, f := range fields {
+		m, err := parseCronField(f, cronFieldRanges[i][0], cronFieldRanges[i][1])
+		if err != nil {
+			return CronExpr{}, fmt.Errorf("scheduler: field %d (%q): %w", i+1, f, err)
+		}
+		matchers[i] = m
+	}
+
+	return CronExpr{raw: expr, minute: matchers[0], hour: matchers[1], dom: matchers[2], month: matchers[3], dow: matchers[4]}, nil
+}
+
+// parseCronField parses one "*", "*/n", "a-b", or "a,b,c" field (any of
+// those may be comma-joined) into a fieldMatcher testing membership.
+func parseCronField(f string, min, max int) (fieldMatcher, error) {
+	if f == "*" {
+		return func(int) bool { return true }, nil
+	}
+
+	allowed := make(map[int]bool)
+	for _, part := range strings.Split(f, ",") {
+		switch {
+		case strings.HasPrefix(part, "*/"):
+			step, err := strconv.Atoi(part[2:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q", part)
+			}
+			for v := min; v <= max; v += step {
+				allowed[v] = true
+			}
+
+		case strings.Contains(part, "-"):
+			lo, hi, _ := strings.Cut(part, "-")
+			loN, err1 := strconv.Atoi(lo)
+			hiN, err2 := strconv.Atoi(hi)
+			if err1 != nil || err2 != nil || loN > hiN {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			for v := loN; v <= hiN; v++ {
+				allowed[v] = true
+			}
+
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			allowed[n] = true
+		}
+	}
+
+	return func(v int) bool { return allowed[v] }, nil
+}
+
+// maxCronLookahead bounds how far into the future Next searches before
+// giving up, so a field combination that can never match (e.g. Feb 30)
+// fails fast instead of looping forever.
+const maxCronLookahead = 366 * 24 * 60
+
+// Next returns the first minute-aligned time strictly after from that
+// this expression matches.
+func (c CronExpr) Next(from time.Time) (time.Time, error) {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < maxCronLookahead; i++ {
+		if c.matches(t) {
+			return t, nil
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}, fmt.Errorf("scheduler: cron expression %q has no matching time within a year", c.raw)
+}
+
+func (c CronExpr) matches(t time.Time) bool {
+	return c.minute(t.Minute()) && c.hour(t.Hour()) && c.dom(t.Day()) &&
+		c.month(int(t.Month())) && c.dow(int(t.Weekday()))
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// JSONLStore append-logs one JSON schedule per line to a file, the same
+// read-whole-file/rewrite-whole-file approach reportstore.JSONLStore (and,
+// before that, internal/cleanup's state file) use.
+type JSONLStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJSONL opens (creating if needed) the append-log at path.
+func NewJSONL(path string) (*JSONLStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("scheduler: jsonl backend requires a dsn (file path)")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: open %s: %w", path, err)
+	}
+	f.Close()
+	return &JSONLStore{path: path}, nil
+}
+
+// readAll loads every schedule currently in the log. Callers must hold s.mu.
+func (s *JSONLStore) readAll() ([]ScheduledTest, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var out []ScheduledTest
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var sch ScheduledTest
+		if err := json.Unmarshal(line, &sch); err != nil {
+			return nil, fmt.Errorf("scheduler: corrupt line in %s: %w", s.path, err)
+		}
+		out = append(out, sch)
+	}
+	return out, scanner.Err()
+}
+
+// rewrite replaces the log's contents with schedules. Callers must hold s.mu.
+func (s *JSONLStore) rewrite(schedules []ScheduledTest) error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, sch := range schedules {
+		if err := enc.Encode(sch); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *JSONLStore) Save(ctx context.Context, sch ScheduledTest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, existing := range all {
+		if existing.ID == sch.ID {
+			all[i] = sch
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		all = append(all, sch)
+	}
+	return s.rewrite(all)
+}
+
+func (s *JSONLStore) List(ctx context.Context) ([]ScheduledTest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return nil, err
+	}
+	return sortByID(all), nil
+}
+
+func (s *JSONLStore) Get(ctx context.Context, id int) (ScheduledTest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return ScheduledTest{}, err
+	}
+	for _, sch := range all {
+		if sch.ID == id {
+			return sch, nil
+		}
+	}
+	return ScheduledTest{}, ErrNotFound
+}
+
+func (s *JSONLStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.readAll()
+	if err != nil {
+		return err
+	}
+
+	kept := all[:0]
+	found := false
+	for _, sch := range all {
+		if sch.ID == id {
+			found = true
+			continue
+		}
+		kept = append(kept, sch)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return s.rewrite(kept)
+}
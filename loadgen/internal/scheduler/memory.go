@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore is the default backend: schedules live only as long as the
+// process does.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	schedules map[int]ScheduledTest
+}
+
+// NewMemory builds an empty MemoryStore.
+func NewMemory() *MemoryStore {
+	return &MemoryStore{schedules: make(map[int]ScheduledTest)}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, sch ScheduledTest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[sch.ID] = sch
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context) ([]ScheduledTest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]ScheduledTest, 0, len(s.schedules))
+	for _, sch := range s.schedules {
+		out = append(out, sch)
+	}
+	return sortByID(out), nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id int) (ScheduledTest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sch, ok := s.schedules[id]
+	if !ok {
+		return ScheduledTest{}, ErrNotFound
+	}
+	return sch, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.schedules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.schedules, id)
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package scheduler persists recurring load-test schedules and evaluates
+// their cron expressions, so internal/web.WebServer can fire a ScheduledTest
+// automatically instead of every test run being started by hand via
+// POST /api/start.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"loadgen/internal/config"
+)
+
+// ErrNotFound is returned by Get/Delete when id doesn't match a saved
+// schedule.
+var ErrNotFound = errors.New("scheduler: schedule not found")
+
+// RunSpec is the load shape a ScheduledTest fires, the same fields
+// internal/web.TestRun configures from a manual POST /api/start.
+type RunSpec struct {
+	Users           int    `json:"users"`
+	Duration        string `json:"duration"`
+	Ramp            string `json:"ramp"`
+	WorkloadProfile string `json:"workload_profile,omitempty"`
+}
+
+// ScheduledTest is one recurring, or one-shot, load test. A recurring
+// schedule has a non-empty CronExpr (see Parse) and NextRun is
+// recomputed and re-saved every time it fires; a one-shot schedule has
+// an empty CronExpr, fires exactly once at StartAt, and is disabled
+// afterward. EndAt, if set, stops a recurring schedule from firing again
+// once passed (it's disabled rather than deleted, so its history stays
+// visible).
+type ScheduledTest struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name,omitempty"`
+	CronExpr string    `json:"cron_expr,omitempty"`
+	Spec     RunSpec   `json:"spec"`
+	NextRun  time.Time `json:"next_run"`
+	StartAt  time.Time `json:"start_at,omitempty"`
+	EndAt    time.Time `json:"end_at,omitempty"`
+	Enabled  bool      `json:"enabled"`
+}
+
+// OneShot reports whether s fires exactly once (at StartAt) rather than
+// recurring on a cron expression.
+func (s ScheduledTest) OneShot() bool {
+	return s.CronExpr == ""
+}
+
+// Store is the persistence boundary for schedules, the same shape as
+// internal/reportstore.Store: Save upserts by ID, callers assign IDs
+// themselves (see internal/web.WebServer.nextScheduleID).
+type Store interface {
+	Save(ctx context.Context, s ScheduledTest) error
+	List(ctx context.Context) ([]ScheduledTest, error)
+	Get(ctx context.Context, id int) (ScheduledTest, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// New builds the Store cfg.Backend selects ("memory" if empty), wiring
+// cfg.DSN through to the sqlite/jsonl backends - the same factory shape
+// as reportstore.New.
+func New(cfg config.Schedules) (Store, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemory(), nil
+	case "sqlite":
+		return NewSQLite(cfg.DSN)
+	case "jsonl":
+		return NewJSONL(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("scheduler: unknown backend %q", cfg.Backend)
+	}
+}
+
+// sortByID orders schedules ascending by ID, giving List a stable,
+// predictable order across the memory/jsonl backends (sqlite's already
+// sorted by its query).
+func sortByID(schedules []ScheduledTest) []ScheduledTest {
+	sort.SliceStable(schedules, func(i, j int) bool { return schedules[i].ID < schedules[j].ID })
+	return schedules
+}
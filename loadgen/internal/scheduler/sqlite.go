@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	// modernc.org/sqlite is a CGO-free driver, so this backend doesn't
+	// force the rest of the binary to build with cgo enabled just to get
+	// persisted schedules.
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore persists schedules in a SQLite database. NextRun gets its
+// own indexed column since fireDueSchedules (internal/web) would
+// otherwise have to deserialize every schedule's payload just to check
+// it; everything else is stored as one JSON blob.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLite opens (creating if needed) the SQLite database at dsn and
+// ensures its schema exists.
+func NewSQLite(dsn string) (*SQLiteStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("scheduler: sqlite backend requires a dsn")
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: open %s: %w", dsn, err)
+	}
+
+	const schema = `
+CREATE TABLE IF NOT EXISTS schedules (
+	id         INTEGER PRIMARY KEY,
+	enabled    INTEGER NOT NULL,
+	next_run   INTEGER NOT NULL,
+	payload    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS schedules_next_run_idx ON schedules(next_run);
+`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("scheduler: create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, sch ScheduledTest) error {
+	payload, err := json.Marshal(sch)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO schedules (id, enabled, next_run, payload)
+VALUES (?, ?, ?, ?)
+ON CONFLICT(id) DO UPDATE SET
+	enabled=excluded.enabled, next_run=excluded.next_run, payload=excluded.payload`,
+		sch.ID, sch.Enabled, sch.NextRun.Unix(), payload)
+	return err
+}
+
+func (s *SQLiteStore) List(ctx context.Context) ([]ScheduledTest, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT payload FROM schedules ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ScheduledTest
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var sch ScheduledTest
+		if err := json.Unmarshal([]byte(payload), &sch); err != nil {
+			return nil, err
+		}
+		out = append(out, sch)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id int) (ScheduledTest, error) {
+	var payload string
+	err := s.db.QueryRowContext(ctx, "SELECT payload FROM schedules WHERE id = ?", id).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return ScheduledTest{}, ErrNotFound
+	}
+	if err != nil {
+		return ScheduledTest{}, err
+	}
+	var sch ScheduledTest
+	if err := json.Unmarshal([]byte(payload), &sch); err != nil {
+		return ScheduledTest{}, err
+	}
+	return sch, nil
+}
+
+func (s *SQLiteStore) Delete(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM schedules WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
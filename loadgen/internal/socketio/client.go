@@ -0,0 +1,403 @@
+// Package socketio implements a minimal Socket.IO v4 client over the
+// Engine.IO v4 websocket transport: the open handshake (sid and the
+// server-advertised pingInterval/pingTimeout), namespace connect,
+// event emission with optional ack callbacks, and binary attachments.
+// Only the websocket transport is implemented - every caller in this
+// repo dials it directly (EIO=4&transport=websocket) rather than
+// starting on HTTP long-polling and upgrading, so there's nothing here
+// that exercises the polling transport.
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// HandshakeInfo is the server's Engine.IO open packet payload.
+type HandshakeInfo struct {
+	SID          string   `json:"sid"`
+	Upgrades     []string `json:"upgrades"`
+	PingInterval int      `json:"pingInterval"` // ms
+	PingTimeout  int      `json:"pingTimeout"`  // ms
+}
+
+// Options configures Dial.
+type Options struct {
+	// Namespace to connect to, e.g. "/chat". Empty means the default
+	// namespace "/".
+	Namespace string
+	// HandshakeTimeout bounds the websocket dial and the Engine.IO/
+	// Socket.IO handshake that follows it. Defaults to 10s.
+	HandshakeTimeout time.Duration
+}
+
+// Client is a connected Socket.IO v4 session over one websocket.
+type Client struct {
+	conn      *websocket.Conn
+	namespace string
+
+	handshake HandshakeInfo
+
+	writeMu sync.Mutex
+
+	mu           sync.Mutex
+	closed       bool
+	nextAckID    int
+	pendingAcks  map[int]chan []interface{}
+	handlers     map[string]func([]interface{})
+	onDisconnect func(error)
+
+	pendingBinary *pendingBinaryPacket
+}
+
+// Dial performs the websocket upgrade, Engine.IO open handshake, and
+// Socket.IO namespace connect against rawURL (an http(s):// or ws(s)://
+// URL whose path is normally "/socket.io/"), then starts the read pump
+// that drives heartbeat replies, event dispatch, and ack resolution.
+func Dial(ctx context.Context, rawURL string, opts Options) (*Client, error) {
+	if opts.HandshakeTimeout <= 0 {
+		opts.HandshakeTimeout = 10 * time.Second
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "/"
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("socketio: parsing url: %w", err)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	q := u.Query()
+	q.Set("EIO", "4")
+	q.Set("transport", "websocket")
+	u.RawQuery = q.Encode()
+
+	dialer := websocket.Dialer{HandshakeTimeout: opts.HandshakeTimeout}
+	conn, _, err := dialer.DialContext(ctx, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("socketio: dialing %s: %w", u.String(), err)
+	}
+
+	c := &Client{
+		conn:        conn,
+		namespace:   namespace,
+		pendingAcks: make(map[int]chan []interface{}),
+		handlers:    make(map[string]func([]interface{})),
+	}
+
+	conn.SetReadDeadline(time.Now().Add(opts.HandshakeTimeout))
+	if err := c.readHandshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := c.connectNamespace(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	go c.readPump()
+	return c, nil
+}
+
+// readHandshake reads the Engine.IO open packet ("0{...}") that the
+// server sends immediately after the websocket upgrade.
+func (c *Client) readHandshake() error {
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("socketio: reading open packet: %w", err)
+	}
+	if len(data) == 0 || data[0] != '0' {
+		return fmt.Errorf("socketio: expected open packet, got %q", data)
+	}
+	if err := json.Unmarshal(data[1:], &c.handshake); err != nil {
+		return fmt.Errorf("socketio: parsing open packet: %w", err)
+	}
+	return nil
+}
+
+// connectNamespace sends the Socket.IO namespace connect packet and
+// waits for the server's connect ack.
+func (c *Client) connectNamespace() error {
+	req := "40"
+	if c.namespace != "/" {
+		req = "40" + c.namespace + ","
+	}
+	if err := c.writeText(req); err != nil {
+		return fmt.Errorf("socketio: sending namespace connect: %w", err)
+	}
+
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("socketio: reading connect ack: %w", err)
+	}
+	s := string(data)
+	if !strings.HasPrefix(s, "40") {
+		return fmt.Errorf("socketio: expected connect ack, got %q", s)
+	}
+	return nil
+}
+
+// On registers handler to be called, on the read pump's goroutine, for
+// every server-emitted event named name. Registering again for the
+// same name replaces the previous handler.
+func (c *Client) On(name string, handler func(args []interface{})) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers[name] = handler
+}
+
+// OnDisconnect registers a handler invoked once when the read pump
+// exits, with the error that ended it (nil on a clean Close).
+func (c *Client) OnDisconnect(handler func(error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onDisconnect = handler
+}
+
+// Emit sends an event with args to the server without waiting for an
+// ack. Any arg of type []byte is sent as a binary attachment.
+func (c *Client) Emit(name string, args ...interface{}) error {
+	frames, err := encodeEvent(c.namespace, -1, name, args)
+	if err != nil {
+		return err
+	}
+	return c.writeFrames(frames)
+}
+
+// EmitAck sends an event with args and blocks until the server's ack
+// arrives, ctx is cancelled, or the connection closes.
+func (c *Client) EmitAck(ctx context.Context, name string, args ...interface{}) ([]interface{}, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("socketio: connection closed")
+	}
+	id := c.nextAckID
+	c.nextAckID++
+	ch := make(chan []interface{}, 1)
+	c.pendingAcks[id] = ch
+	c.mu.Unlock()
+
+	frames, err := encodeEvent(c.namespace, id, name, args)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pendingAcks, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+	if err := c.writeFrames(frames); err != nil {
+		c.mu.Lock()
+		delete(c.pendingAcks, id)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	select {
+	case ackArgs := <-ch:
+		return ackArgs, nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pendingAcks, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// Handshake returns the server-advertised sid/ping settings from Dial's
+// handshake.
+func (c *Client) Handshake() HandshakeInfo {
+	return c.handshake
+}
+
+// Close closes the underlying websocket connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Client) writeText(s string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, []byte(s))
+}
+
+func (c *Client) writeFrames(frames wireFrames) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.conn.WriteMessage(websocket.TextMessage, []byte(frames.text)); err != nil {
+		return err
+	}
+	for _, attachment := range frames.attachments {
+		if err := c.conn.WriteMessage(websocket.BinaryMessage, attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPump drives heartbeat replies (pong on ping), dispatches events
+// to On handlers, resolves pending EmitAck calls, and reassembles
+// binary_event/binary_ack attachments. It runs until the connection
+// errors or Close is called.
+func (c *Client) readPump() {
+	var exitErr error
+	defer func() {
+		c.mu.Lock()
+		handler := c.onDisconnect
+		c.mu.Unlock()
+		if handler != nil {
+			handler(exitErr)
+		}
+	}()
+
+	for {
+		msgType, data, err := c.conn.ReadMessage()
+		if err != nil {
+			exitErr = err
+			return
+		}
+
+		if msgType == websocket.BinaryMessage {
+			c.handleBinaryFrame(data)
+			continue
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		switch data[0] {
+		case enginePing:
+			c.writeText(string(enginePong))
+		case enginePong, engineNoop, engineUpgrade:
+			// nothing to do
+		case engineMessage:
+			c.handleSocketIOPacket(data[1:])
+		case engineClose:
+			exitErr = fmt.Errorf("socketio: server closed the connection")
+			return
+		}
+	}
+}
+
+func (c *Client) handleSocketIOPacket(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	pkt, err := parsePacket(data)
+	if err != nil {
+		return
+	}
+	if pkt.namespace != "" && pkt.namespace != c.namespace {
+		return
+	}
+
+	switch pkt.sioType {
+	case sioEvent:
+		c.dispatchEvent(pkt.payload)
+	case sioAck:
+		c.resolveAck(pkt.ackID, pkt.payload)
+	case sioBinaryEvent, sioBinaryAck:
+		c.pendingBinary = &pendingBinaryPacket{pkt: pkt, remaining: pkt.numAttachments}
+		if pkt.numAttachments == 0 {
+			c.deliverBinaryPacket(c.pendingBinary)
+		}
+	case sioDisconnect:
+		// Server requested namespace disconnect; let the websocket close
+		// naturally drive readPump's exit.
+	}
+}
+
+func (c *Client) handleBinaryFrame(data []byte) {
+	pb := c.pendingBinary
+	if pb == nil {
+		return
+	}
+	pb.attachments = append(pb.attachments, data)
+	pb.remaining--
+	if pb.remaining <= 0 {
+		c.deliverBinaryPacket(pb)
+		c.pendingBinary = nil
+	}
+}
+
+func (c *Client) deliverBinaryPacket(pb *pendingBinaryPacket) {
+	args := injectBinary(pb.pkt.payload, pb.attachments)
+	switch pb.pkt.sioType {
+	case sioBinaryEvent:
+		c.dispatchEventArgs(args)
+	case sioBinaryAck:
+		c.resolveAckArgs(pb.pkt.ackID, args)
+	}
+}
+
+func (c *Client) dispatchEvent(payload json.RawMessage) {
+	var args []interface{}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return
+	}
+	c.dispatchEventArgs(args)
+}
+
+func (c *Client) dispatchEventArgs(args []interface{}) {
+	if len(args) == 0 {
+		return
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	handler := c.handlers[name]
+	c.mu.Unlock()
+	if handler != nil {
+		handler(args[1:])
+	}
+}
+
+func (c *Client) resolveAck(id int, payload json.RawMessage) {
+	var args []interface{}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		return
+	}
+	c.resolveAckArgs(id, args)
+}
+
+func (c *Client) resolveAckArgs(id int, args []interface{}) {
+	c.mu.Lock()
+	ch, ok := c.pendingAcks[id]
+	if ok {
+		delete(c.pendingAcks, id)
+	}
+	c.mu.Unlock()
+	if ok {
+		ch <- args
+	}
+}
+
+type pendingBinaryPacket struct {
+	pkt         socketIOPacket
+	remaining   int
+	attachments [][]byte
+}
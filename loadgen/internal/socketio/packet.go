@@ -0,0 +1,166 @@
+package socketio
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Engine.IO v4 packet types (the single leading byte of every websocket
+// text frame).
+const (
+	engineOpen    = '0'
+	engineClose   = '1'
+	enginePing    = '2'
+	enginePong    = '3'
+	engineMessage = '4'
+	engineUpgrade = '5'
+	engineNoop    = '6'
+)
+
+// Socket.IO packet types, carried as the byte following an
+// engineMessage packet's leading '4'.
+const (
+	sioConnect      = '0'
+	sioDisconnect   = '1'
+	sioEvent        = '2'
+	sioAck          = '3'
+	sioConnectError = '4'
+	sioBinaryEvent  = '5'
+	sioBinaryAck    = '6'
+)
+
+// binaryPlaceholder is what a []byte argument becomes in the JSON
+// payload of a binary_event/binary_ack packet; matching attachments are
+// sent as separate binary websocket frames in argument order.
+type binaryPlaceholder struct {
+	Placeholder bool `json:"_placeholder"`
+	Num         int  `json:"num"`
+}
+
+// socketIOPacket is a parsed Socket.IO packet (the payload of an
+// Engine.IO message packet).
+type socketIOPacket struct {
+	sioType        byte
+	namespace      string
+	ackID          int // -1 if absent
+	numAttachments int
+	payload        json.RawMessage
+}
+
+// parsePacket decodes the Socket.IO packet that follows an Engine.IO
+// message packet's leading '4'. Format: <type><numAttachments>-<namespace,><ackID><json>,
+// where numAttachments/- is only present for binary_event/binary_ack
+// and namespace/ackID are each optional.
+func parsePacket(data []byte) (socketIOPacket, error) {
+	if len(data) == 0 {
+		return socketIOPacket{}, fmt.Errorf("socketio: empty packet")
+	}
+
+	pkt := socketIOPacket{sioType: data[0], ackID: -1}
+	rest := string(data[1:])
+
+	if pkt.sioType == sioBinaryEvent || pkt.sioType == sioBinaryAck {
+		if i := strings.IndexByte(rest, '-'); i >= 0 {
+			if n, err := strconv.Atoi(rest[:i]); err == nil {
+				pkt.numAttachments = n
+				rest = rest[i+1:]
+			}
+		}
+	}
+
+	if strings.HasPrefix(rest, "/") {
+		if i := strings.IndexByte(rest, ','); i >= 0 {
+			pkt.namespace = rest[:i]
+			rest = rest[i+1:]
+		}
+	}
+
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i > 0 {
+		id, err := strconv.Atoi(rest[:i])
+		if err == nil {
+			pkt.ackID = id
+			rest = rest[i:]
+		}
+	}
+
+	pkt.payload = json.RawMessage(rest)
+	return pkt, nil
+}
+
+// wireFrames is an encoded Socket.IO packet ready to write: text is the
+// leading text websocket frame, attachments are the binary websocket
+// frames that must follow it, in order, for a binary_event packet.
+type wireFrames struct {
+	text        string
+	attachments [][]byte
+}
+
+// encodeEvent builds the wire frames for an event packet. ackID < 0
+// means no ack is requested. Any arg of type []byte becomes a binary
+// attachment, switching the packet to binary_event.
+func encodeEvent(namespace string, ackID int, name string, args []interface{}) (wireFrames, error) {
+	values := make([]interface{}, 0, len(args)+1)
+	values = append(values, name)
+
+	var attachments [][]byte
+	for _, a := range args {
+		if b, ok := a.([]byte); ok {
+			values = append(values, binaryPlaceholder{Placeholder: true, Num: len(attachments)})
+			attachments = append(attachments, b)
+			continue
+		}
+		values = append(values, a)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return wireFrames{}, fmt.Errorf("socketio: encoding event %q: %w", name, err)
+	}
+
+	var b strings.Builder
+	b.WriteByte(engineMessage)
+	if len(attachments) > 0 {
+		b.WriteByte(sioBinaryEvent)
+		fmt.Fprintf(&b, "%d-", len(attachments))
+	} else {
+		b.WriteByte(sioEvent)
+	}
+	if namespace != "" && namespace != "/" {
+		b.WriteString(namespace)
+		b.WriteByte(',')
+	}
+	if ackID >= 0 {
+		fmt.Fprintf(&b, "%d", ackID)
+	}
+	b.Write(data)
+
+	return wireFrames{text: b.String(), attachments: attachments}, nil
+}
+
+// injectBinary replaces each binaryPlaceholder found in a decoded
+// event/ack payload with its corresponding attachment, in the order
+// they were received.
+func injectBinary(payload json.RawMessage, attachments [][]byte) []interface{} {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil
+	}
+	args := make([]interface{}, len(raw))
+	for i, r := range raw {
+		var ph binaryPlaceholder
+		if json.Unmarshal(r, &ph) == nil && ph.Placeholder && ph.Num < len(attachments) {
+			args[i] = attachments[ph.Num]
+			continue
+		}
+		var v interface{}
+		json.Unmarshal(r, &v)
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,179 @@
+package socketio
+
+import "testing"
+
+func TestParsePacketPlainEvent(t *testing.T) {
+	pkt, err := parsePacket([]byte(`2["chat message","hi"]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioEvent {
+		t.Fatalf("expected sioEvent, got %q", pkt.sioType)
+	}
+	if pkt.namespace != "" {
+		t.Fatalf("expected no namespace, got %q", pkt.namespace)
+	}
+	if pkt.ackID != -1 {
+		t.Fatalf("expected ackID -1 (absent), got %d", pkt.ackID)
+	}
+	if string(pkt.payload) != `["chat message","hi"]` {
+		t.Fatalf("unexpected payload: %s", pkt.payload)
+	}
+}
+
+func TestParsePacketWithAckID(t *testing.T) {
+	pkt, err := parsePacket([]byte(`312["chat message","hi"]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioAck {
+		t.Fatalf("expected sioAck, got %q", pkt.sioType)
+	}
+	if pkt.ackID != 12 {
+		t.Fatalf("expected ackID 12, got %d", pkt.ackID)
+	}
+	if string(pkt.payload) != `["chat message","hi"]` {
+		t.Fatalf("unexpected payload: %s", pkt.payload)
+	}
+}
+
+func TestParsePacketWithNamespace(t *testing.T) {
+	pkt, err := parsePacket([]byte(`2/chat,["typing"]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.namespace != "/chat" {
+		t.Fatalf("expected namespace /chat, got %q", pkt.namespace)
+	}
+	if pkt.ackID != -1 {
+		t.Fatalf("expected ackID -1 (absent), got %d", pkt.ackID)
+	}
+	if string(pkt.payload) != `["typing"]` {
+		t.Fatalf("unexpected payload: %s", pkt.payload)
+	}
+}
+
+func TestParsePacketWithNamespaceAndAckID(t *testing.T) {
+	pkt, err := parsePacket([]byte(`3/chat,7["ok"]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.namespace != "/chat" {
+		t.Fatalf("expected namespace /chat, got %q", pkt.namespace)
+	}
+	if pkt.ackID != 7 {
+		t.Fatalf("expected ackID 7, got %d", pkt.ackID)
+	}
+	if string(pkt.payload) != `["ok"]` {
+		t.Fatalf("unexpected payload: %s", pkt.payload)
+	}
+}
+
+func TestParsePacketBinaryEventAttachmentCount(t *testing.T) {
+	pkt, err := parsePacket([]byte(`52-["image",{"_placeholder":true,"num":0}]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioBinaryEvent {
+		t.Fatalf("expected sioBinaryEvent, got %q", pkt.sioType)
+	}
+	if pkt.numAttachments != 2 {
+		t.Fatalf("expected 2 attachments, got %d", pkt.numAttachments)
+	}
+	if pkt.ackID != -1 {
+		t.Fatalf("expected ackID -1 (absent), got %d", pkt.ackID)
+	}
+}
+
+func TestParsePacketBinaryAckWithNamespaceAndAckID(t *testing.T) {
+	pkt, err := parsePacket([]byte(`61-/chat,3[{"_placeholder":true,"num":0}]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioBinaryAck {
+		t.Fatalf("expected sioBinaryAck, got %q", pkt.sioType)
+	}
+	if pkt.numAttachments != 1 {
+		t.Fatalf("expected 1 attachment, got %d", pkt.numAttachments)
+	}
+	if pkt.namespace != "/chat" {
+		t.Fatalf("expected namespace /chat, got %q", pkt.namespace)
+	}
+	if pkt.ackID != 3 {
+		t.Fatalf("expected ackID 3, got %d", pkt.ackID)
+	}
+}
+
+func TestParsePacketNonBinaryTypeIgnoresDashPrefix(t *testing.T) {
+	// A plain event's payload happens to start with digits and a '-';
+	// only binary_event/binary_ack should ever try to parse an attachment
+	// count out of it.
+	pkt, err := parsePacket([]byte(`2["id","2-3"]`))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.numAttachments != 0 {
+		t.Fatalf("expected 0 attachments for a non-binary packet, got %d", pkt.numAttachments)
+	}
+	if string(pkt.payload) != `["id","2-3"]` {
+		t.Fatalf("unexpected payload: %s", pkt.payload)
+	}
+}
+
+func TestParsePacketEmptyData(t *testing.T) {
+	if _, err := parsePacket(nil); err == nil {
+		t.Fatalf("expected an error for empty packet data")
+	}
+}
+
+func TestEncodeEventDecodesBackWithParsePacket(t *testing.T) {
+	frames, err := encodeEvent("/chat", 5, "message", []interface{}{"hello"})
+	if err != nil {
+		t.Fatalf("encodeEvent: %v", err)
+	}
+
+	pkt, err := parsePacket([]byte(frames.text[1:])) // drop the engineMessage byte
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioEvent {
+		t.Fatalf("expected sioEvent, got %q", pkt.sioType)
+	}
+	if pkt.namespace != "/chat" {
+		t.Fatalf("expected namespace /chat, got %q", pkt.namespace)
+	}
+	if pkt.ackID != 5 {
+		t.Fatalf("expected ackID 5, got %d", pkt.ackID)
+	}
+}
+
+func TestEncodeEventWithBinaryAttachmentRoundTrips(t *testing.T) {
+	attachment := []byte("binary-data")
+	frames, err := encodeEvent("", -1, "upload", []interface{}{attachment})
+	if err != nil {
+		t.Fatalf("encodeEvent: %v", err)
+	}
+	if len(frames.attachments) != 1 {
+		t.Fatalf("expected 1 attachment frame, got %d", len(frames.attachments))
+	}
+
+	pkt, err := parsePacket([]byte(frames.text[1:]))
+	if err != nil {
+		t.Fatalf("parsePacket: %v", err)
+	}
+	if pkt.sioType != sioBinaryEvent {
+		t.Fatalf("expected sioBinaryEvent, got %q", pkt.sioType)
+	}
+	if pkt.numAttachments != 1 {
+		t.Fatalf("expected 1 attachment, got %d", pkt.numAttachments)
+	}
+
+	args := injectBinary(pkt.payload, frames.attachments)
+	if len(args) != 2 {
+		t.Fatalf("expected event name + 1 arg, got %d", len(args))
+	}
+	got, ok := args[1].([]byte)
+	if !ok || string(got) != string(attachment) {
+		t.Fatalf("expected the attachment to round-trip, got %v", args[1])
+	}
+}
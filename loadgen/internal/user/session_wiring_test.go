@@ -0,0 +1,39 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"loadgen/internal/config"
+)
+
+// TestRunRejectsInvalidSessionProfileWithoutStartingTheLegacyLoop checks
+// Run's session-engine handoff: an invalid profile (here, a guard
+// compileProfile doesn't recognize) must make Run return immediately
+// rather than silently falling through to the legacy authenticate/
+// selectActionWeighted loop, which would dial out to real services.
+func TestRunRejectsInvalidSessionProfileWithoutStartingTheLegacyLoop(t *testing.T) {
+	cfg := &config.Config{
+		SessionProfiles: []config.SessionProfile{
+			{
+				States: []config.SessionState{
+					{Name: "Browsing", Transitions: []config.SessionTransition{{To: "Browsing", Weight: 1, Guard: "is_admin"}}},
+				},
+			},
+		},
+	}
+	u := New(1, cfg, 1)
+
+	done := make(chan struct{})
+	go func() {
+		u.Run(context.Background(), nil)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected Run to reject the invalid session profile and return immediately, not fall through to the legacy dial-out loop")
+	}
+}
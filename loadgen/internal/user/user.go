@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
 	"time"
 
 	"loadgen/internal/behaviors"
+	"loadgen/internal/behaviors/session"
 	"loadgen/internal/config"
 	"loadgen/internal/metrics"
+	"loadgen/internal/obs"
 )
 
 type User struct {
@@ -22,11 +25,33 @@ type User struct {
 	chat     *behaviors.ChatBehavior
 	posts    *behaviors.PostsBehavior
 	profile  *behaviors.ProfileBehavior
+	// rng is this user's own random source, seeded independently per-user
+	// (see New's seed parameter) so a distributed run's agents can be
+	// given a shared base seed and still have every user pick different,
+	// reproducible actions rather than all sharing the package-level
+	// math/rand source. Only this file's own selection/content helpers
+	// use it; internal/behaviors keeps using math/rand directly.
+	rng *rand.Rand
+	// seed is kept alongside rng so Run can hand the same value to
+	// session.Session.Run when cfg.SessionProfiles is configured (see
+	// Run) - session drives its own rand.Rand rather than sharing u.rng,
+	// since its vuser type doesn't have access to this one.
+	seed int64
+	// lastAction is the name of the action selectActionMarkov picked last
+	// ("" before the first pick), used to look up the next row of
+	// config.ActionMarkov.Matrix. Unused when ActionMarkov isn't
+	// configured, since selectAction then stays on selectActionWeighted.
+	lastAction string
 }
 
-func New(id int, cfg *config.Config) *User {
+// New builds a User identified by id (its username is always
+// "user_<id>", so two Users must never share an id - see
+// generator.Generator.startUserID for how a distributed run keeps agents'
+// ids disjoint). seed seeds u's own RNG; callers that don't need
+// reproducibility can pass time.Now().UnixNano().
+func New(id int, cfg *config.Config, seed int64) *User {
 	username := fmt.Sprintf("user_%d", id)
-	
+
 	return &User{
 		ID:       id,
 		Username: username,
@@ -35,17 +60,50 @@ func New(id int, cfg *config.Config) *User {
 		chat:     behaviors.NewChat(cfg),
 		posts:    behaviors.NewPosts(cfg),
 		profile:  behaviors.NewProfile(cfg),
+		rng:      rand.New(rand.NewSource(seed)),
+		seed:     seed,
 	}
 }
 
-func (u *User) Run(ctx context.Context) {
+// Run drives u's behavior loop until ctx is done (immediate cancellation)
+// or drain is closed (graceful: Run finishes its current action, then
+// returns, instead of abandoning it mid-flight). drain may be nil, in
+// which case only ctx.Done() can stop the loop.
+//
+// If cfg.SessionProfiles is configured, u instead hands off entirely to
+// a session.Session built from the first configured profile - this
+// ad-hoc per-user path has no notion of weighting across multiple
+// profiles (config.SessionProfile carries no Weight field), so only
+// profiles[0] is ever used here. Configs without a session_profiles
+// section fall through to either runMarkovLoop (if config.ActionMarkov
+// is configured) or the hardcoded selectActionWeighted loop below.
+func (u *User) Run(ctx context.Context, drain <-chan struct{}) {
 	defer metrics.ActiveUsers.Dec()
-	
-	log.Printf("User %s starting simulation", u.Username)
+
+	logger := obs.FromContext(ctx).With(map[string]interface{}{"user_id": u.Username})
+	logger.Info("starting simulation")
+
+	if len(u.config.SessionProfiles) > 0 {
+		sess, err := session.New(u.config.SessionProfiles[0])
+		if err != nil {
+			logger.Error("invalid session profile", err)
+			return
+		}
+		sess.Run(ctx, u.config, u.ID, u.seed, drain)
+		return
+	}
+
+	useMarkov := len(u.config.ActionMarkov.Initial) > 0
+	if useMarkov {
+		if err := validateActionMarkov(u.config.ActionMarkov); err != nil {
+			logger.Error("invalid action_markov config", err)
+			return
+		}
+	}
 
 	// Login/Register
 	if err := u.authenticate(ctx); err != nil {
-		log.Printf("User %s auth failed: %v", u.Username, err)
+		logger.Error("auth failed", err)
 		return
 	}
 
@@ -62,16 +120,25 @@ func (u *User) Run(ctx context.Context) {
 	// GUARANTEE: Send at least one chat message per user
 	u.sendChatMessage(ctx)
 
+	if useMarkov {
+		u.runMarkovLoop(ctx, drain, logger)
+		return
+	}
+
 	// Ensure each user uses at least one service per cycle
 	serviceUsed := make(map[string]bool)
 	serviceUsed["chat"] = true // Already used chat
 	cycleCount := 0
 
 	// Main behavior loop
+	start := time.Now()
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("User %s stopping", u.Username)
+			logger.With(map[string]interface{}{"iteration": cycleCount, "elapsed_ms": time.Since(start).Milliseconds()}).Info("stopping")
+			return
+		case <-drain:
+			logger.With(map[string]interface{}{"iteration": cycleCount, "elapsed_ms": time.Since(start).Milliseconds()}).Info("draining")
 			return
 		default:
 			// Reset service tracking every 4 actions
@@ -82,26 +149,253 @@ func (u *User) Run(ctx context.Context) {
 				serviceUsed["chat"] = true
 			}
 
-			action := u.selectAction(serviceUsed)
+			action := u.selectActionWeighted(serviceUsed)
+			action(ctx)
+			cycleCount++
+
+			u.idle()
+		}
+	}
+}
+
+// validateActionMarkov checks that every action name appearing in m's
+// Initial distribution and Matrix rows/entries is one actionCatalog
+// recognizes, so a typo (e.g. "sendChatMesage") fails profile load
+// instead of silently falling back to viewPosts every time it's sampled
+// - the same "surface at load time, not mid-run" contract
+// session.compileProfile's guardIsValid gives SessionTransition.Guard.
+func validateActionMarkov(m config.ActionMarkov) error {
+	for name := range m.Initial {
+		if _, ok := actionCatalog[name]; !ok {
+			return fmt.Errorf("action_markov: initial: unrecognized action %q", name)
+		}
+	}
+	for from, row := range m.Matrix {
+		if _, ok := actionCatalog[from]; !ok {
+			return fmt.Errorf("action_markov: matrix: unrecognized action %q", from)
+		}
+		for to := range row {
+			if _, ok := actionCatalog[to]; !ok {
+				return fmt.Errorf("action_markov: matrix[%q]: unrecognized action %q", from, to)
+			}
+		}
+	}
+	return nil
+}
+
+// runMarkovLoop is Run's behavior loop when config.ActionMarkov is
+// configured: each tick picks the next action via selectActionMarkov (a
+// first-order Markov chain keyed by u.lastAction) instead of
+// selectActionWeighted's independent per-tick weights. selectActionWeighted
+// keeps its "each service every 4 actions" invariant two ways: an
+// unconditional extra sendChatMessage call every reset, plus per-tick
+// odds of forcing an unused service. Here that's replaced with a single
+// post-selection override per window: if the window just completed
+// (cycleCount>0 and cycleCount%4==0) left a service untouched, that
+// service is forced onto the first pick of the new window instead of
+// whatever the chain would have picked - deliberately not an *extra*
+// guaranteed chat message like the weighted loop's, since the invariant
+// here generalizes to "every service", not "chat specifically".
+func (u *User) runMarkovLoop(ctx context.Context, drain <-chan struct{}, logger obs.Logger) {
+	// cycleCount==0's reset branch below always runs before this map is
+	// ever read (its forceService check is guarded on cycleCount>0), so
+	// the chat message already guaranteed above isn't otherwise accounted
+	// for here - the first window simply starts fully unused like any
+	// other, which is fine since it's backed by a real call either way.
+	serviceUsed := make(map[string]bool)
+	cycleCount := 0
+
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.With(map[string]interface{}{"iteration": cycleCount, "elapsed_ms": time.Since(start).Milliseconds()}).Info("stopping")
+			return
+		case <-drain:
+			logger.With(map[string]interface{}{"iteration": cycleCount, "elapsed_ms": time.Since(start).Milliseconds()}).Info("draining")
+			return
+		default:
+			forceService := ""
+			if cycleCount%4 == 0 {
+				if cycleCount > 0 {
+					forceService = missingService(serviceUsed)
+				}
+				serviceUsed = make(map[string]bool)
+			}
+
+			action := u.selectActionMarkov(serviceUsed, forceService)
 			action(ctx)
 			cycleCount++
-			
+
 			u.idle()
 		}
 	}
 }
 
-func (u *User) selectAction(serviceUsed map[string]bool) func(context.Context) {
+// selectActionMarkov samples the next action from config.ActionMarkov:
+// row Matrix[u.lastAction] (or Initial, before any action has been taken
+// or if that row is empty) via the same cumulative-weight walk
+// selectActionWeighted uses, then - if forceService is non-empty and the
+// sampled action doesn't already belong to it - overrides the pick with
+// one drawn from forceService instead (see runMarkovLoop for when that
+// happens). Either way, u.lastAction is updated to the action actually
+// returned, so the chain resumes from wherever it really ended up next
+// call.
+func (u *User) selectActionMarkov(serviceUsed map[string]bool, forceService string) func(context.Context) {
+	row := u.config.ActionMarkov.Matrix[u.lastAction]
+	weights := row
+	if u.lastAction == "" || len(row) == 0 {
+		weights = u.config.ActionMarkov.Initial
+	}
+
+	name := weightedPick(u.rng, weights)
+	if name == "" {
+		name = "viewPosts"
+	}
+
+	if forceService != "" && actionService(name) != forceService {
+		name = u.anyActionIn(forceService)
+	}
+
+	if u.lastAction != "" {
+		metrics.ActionTransitionsTotal.WithLabelValues(u.lastAction, name).Inc()
+	}
+	u.lastAction = name
+	serviceUsed[actionService(name)] = true
+
+	return u.actionByName(name)
+}
+
+// weightedPick samples one key from weights with probability proportional
+// to its value, via the same cumulative-weight walk used throughout this
+// package - except map iteration order is randomized by Go, which would
+// make u.rng-seeded runs non-reproducible, so the walk is done over keys
+// sorted ascending instead. Returns "" if weights is empty or every
+// weight is <= 0.
+func weightedPick(rng *rand.Rand, weights map[string]float64) string {
+	if len(weights) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(weights))
+	total := 0.0
+	for k, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		keys = append(keys, k)
+		total += w
+	}
+	if total <= 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	r := rng.Float64() * total
+	for _, k := range keys {
+		r -= weights[k]
+		if r <= 0 {
+			return k
+		}
+	}
+	return keys[len(keys)-1]
+}
+
+// actionCatalog is the single source of truth for ActionMarkov's 7 action
+// names and which service each exercises; actionService, actionsByService,
+// and validateActionMarkov are all derived from it. actionByName's switch
+// binds these same names to *User methods - it can't be derived from this
+// map (a method value needs a constructed u), so it must be kept in sync
+// with this list by hand.
+var actionCatalog = map[string]string{
+	"viewPosts":        "posts",
+	"createPost":       "posts",
+	"likeRandomPost":   "posts",
+	"sendChatMessage":  "chat",
+	"readChatMessages": "chat",
+	"updateProfile":    "profile",
+	"viewProfile":      "profile",
+}
+
+// actionService maps an ActionMarkov action name to the service it
+// exercises ("posts", "chat", or "profile"), so selectActionMarkov can
+// tell whether a pick satisfies the every-4-actions invariant. Returns ""
+// for a name not in actionCatalog.
+func actionService(name string) string {
+	return actionCatalog[name]
+}
+
+// actionsByService is actionCatalog grouped by service, used by
+// anyActionIn to force a transition into a specific service.
+var actionsByService = func() map[string][]string {
+	byService := make(map[string][]string, 3)
+	for name, svc := range actionCatalog {
+		byService[svc] = append(byService[svc], name)
+	}
+	for _, names := range byService {
+		sort.Strings(names)
+	}
+	return byService
+}()
+
+// missingService returns the first of "posts", "chat", "profile" not yet
+// marked in serviceUsed, or "" if all three have been used - the same
+// priority order selectActionWeighted already favors them in.
+func missingService(serviceUsed map[string]bool) string {
+	for _, svc := range []string{"posts", "chat", "profile"} {
+		if !serviceUsed[svc] {
+			return svc
+		}
+	}
+	return ""
+}
+
+// anyActionIn picks a uniformly random action belonging to service.
+func (u *User) anyActionIn(service string) string {
+	names := actionsByService[service]
+	if len(names) == 0 {
+		return ""
+	}
+	return names[u.rng.Intn(len(names))]
+}
+
+// actionByName resolves an ActionMarkov action name to the method that
+// performs it, falling back to viewPosts for a name this package doesn't
+// recognize (e.g. a typo in config).
+func (u *User) actionByName(name string) func(context.Context) {
+	switch name {
+	case "viewPosts":
+		return u.viewPosts
+	case "createPost":
+		return u.createPost
+	case "likeRandomPost":
+		return u.likeRandomPost
+	case "sendChatMessage":
+		return u.sendChatMessage
+	case "readChatMessages":
+		return u.readChatMessages
+	case "updateProfile":
+		return u.updateProfile
+	case "viewProfile":
+		return u.viewProfile
+	default:
+		return u.viewPosts
+	}
+}
+
+// selectActionWeighted is the independent per-tick weighted picker used
+// when config.ActionMarkov isn't configured (see selectActionMarkov for
+// the Markov-chain replacement).
+func (u *User) selectActionWeighted(serviceUsed map[string]bool) func(context.Context) {
 	// Ensure each service gets used
-	if !serviceUsed["posts"] && rand.Float32() < 0.4 {
+	if !serviceUsed["posts"] && u.rng.Float32() < 0.4 {
 		serviceUsed["posts"] = true
 		return u.randomPostsAction
 	}
-	if !serviceUsed["chat"] && rand.Float32() < 0.3 {
+	if !serviceUsed["chat"] && u.rng.Float32() < 0.3 {
 		serviceUsed["chat"] = true
 		return u.randomChatAction
 	}
-	if !serviceUsed["profile"] && rand.Float32() < 0.2 {
+	if !serviceUsed["profile"] && u.rng.Float32() < 0.2 {
 		serviceUsed["profile"] = true
 		return u.randomProfileAction
 	}
@@ -123,7 +417,7 @@ func (u *User) selectAction(serviceUsed map[string]bool) func(context.Context) {
 		totalWeight += a.weight
 	}
 
-	r := rand.Float32() * totalWeight
+	r := u.rng.Float32() * totalWeight
 	for _, a := range actions {
 		r -= a.weight
 		if r <= 0 {
@@ -134,7 +428,7 @@ func (u *User) selectAction(serviceUsed map[string]bool) func(context.Context) {
 }
 
 func (u *User) randomProfileAction(ctx context.Context) {
-	if rand.Float32() < 0.7 {
+	if u.rng.Float32() < 0.7 {
 		u.updateProfile(ctx)
 	} else {
 		u.viewProfile(ctx)
@@ -155,12 +449,12 @@ func (u *User) randomPostsAction(ctx context.Context) {
 		u.likeRandomPost,
 		u.viewPosts,
 	}
-	action := actions[rand.Intn(len(actions))]
+	action := actions[u.rng.Intn(len(actions))]
 	action(ctx)
 }
 
 func (u *User) randomChatAction(ctx context.Context) {
-	if rand.Float32() < 0.7 {
+	if u.rng.Float32() < 0.7 {
 		u.sendChatMessage(ctx)
 	} else {
 		u.readChatMessages(ctx)
@@ -181,7 +475,7 @@ func (u *User) authenticate(ctx context.Context) error {
 			return fmt.Errorf("login after register failed: %w", err)
 		}
 	}
-	
+
 	u.Token = token
 	u.UserID = fmt.Sprintf("%d", u.ID) // Use user ID for profile operations
 	log.Printf("User %s authenticated", u.Username)
@@ -196,8 +490,8 @@ func (u *User) performRandomAction(ctx context.Context) {
 		u.sendChatMessage,
 		u.readChatMessages,
 	}
-	
-	action := actions[rand.Intn(len(actions))]
+
+	action := actions[u.rng.Intn(len(actions))]
 	action(ctx)
 }
 
@@ -213,7 +507,7 @@ func (u *User) createPost(ctx context.Context) {
 		fmt.Sprintf("Random post by %s 🚀", u.Username),
 		fmt.Sprintf("%s checking in!", u.Username),
 	}
-	content := contents[rand.Intn(len(contents))]
+	content := contents[u.rng.Intn(len(contents))]
 	u.posts.CreatePost(ctx, u.Token, content)
 }
 
@@ -221,7 +515,7 @@ func (u *User) likeRandomPost(ctx context.Context) {
 	// Get posts and like a random one
 	posts := u.posts.GetPosts(ctx, u.Token)
 	if len(posts) > 0 {
-		randomPost := posts[rand.Intn(len(posts))]
+		randomPost := posts[u.rng.Intn(len(posts))]
 		u.posts.LikePost(ctx, u.Token, randomPost.ID)
 	}
 }
@@ -235,7 +529,7 @@ func (u *User) sendChatMessage(ctx context.Context) {
 		fmt.Sprintf("%s is online and chatting! 🎉", u.Username),
 		fmt.Sprintf("Random message from %s at %s", u.Username, time.Now().Format("15:04")),
 	}
-	message := messages[rand.Intn(len(messages))]
+	message := messages[u.rng.Intn(len(messages))]
 	u.chat.SendMessage(ctx, message)
 }
 
@@ -245,6 +539,6 @@ func (u *User) readChatMessages(ctx context.Context) {
 
 func (u *User) idle() {
 	// More realistic idle times: 2-8 seconds
-	idleTime := time.Duration(rand.Intn(6)+2) * time.Second
+	idleTime := time.Duration(u.rng.Intn(6)+2) * time.Second
 	time.Sleep(idleTime)
 }
@@ -0,0 +1,159 @@
+package user
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"loadgen/internal/config"
+)
+
+func TestWeightedPickProportional(t *testing.T) {
+	weights := map[string]float64{"a": 1, "b": 9}
+	rng := rand.New(rand.NewSource(1))
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[weightedPick(rng, weights)]++
+	}
+	if counts["b"] <= counts["a"] {
+		t.Fatalf("expected b (weight 9) to be picked far more than a (weight 1), got %v", counts)
+	}
+}
+
+func TestWeightedPickIgnoresNonPositiveWeights(t *testing.T) {
+	weights := map[string]float64{"a": 0, "b": -1, "c": 5}
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		if got := weightedPick(rng, weights); got != "c" {
+			t.Fatalf("expected only the positive-weight key to ever be picked, got %q", got)
+		}
+	}
+}
+
+func TestWeightedPickEmptyOrAllZero(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	if got := weightedPick(rng, nil); got != "" {
+		t.Fatalf("expected \"\" for an empty weights map, got %q", got)
+	}
+	if got := weightedPick(rng, map[string]float64{"a": 0, "b": 0}); got != "" {
+		t.Fatalf("expected \"\" when every weight is <= 0, got %q", got)
+	}
+}
+
+func TestValidateActionMarkovAcceptsKnownActions(t *testing.T) {
+	m := config.ActionMarkov{
+		Initial: map[string]float64{"viewPosts": 1},
+		Matrix: map[string]map[string]float64{
+			"viewPosts": {"createPost": 1, "likeRandomPost": 1},
+		},
+	}
+	if err := validateActionMarkov(m); err != nil {
+		t.Fatalf("validateActionMarkov: unexpected error: %v", err)
+	}
+}
+
+func TestValidateActionMarkovRejectsUnknownInitial(t *testing.T) {
+	m := config.ActionMarkov{Initial: map[string]float64{"sendChatMesage": 1}}
+	if err := validateActionMarkov(m); err == nil {
+		t.Fatalf("expected an error for a typo'd initial action name")
+	}
+}
+
+func TestValidateActionMarkovRejectsUnknownMatrixRow(t *testing.T) {
+	m := config.ActionMarkov{Matrix: map[string]map[string]float64{"bogus": {"viewPosts": 1}}}
+	if err := validateActionMarkov(m); err == nil {
+		t.Fatalf("expected an error for a typo'd matrix row action name")
+	}
+}
+
+func TestValidateActionMarkovRejectsUnknownMatrixEntry(t *testing.T) {
+	m := config.ActionMarkov{Matrix: map[string]map[string]float64{"viewPosts": {"bogus": 1}}}
+	if err := validateActionMarkov(m); err == nil {
+		t.Fatalf("expected an error for a typo'd matrix entry action name")
+	}
+}
+
+func newTestUser(m config.ActionMarkov) *User {
+	return &User{
+		config: &config.Config{ActionMarkov: m},
+		rng:    rand.New(rand.NewSource(1)),
+	}
+}
+
+func TestSelectActionMarkovUsesInitialBeforeFirstPick(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{Initial: map[string]float64{"createPost": 1}})
+
+	action := u.selectActionMarkov(map[string]bool{}, "")
+	if u.lastAction != "createPost" {
+		t.Fatalf("expected lastAction to become %q, got %q", "createPost", u.lastAction)
+	}
+	if funcName(action) != funcName(u.createPost) {
+		t.Fatalf("expected the returned action to be u.createPost")
+	}
+}
+
+func TestSelectActionMarkovFallsBackToInitialWhenRowEmpty(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{
+		Initial: map[string]float64{"viewPosts": 1},
+		Matrix:  map[string]map[string]float64{},
+	})
+	u.lastAction = "sendChatMessage"
+
+	u.selectActionMarkov(map[string]bool{}, "")
+	if u.lastAction != "viewPosts" {
+		t.Fatalf("expected a row-less lastAction to fall back to Initial, got %q", u.lastAction)
+	}
+}
+
+func TestSelectActionMarkovUsesMatrixRowAfterFirstPick(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{
+		Matrix: map[string]map[string]float64{
+			"viewPosts": {"createPost": 1},
+		},
+	})
+	u.lastAction = "viewPosts"
+
+	u.selectActionMarkov(map[string]bool{}, "")
+	if u.lastAction != "createPost" {
+		t.Fatalf("expected the matrix row for %q to pick %q, got %q", "viewPosts", "createPost", u.lastAction)
+	}
+}
+
+func TestSelectActionMarkovForcesUnusedService(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{Initial: map[string]float64{"viewPosts": 1}})
+	serviceUsed := map[string]bool{}
+
+	u.selectActionMarkov(serviceUsed, "chat")
+	if actionService(u.lastAction) != "chat" {
+		t.Fatalf("expected forceService %q to override the pick, got action %q (service %q)", "chat", u.lastAction, actionService(u.lastAction))
+	}
+	if !serviceUsed["chat"] {
+		t.Fatalf("expected serviceUsed to be marked for the forced service")
+	}
+}
+
+func TestSelectActionMarkovDoesNotForceWhenPickAlreadyMatches(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{Initial: map[string]float64{"viewPosts": 1}})
+
+	u.selectActionMarkov(map[string]bool{}, "posts")
+	if u.lastAction != "viewPosts" {
+		t.Fatalf("expected the already-matching pick to survive forceService, got %q", u.lastAction)
+	}
+}
+
+func TestActionByNameFallsBackToViewPosts(t *testing.T) {
+	u := newTestUser(config.ActionMarkov{})
+	if funcName(u.actionByName("bogus")) != funcName(u.viewPosts) {
+		t.Fatalf("expected an unrecognized action name to fall back to viewPosts")
+	}
+}
+
+// funcName compares *User method values by their underlying function
+// pointer, since method values themselves aren't comparable with ==.
+func funcName(f func(context.Context)) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
@@ -3,53 +3,242 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"io"
+	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"loadgen/internal/cleanup"
+	"loadgen/internal/clock"
 	"loadgen/internal/config"
+	"loadgen/internal/control"
 	"loadgen/internal/generator"
+	"loadgen/internal/metrics"
+	"loadgen/internal/obs"
+	"loadgen/internal/query"
+	"loadgen/internal/reportstore"
+	"loadgen/internal/scheduler"
 )
 
 type WebServer struct {
-	config      *config.Config
-	currentTest *TestRun
-	reports     []TestReport
-	cleanup     *cleanup.Cleanup
-	mu          sync.RWMutex
+	config           *config.Config
+	currentTest      *TestRun
+	currentGenerator *generator.Generator // the running gen.Run's Generator, for handleDrainCurrent to signal (see runTest)
+	queue            []TestRun            // runs waiting for currentTest to finish, drained FIFO (see runTest)
+	reportSeq        int
+	reports          reportstore.Store
+	schedules        scheduler.Store
+	scheduleSeq      int
+	cleanup          *cleanup.Cleanup
+	mu               sync.RWMutex
+	subscribers      map[chan Event]struct{}
+	metricsTS        *query.Store
+	// labelCardinality tracks, per label key, every distinct value a
+	// TestRun.Labels has ever used - see validateLabels - so one run can't
+	// blow up RequestsTotal's cardinality with e.g. a unique ID as a label
+	// value.
+	labelCardinality map[string]map[string]bool
+	stopScheduler    context.CancelFunc
+	// logger is the base obs.Logger runTest enriches per-run (test_id) and
+	// attaches to that run's ctx (see obs.WithLogger), so everything runTest
+	// calls - generator.New/Run, collectMetrics, user.Run - logs through the
+	// same Logger without needing its own ws.logger reference.
+	logger obs.Logger
+	// clock is how runTest/collectMetrics/sampleMetrics read the current
+	// time, so report.StartTime/EndTime and metrics timestamps come from a
+	// single substitutable source (see internal/clock) instead of calling
+	// time.Now directly.
+	clock clock.Clock
+}
+
+// Event is one named message delivered to /api/events subscribers. Kind
+// selects the client-side EventSource listener ("status", "metrics",
+// "tracked_users", "report", or "schedules" - see handleHome's script)
+// and Data is marshaled as that event's JSON payload.
+type Event struct {
+	Kind string
+	Data interface{}
 }
 
 type TestRun struct {
-	Users     int       `json:"users"`
-	Duration  string    `json:"duration"`
-	Ramp      string    `json:"ramp"`
-	Status    string    `json:"status"`
-	StartTime time.Time `json:"start_time"`
-	cancel    context.CancelFunc
-}
-
-type TestReport struct {
-	ID           int                    `json:"id"`
-	Users        int                    `json:"users"`
-	Duration     string                 `json:"duration"`
-	Ramp         string                 `json:"ramp"`
-	StartTime    time.Time              `json:"start_time"`
-	EndTime      time.Time              `json:"end_time"`
-	Status       string                 `json:"status"`
-	Metrics      map[string]interface{} `json:"metrics"`
-	TrackedUsers []string               `json:"tracked_users"`
+	Users           int       `json:"users"`
+	Duration        string    `json:"duration"`
+	Ramp            string    `json:"ramp"`
+	WorkloadProfile string    `json:"workload_profile,omitempty"` // constant|ramp|spike|step; see generator.Profile*
+	Status          string    `json:"status"`
+	StartTime       time.Time `json:"start_time"`
+	// Labels are custom segmented-reporting properties for this run (e.g.
+	// env=staging, scenario=checkout, build=abc123), set via the start
+	// form/POST /api/start body, validated by validateLabels, and
+	// propagated into RequestsTotal's scenario/env/build dimensions for
+	// the run's duration (see metrics.SetRunLabels).
+	Labels map[string]string `json:"labels,omitempty"`
+	cancel context.CancelFunc
 }
 
+// TestReport aliases reportstore.Report: internal/web only produces and
+// displays reports, internal/reportstore owns persisting them, but moving
+// the type there shouldn't have meant renaming every TestReport{...}
+// literal in this file.
+type TestReport = reportstore.Report
+
 func NewWebServer(cfg *config.Config) *WebServer {
-	return &WebServer{
-		config:  cfg,
-		reports: make([]TestReport, 0),
-		cleanup: cleanup.New(cfg),
+	store, err := reportstore.New(cfg.Reports)
+	if err != nil {
+		log.Printf("web: %v; falling back to in-memory report storage", err)
+		store = reportstore.NewMemory()
+	}
+
+	schedules, err := scheduler.New(cfg.Schedules)
+	if err != nil {
+		log.Printf("web: %v; falling back to in-memory schedule storage", err)
+		schedules = scheduler.NewMemory()
+	}
+
+	ws := &WebServer{
+		config:           cfg,
+		reports:          store,
+		schedules:        schedules,
+		cleanup:          cleanup.New(cfg),
+		subscribers:      make(map[chan Event]struct{}),
+		metricsTS:        query.NewStore(cfg.Metrics.RetentionPoints),
+		labelCardinality: make(map[string]map[string]bool),
+		logger:           obs.NewSlog(slog.Default()),
+		clock:            clock.Real{},
+	}
+	ws.cleanup.SetObserver(ws)
+
+	if existing, err := store.List(context.Background(), reportstore.ListOpts{SortBy: "id"}); err == nil {
+		for _, r := range existing {
+			if r.ID > ws.reportSeq {
+				ws.reportSeq = r.ID
+			}
+		}
+	}
+	if existing, err := schedules.List(context.Background()); err == nil {
+		for _, s := range existing {
+			if s.ID > ws.scheduleSeq {
+				ws.scheduleSeq = s.ID
+			}
+		}
+	}
+
+	return ws
+}
+
+// nextReportID returns the next report ID to assign, persisting across
+// restarts by having started from the highest ID NewWebServer found in
+// the configured store.
+func (ws *WebServer) nextReportID() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.reportSeq++
+	return ws.reportSeq
+}
+
+// nextScheduleID returns the next schedule ID to assign, the scheduler
+// equivalent of nextReportID.
+func (ws *WebServer) nextScheduleID() int {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.scheduleSeq++
+	return ws.scheduleSeq
+}
+
+// labelKeyRe is the allowed shape for a TestRun.Labels key, matching a
+// valid Prometheus label name so every key is safe to eventually promote
+// into a RequestsTotal dimension (see metrics.SetRunLabels).
+var labelKeyRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// maxLabelCardinality bounds how many distinct values a single label key
+// may take across this process's lifetime, so a careless or malicious
+// caller can't blow up RequestsTotal's series count by using e.g. a
+// unique request ID as a label value.
+const maxLabelCardinality = 50
+
+// validateLabels checks labels' keys against labelKeyRe and enforces
+// maxLabelCardinality per key, recording any newly-accepted values in
+// ws.labelCardinality as it goes. Callers must hold ws.mu for writing.
+func (ws *WebServer) validateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if !labelKeyRe.MatchString(k) {
+			return fmt.Errorf("invalid label key %q: must match %s", k, labelKeyRe.String())
+		}
+		values := ws.labelCardinality[k]
+		if values == nil {
+			values = make(map[string]bool)
+			ws.labelCardinality[k] = values
+		}
+		if !values[v] && len(values) >= maxLabelCardinality {
+			return fmt.Errorf("label %q already has %d distinct values; rejecting %q to bound metric cardinality", k, maxLabelCardinality, v)
+		}
+		values[v] = true
+	}
+	return nil
+}
+
+// UserTracked implements cleanup.Observer, publishing the updated tracked
+// user count to /api/events subscribers whenever the generator or a
+// delete-users handler starts tracking a new user.
+func (ws *WebServer) UserTracked(username string) {
+	ws.publish("tracked_users", map[string]interface{}{
+		"username": username,
+		"tracked":  ws.cleanup.GetTrackedUsers(),
+	})
+}
+
+// UserUntracked implements cleanup.Observer, publishing the updated
+// tracked user count whenever a user is deleted/untracked.
+func (ws *WebServer) UserUntracked(username string) {
+	ws.publish("tracked_users", map[string]interface{}{
+		"username": username,
+		"removed":  true,
+		"tracked":  ws.cleanup.GetTrackedUsers(),
+	})
+}
+
+// subscribe registers a new /api/events client, returning a buffered
+// channel of Events meant for it alone. The buffer absorbs a burst of
+// publishes between two reads of the SSE loop; a still-full channel means
+// a slow client, and publish drops rather than blocks for it.
+func (ws *WebServer) subscribe() chan Event {
+	ch := make(chan Event, 16)
+	ws.mu.Lock()
+	ws.subscribers[ch] = struct{}{}
+	ws.mu.Unlock()
+	return ch
+}
+
+// unsubscribe removes and closes ch, undoing subscribe.
+func (ws *WebServer) unsubscribe(ch chan Event) {
+	ws.mu.Lock()
+	delete(ws.subscribers, ch)
+	ws.mu.Unlock()
+	close(ch)
+}
+
+// publish fans kind/data out to every subscribed /api/events client as an
+// Event. It never blocks on a slow subscriber: a subscriber whose buffer
+// is still full simply misses this event, since live metrics are only
+// useful fresh and a dropped counter update is superseded by the next one.
+func (ws *WebServer) publish(kind string, data interface{}) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	for ch := range ws.subscribers {
+		select {
+		case ch <- Event{Kind: kind, Data: data}:
+		default:
+		}
 	}
 }
 
@@ -58,9 +247,18 @@ func (ws *WebServer) Start(addr string) *http.Server {
 	mux.HandleFunc("/", ws.handleHome)
 	mux.HandleFunc("/api/start", ws.handleStart)
 	mux.HandleFunc("/api/stop", ws.handleStop)
+	mux.HandleFunc("/api/tests/current/drain", ws.handleDrainCurrent)
+	mux.HandleFunc("/api/tests/current/abort", ws.handleAbortCurrent)
 	mux.HandleFunc("/api/status", ws.handleStatus)
 	mux.HandleFunc("/api/overview", ws.handleOverview)
 	mux.HandleFunc("/api/reports", ws.handleReports)
+	mux.HandleFunc("/api/reports/compare", ws.handleReportCompare)
+	mux.HandleFunc("/api/reports/", ws.handleReportByID)
+	mux.HandleFunc("/api/events", ws.handleEvents)
+	mux.HandleFunc("/api/query", ws.handleQuery)
+	mux.HandleFunc("/api/query_range", ws.handleQueryRange)
+	mux.HandleFunc("/api/schedules", ws.handleSchedules)
+	mux.HandleFunc("/api/schedules/", ws.handleScheduleByID)
 	mux.HandleFunc("/api/reduce", ws.handleReduceLoad)
 	mux.HandleFunc("/api/delete-users", ws.handleDeleteUsers)
 	mux.HandleFunc("/api/delete-user", ws.handleDeleteUser)
@@ -72,9 +270,23 @@ func (ws *WebServer) Start(addr string) *http.Server {
 	}
 
 	go server.ListenAndServe()
+
+	schedulerCtx, cancel := context.WithCancel(context.Background())
+	ws.stopScheduler = cancel
+	go ws.runScheduler(schedulerCtx)
+
 	return server
 }
 
+// Close stops the background scheduler goroutine Start launched. It
+// doesn't touch the *http.Server Start returned - that's the caller's to
+// shut down.
+func (ws *WebServer) Close() {
+	if ws.stopScheduler != nil {
+		ws.stopScheduler()
+	}
+}
+
 const htmlTemplate = `
 <!DOCTYPE html>
 <html>
@@ -101,7 +313,9 @@ const htmlTemplate = `
         .reports { margin-top: 20px; }
         .report { border: 1px solid #ddd; margin: 10px 0; padding: 15px; border-radius: 4px; }
         .report h4 { margin: 0 0 10px 0; color: #333; }
+        .label-chip { display: inline-block; background: #eef; border-radius: 12px; padding: 2px 10px; margin: 2px 4px 2px 0; font-size: 12px; color: #334; }
     </style>
+    <script src="https://cdn.jsdelivr.net/npm/chart.js"></script>
 </head>
 <body>
     <div class="container">
@@ -121,8 +335,23 @@ const htmlTemplate = `
                 <label>Ramp-up Rate:</label>
                 <input type="text" id="ramp" value="5/s" placeholder="e.g., 5/s, 10/s">
             </div>
+            <div class="form-group">
+                <label>Workload Profile:</label>
+                <select id="workloadProfile">
+                    <option value="ramp" selected>ramp</option>
+                    <option value="constant">constant</option>
+                    <option value="spike">spike</option>
+                    <option value="step">step</option>
+                </select>
+            </div>
+            <div class="form-group">
+                <label>Labels:</label>
+                <input type="text" id="labels" placeholder="env=staging,scenario=checkout">
+            </div>
             <button class="btn-primary" onclick="startTest()">Start Test</button>
             <button class="btn-danger" onclick="stopTest()">Stop Test</button>
+            <button onclick="drainTest()">Drain Test</button>
+            <button class="btn-danger" onclick="abortTest()">Abort Test</button>
         </div>
 
         <div class="card">
@@ -131,6 +360,63 @@ const htmlTemplate = `
             <div id="metrics" class="metrics"></div>
         </div>
 
+        <div class="card">
+            <h2>Metrics Over Time</h2>
+            <div class="form-group">
+                <label>Metric:</label>
+                <select id="chartMetric" onchange="refreshChart()">
+                    <option value="active_users" selected>active_users</option>
+                    <option value="websocket_connections">websocket_connections</option>
+                    <option value="rate(total_requests[30s])">rate(total_requests[30s])</option>
+                </select>
+            </div>
+            <canvas id="metricsChart" height="80"></canvas>
+        </div>
+
+        <div class="card">
+            <h2>Schedules</h2>
+            <p>Queue depth: <span id="scheduleQueueDepth">0</span></p>
+            <div class="form-group">
+                <label>Name:</label>
+                <input type="text" id="scheduleName" placeholder="e.g., nightly-soak">
+            </div>
+            <div class="form-group">
+                <label>Cron (min hour dom month dow, blank for one-shot):</label>
+                <input type="text" id="scheduleCron" value="*/30 * * * *" placeholder="*/30 * * * *">
+            </div>
+            <div class="form-group">
+                <label>Start at (one-shot; RFC3339, required if cron is blank):</label>
+                <input type="text" id="scheduleStartAt" placeholder="2026-07-28T09:00:00Z">
+            </div>
+            <div class="form-group">
+                <label>End at (recurring; optional):</label>
+                <input type="text" id="scheduleEndAt" placeholder="2026-08-28T00:00:00Z">
+            </div>
+            <div class="form-group">
+                <label>Users:</label>
+                <input type="number" id="scheduleUsers" value="10" min="1" max="1000">
+            </div>
+            <div class="form-group">
+                <label>Duration:</label>
+                <input type="text" id="scheduleDuration" value="2m">
+            </div>
+            <div class="form-group">
+                <label>Ramp-up Rate:</label>
+                <input type="text" id="scheduleRamp" value="5/s">
+            </div>
+            <div class="form-group">
+                <label>Workload Profile:</label>
+                <select id="scheduleWorkloadProfile">
+                    <option value="ramp" selected>ramp</option>
+                    <option value="constant">constant</option>
+                    <option value="spike">spike</option>
+                    <option value="step">step</option>
+                </select>
+            </div>
+            <button class="btn-primary" onclick="createSchedule()">Add Schedule</button>
+            <div id="scheduleList" style="margin-top: 15px;"></div>
+        </div>
+
         <div class="card">
             <h2>Reduce Load</h2>
             <p>Remove load-generated users and their data (only affects users created by load generator)</p>
@@ -162,15 +448,26 @@ const htmlTemplate = `
     </div>
 
     <script>
+        function parseLabels(text) {
+            const labels = {};
+            text.split(',').forEach(pair => {
+                const [key, value] = pair.split('=').map(s => s.trim());
+                if (key && value) labels[key] = value;
+            });
+            return labels;
+        }
+
         function startTest() {
             const users = document.getElementById('users').value;
             const duration = document.getElementById('duration').value;
             const ramp = document.getElementById('ramp').value;
-            
+            const workloadProfile = document.getElementById('workloadProfile').value;
+            const labels = parseLabels(document.getElementById('labels').value);
+
             fetch('/api/start', {
                 method: 'POST',
                 headers: {'Content-Type': 'application/json'},
-                body: JSON.stringify({users: parseInt(users), duration, ramp})
+                body: JSON.stringify({users: parseInt(users), duration, ramp, workload_profile: workloadProfile, labels})
             }).then(response => response.json())
               .then(data => updateStatus());
         }
@@ -181,6 +478,18 @@ const htmlTemplate = `
                 .then(data => updateStatus());
         }
 
+        function drainTest() {
+            fetch('/api/tests/current/drain', {method: 'POST'})
+                .then(response => response.json())
+                .then(data => updateStatus());
+        }
+
+        function abortTest() {
+            fetch('/api/tests/current/abort', {method: 'POST'})
+                .then(response => response.json())
+                .then(data => updateStatus());
+        }
+
         function updateStatus() {
             fetch('/api/status')
                 .then(response => response.json())
@@ -279,7 +588,7 @@ const htmlTemplate = `
 								// failed users (if any)
 								if (data.failed_users) {
 									const failed = data.failed_users;
-									const failEntries = Object.keys(failed).map(k => k + ' (status ' + failed[k] + ')');
+									const failEntries = Object.keys(failed).map(k => k + ' (status ' + failed[k].status + ', ' + failed[k].attempts + ' attempts)');
 									if (failEntries.length) html += '<div style="margin-top:6px;color:#a33;"><strong>Failed to delete:</strong> ' + failEntries.join(', ') + '</div>';
 								}
 								document.getElementById('loadInfo').innerHTML = html;
@@ -325,7 +634,7 @@ const htmlTemplate = `
 					if (data.deleted_users.length) html += '<div style="margin-top:6px;"><strong>Deleted:</strong> ' + data.deleted_users.join(', ') + '</div>';
 					if (data.failed_users) {
 						const failed = data.failed_users;
-						const failEntries = Object.keys(failed).map(k => k + ' (status ' + failed[k] + ')');
+						const failEntries = Object.keys(failed).map(k => k + ' (status ' + failed[k].status + ', ' + failed[k].attempts + ' attempts)');
 						if (failEntries.length) html += '<div style="margin-top:6px;color:#a33;"><strong>Failed to delete:</strong> ' + failEntries.join(', ') + '</div>';
 					}
 					document.getElementById('deleteInfo').innerHTML = html;
@@ -351,12 +660,17 @@ const htmlTemplate = `
 						reportsDiv.innerHTML = data.map(report => {
 							const duration = new Date(report.end_time) - new Date(report.start_time);
 							const durationStr = Math.round(duration / 1000) + 's';
+							const labels = report.labels || {};
+							const labelsHtml = Object.keys(labels).length
+								? '<p class="labels">' + Object.entries(labels).map(([k, v]) => '<span class="label-chip">' + k + '=' + v + '</span>').join(' ') + '</p>'
+								: '';
 							return '<div class="report">' +
 							'<h4>Test #' + report.id + ' - ' + report.status.toUpperCase() + '</h4>' +
 							'<p><strong>Config:</strong> ' + report.users + ' users, ' + report.duration + ' duration, ' + report.ramp + ' ramp-up</p>' +
 							'<p><strong>Started:</strong> ' + new Date(report.start_time).toLocaleString() + '</p>' +
 							'<p><strong>Ended:</strong> ' + new Date(report.end_time).toLocaleString() + '</p>' +
 							'<p><strong>Actual Duration:</strong> ' + durationStr + '</p>' +
+							labelsHtml +
 							'</div>';
 						}).reverse().join('');
 					})
@@ -366,15 +680,129 @@ const htmlTemplate = `
 					});
 			}
 
-			// Update every 2 seconds
-			setInterval(() => {
-				updateStatus();
-				updateReports();
-			}, 2000);
+			function createSchedule() {
+				const body = {
+					name: document.getElementById('scheduleName').value,
+					cron: document.getElementById('scheduleCron').value,
+					startAt: document.getElementById('scheduleStartAt').value,
+					endAt: document.getElementById('scheduleEndAt').value,
+					users: parseInt(document.getElementById('scheduleUsers').value),
+					duration: document.getElementById('scheduleDuration').value,
+					ramp: document.getElementById('scheduleRamp').value,
+					workload_profile: document.getElementById('scheduleWorkloadProfile').value
+				};
+				fetch('/api/schedules', {
+					method: 'POST',
+					headers: {'Content-Type': 'application/json'},
+					body: JSON.stringify(body)
+				}).then(r => r.json()).then(() => updateSchedules())
+				  .catch(err => alert('Failed to create schedule: ' + err.message));
+			}
+
+			function deleteSchedule(id) {
+				fetch('/api/schedules/' + id, {method: 'DELETE'})
+					.then(() => updateSchedules());
+			}
+
+			function runScheduleNow(id) {
+				fetch('/api/schedules/' + id + '/run-now', {method: 'POST'})
+					.then(() => updateSchedules());
+			}
+
+			function updateSchedules() {
+				fetch('/api/schedules')
+					.then(response => response.json())
+					.then(schedules => {
+						const listDiv = document.getElementById('scheduleList');
+						if (!schedules || schedules.length === 0) {
+							listDiv.innerHTML = '<em>No schedules configured</em>';
+							return;
+						}
+						listDiv.innerHTML = schedules.map(s => {
+							const next = s.next_run ? new Date(s.next_run).toLocaleString() : '-';
+							const trigger = s.cron_expr ? '<code>' + s.cron_expr + '</code>' : 'one-shot';
+							const enabled = s.enabled ? '' : ' (disabled)';
+							return '<div class="report">' +
+								'<strong>' + (s.name || ('Schedule #' + s.id)) + '</strong> - ' + trigger + enabled +
+								'<p>Next run: ' + next + ' | ' + s.spec.users + ' users, ' + s.spec.duration + '</p>' +
+								'<button onclick="runScheduleNow(' + s.id + ')">Run Now</button> ' +
+								'<button class="btn-danger" onclick="deleteSchedule(' + s.id + ')">Delete</button>' +
+								'</div>';
+						}).join('');
+					});
+				fetch('/api/overview')
+					.then(r => r.json())
+					.then(data => {
+						document.getElementById('scheduleQueueDepth').innerText = data.queue_depth || 0;
+					});
+			}
+
+			// Live updates via /api/events (SSE) replace the old 2-second
+			// setInterval poll of /api/status, /api/overview, and
+			// /api/reports. EventSource reconnects on its own if the
+			// connection drops, so there's no manual retry here.
+			function connectEvents() {
+				const es = new EventSource('/api/events');
+
+				es.addEventListener('status', (e) => {
+					const data = JSON.parse(e.data);
+					const statusDiv = document.getElementById('status');
+					if (data.status === 'running') {
+						statusDiv.className = 'status running';
+						statusDiv.innerHTML = 'Running: ' + data.users + ' users, ' + data.duration + ' duration, ' + data.ramp + ' ramp-up';
+					} else {
+						statusDiv.className = 'status stopped';
+						statusDiv.innerHTML = 'No test running';
+					}
+					updateMetrics();
+				});
+
+				es.addEventListener('tracked_users', () => updateMetrics());
+				es.addEventListener('metrics', () => refreshChart());
+				es.addEventListener('report', () => updateReports());
+				es.addEventListener('schedules', () => updateSchedules());
+			}
+
+			let metricsChart = null;
+
+			function refreshChart() {
+				const metric = document.getElementById('chartMetric').value;
+				const end = new Date();
+				const start = new Date(end.getTime() - 5 * 60 * 1000); // last 5 minutes
+
+				const url = '/api/query_range?query=' + encodeURIComponent(metric) +
+					'&start=' + encodeURIComponent(start.toISOString()) +
+					'&end=' + encodeURIComponent(end.toISOString()) +
+					'&step=5s';
+
+				fetch(url).then(r => r.json()).then(data => {
+					const series = (data.result && data.result[0]) || {values: []};
+					const labels = series.values.map(v => new Date(v[0] * 1000).toLocaleTimeString());
+					const points = series.values.map(v => v[1]);
+
+					if (!window.Chart) return; // Chart.js failed to load (e.g. offline)
+					if (!metricsChart) {
+						const ctx = document.getElementById('metricsChart').getContext('2d');
+						metricsChart = new Chart(ctx, {
+							type: 'line',
+							data: {labels: labels, datasets: [{label: metric, data: points, borderColor: '#007acc', fill: false}]},
+							options: {animation: false, scales: {x: {display: true}, y: {beginAtZero: true}}}
+						});
+					} else {
+						metricsChart.data.labels = labels;
+						metricsChart.data.datasets[0].label = metric;
+						metricsChart.data.datasets[0].data = points;
+						metricsChart.update();
+					}
+				}).catch(() => {});
+			}
 
-			// Initial load
+			// Initial load, then switch to push updates
 			updateStatus();
 			updateReports();
+			updateSchedules();
+			refreshChart();
+			connectEvents();
     </script>
 </body>
 </html>
@@ -398,29 +826,66 @@ func (ws *WebServer) handleStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ws.mu.Lock()
-	defer ws.mu.Unlock()
-
-	// Stop current test if running
+	if err := ws.validateLabels(req.Labels); err != nil {
+		ws.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// A manual start always wins: cancel whatever's running rather than
+	// queuing behind it. Scheduled runs queue instead (see enqueueOrStart).
 	if ws.currentTest != nil && ws.currentTest.Status == "running" {
 		ws.currentTest.cancel()
 	}
+	ws.mu.Unlock()
+
+	ws.beginTest(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
+}
 
-	// Start new test
+// beginTest starts req as the current test: builds its cancel context,
+// installs it as ws.currentTest, launches runTest, and publishes its
+// status. Callers are responsible for ws.currentTest being free first -
+// handleStart cancels whatever's running, enqueueOrStart and runTest's
+// queue drain only call this when nothing is.
+func (ws *WebServer) beginTest(req TestRun) {
 	ctx, cancel := context.WithCancel(context.Background())
+
+	ws.mu.Lock()
 	ws.currentTest = &TestRun{
-		Users:     req.Users,
-		Duration:  req.Duration,
-		Ramp:      req.Ramp,
-		Status:    "running",
-		StartTime: time.Now(),
-		cancel:    cancel,
+		Users:           req.Users,
+		Duration:        req.Duration,
+		Ramp:            req.Ramp,
+		WorkloadProfile: req.WorkloadProfile,
+		Status:          "running",
+		StartTime:       time.Now(),
+		Labels:          req.Labels,
+		cancel:          cancel,
 	}
+	current := ws.currentTest
+	ws.mu.Unlock()
 
-	// Run test in background
 	go ws.runTest(ctx, req)
+	ws.publish("status", current)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
+// enqueueOrStart starts req immediately if no test is currently running,
+// otherwise appends it to ws.queue to run once the current one finishes
+// (see runTest's queue drain). Used by schedule firing and run-now, which
+// - unlike a manual POST /api/start - shouldn't cancel an in-flight test.
+func (ws *WebServer) enqueueOrStart(req TestRun) {
+	ws.mu.Lock()
+	if ws.currentTest != nil && ws.currentTest.Status == "running" {
+		ws.queue = append(ws.queue, req)
+		depth := len(ws.queue)
+		ws.mu.Unlock()
+		ws.publish("schedules", map[string]interface{}{"queued": true, "queue_depth": depth})
+		return
+	}
+	ws.mu.Unlock()
+
+	ws.beginTest(req)
 }
 
 func (ws *WebServer) handleStop(w http.ResponseWriter, r *http.Request) {
@@ -429,31 +894,177 @@ func (ws *WebServer) handleStop(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ws.mu.Lock()
-	defer ws.mu.Unlock()
+	ws.stopCurrentTest(r.Context())
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "stopped"})
+}
+
+// stopCurrentTest cancels the running test, if any, and saves/publishes
+// its report - the shared core of handleStop and the control.Hooks
+// StopRun method.
+func (ws *WebServer) stopCurrentTest(ctx context.Context) {
+	ws.mu.Lock()
+	var stopped *TestRun
 	if ws.currentTest != nil && ws.currentTest.Status == "running" {
 		ws.currentTest.cancel()
 		ws.currentTest.Status = "stopped"
-
-		// Create report for stopped test
-		report := TestReport{
-			ID:           len(ws.reports) + 1,
-			Users:        ws.currentTest.Users,
-			Duration:     ws.currentTest.Duration,
-			Ramp:         ws.currentTest.Ramp,
-			StartTime:    ws.currentTest.StartTime,
-			EndTime:      time.Now(),
-			Status:       "stopped",
-			Metrics:      ws.collectMetrics(),
-			TrackedUsers: ws.cleanup.GetTrackedUsers(),
-		}
-		ws.reports = append(ws.reports, report)
+		stopped = ws.currentTest
 		ws.currentTest = nil
 	}
+	ws.mu.Unlock()
+
+	if stopped == nil {
+		return
+	}
+
+	report := TestReport{
+		ID:              ws.nextReportID(),
+		Users:           stopped.Users,
+		Duration:        stopped.Duration,
+		Ramp:            stopped.Ramp,
+		WorkloadProfile: stopped.WorkloadProfile,
+		StartTime:       stopped.StartTime,
+		EndTime:         time.Now(),
+		Status:          "stopped",
+		Metrics:         ws.collectMetrics(ctx),
+		TrackedUsers:    ws.cleanup.GetTrackedUsers(),
+		Labels:          stopped.Labels,
+	}
+	if err := ws.reports.Save(context.Background(), report); err != nil {
+		log.Printf("web: failed to save report %d: %v", report.ID, err)
+	}
+	ws.publish("report", report)
+	ws.publish("status", map[string]string{"status": "stopped"})
+}
+
+// StartRun, StopRun, ScaleUsers and Events implement control.Hooks, so a
+// control.Controller built over ws can drive the same start/stop/scale/
+// event-stream operations the HTTP handlers above expose, for whatever
+// eventually sits behind proto/control.proto's LoadgenControl service.
+func (ws *WebServer) StartRun(req control.StartRunRequest) error {
+	run := TestRun{
+		Users:           req.Users,
+		Duration:        req.Duration,
+		Ramp:            req.Ramp,
+		WorkloadProfile: req.WorkloadProfile,
+		Labels:          req.Labels,
+	}
+
+	ws.mu.Lock()
+	if err := ws.validateLabels(run.Labels); err != nil {
+		ws.mu.Unlock()
+		return err
+	}
+	if ws.currentTest != nil && ws.currentTest.Status == "running" {
+		ws.currentTest.cancel()
+	}
+	ws.mu.Unlock()
+
+	ws.beginTest(run)
+	return nil
+}
+
+func (ws *WebServer) StopRun() error {
+	ws.stopCurrentTest(context.Background())
+	return nil
+}
+
+// ScaleUsers only supports scaling down: internal/generator.Generator
+// spawns exactly Users goroutines up front, so there's no way to grow a
+// running test's population, only to delete some of its tracked users
+// via cleanup.Cleanup.ReduceLoad (the same call POST /api/reduce-load
+// makes).
+func (ws *WebServer) ScaleUsers(ctx context.Context, req control.ScaleUsersRequest) (int64, error) {
+	current := int64(len(ws.cleanup.GetTrackedUsers()))
+	if int64(req.TargetCount) >= current {
+		return current, fmt.Errorf("web: scale up not supported, %d active users already at or below target %d", current, req.TargetCount)
+	}
+	ws.cleanup.ReduceLoad(ctx, int(current)-req.TargetCount)
+	return int64(len(ws.cleanup.GetTrackedUsers())), nil
+}
+
+// Events adapts ws's existing subscribe/publish SSE hub into a
+// control.RunEvent stream. It's coarser than the per-user lifecycle
+// events (authenticated, action executed, errored) control.proto's
+// RunEvent doc aims for - internal/user.User doesn't publish at that
+// granularity yet, only test-level status/report/tracked_users events -
+// so Kind here is one of those instead; wiring real per-action events
+// would mean threading an emitter through internal/user and
+// internal/generator, which is a bigger change than this adapter.
+func (ws *WebServer) Events() (<-chan control.RunEvent, func()) {
+	sub := ws.subscribe()
+	out := make(chan control.RunEvent, 16)
+
+	go func() {
+		defer close(out)
+		for ev := range sub {
+			select {
+			case out <- control.RunEvent{Kind: ev.Kind, Detail: fmt.Sprintf("%v", ev.Data), Time: time.Now()}:
+			default:
+			}
+		}
+	}()
+
+	return out, func() { ws.unsubscribe(sub) }
+}
+
+// handleDrainCurrent serves POST /api/tests/current/drain: a graceful
+// stop, inspired by Loki's shutdown_and_forget. It signals the running
+// generator.Generator to stop ramping up and let every in-flight virtual
+// user finish its current scenario action before exiting, rather than
+// cancelling ctx outright (see handleAbortCurrent for that). runTest
+// finalizes the eventual report with status "drained" once gen.Run
+// actually returns.
+func (ws *WebServer) handleDrainCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ws.mu.Lock()
+	if ws.currentTest == nil || ws.currentTest.Status != "running" {
+		ws.mu.Unlock()
+		http.Error(w, "no test is currently running", http.StatusConflict)
+		return
+	}
+	ws.currentTest.Status = "draining"
+	gen := ws.currentGenerator
+	ws.mu.Unlock()
+
+	if gen != nil {
+		gen.Drain()
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "stopped"})
+	json.NewEncoder(w).Encode(map[string]string{"status": "draining"})
+}
+
+// handleAbortCurrent serves POST /api/tests/current/abort: cancels the
+// running test's context immediately, abandoning any in-flight requests
+// mid-call, and forgets (see cleanup.Cleanup.Forget) the users it had
+// tracked so far rather than leaving them as cleanup candidates. Contrast
+// with handleStop, which also stops the run immediately but keeps its
+// tracked users; and handleDrainCurrent, which lets the run wind down on
+// its own instead of cancelling it.
+func (ws *WebServer) handleAbortCurrent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ws.mu.Lock()
+	if ws.currentTest == nil || (ws.currentTest.Status != "running" && ws.currentTest.Status != "draining") {
+		ws.mu.Unlock()
+		http.Error(w, "no test is currently running", http.StatusConflict)
+		return
+	}
+	ws.currentTest.Status = "aborted"
+	ws.currentTest.cancel()
+	ws.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "aborted"})
 }
 
 func (ws *WebServer) handleReduceLoad(w http.ResponseWriter, r *http.Request) {
@@ -479,11 +1090,11 @@ func (ws *WebServer) handleReduceLoad(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"deleted_count":   len(deletedUsers),
-		"deleted_users":   deletedUsers,
-		"failed_users":    failed,
-		"remaining":       remaining,
-		"status":          "completed",
+		"deleted_count": len(deletedUsers),
+		"deleted_users": deletedUsers,
+		"failed_users":  failed,
+		"remaining":     remaining,
+		"status":        "completed",
 	})
 }
 
@@ -493,7 +1104,7 @@ func (ws *WebServer) handleDeleteUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct{
+	var req struct {
 		Count int `json:"count"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -512,9 +1123,9 @@ func (ws *WebServer) handleDeleteUsers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"deleted_count": len(deletedUsers),
 		"deleted_users": deletedUsers,
-		"failed_users": failed,
-		"remaining": remaining,
-		"status": "completed",
+		"failed_users":  failed,
+		"remaining":     remaining,
+		"status":        "completed",
 	})
 }
 
@@ -524,7 +1135,7 @@ func (ws *WebServer) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct{
+	var req struct {
 		Username string `json:"username"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -540,28 +1151,32 @@ func (ws *WebServer) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"deleted": deleted,
+		"deleted":     deleted,
 		"status_code": code,
-		"remaining": remaining,
+		"remaining":   remaining,
 	})
 }
 
-func (ws *WebServer) collectMetrics() map[string]interface{} {
+func (ws *WebServer) collectMetrics(ctx context.Context) map[string]interface{} {
+	logger := obs.FromContext(ctx)
+
 	// Collect current metrics from Prometheus endpoint
 	resp, err := http.Get("http://localhost:" + ws.config.MetricsPort + "/metrics")
 	if err != nil {
+		logger.Error("could not collect metrics", err)
 		return map[string]interface{}{"error": "Could not collect metrics"}
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		logger.Error("could not read metrics", err)
 		return map[string]interface{}{"error": "Could not read metrics"}
 	}
 
 	txt := string(body)
 	metrics := make(map[string]interface{})
-	metrics["timestamp"] = time.Now()
+	metrics["timestamp"] = ws.clock.Now()
 	metrics["status"] = "collected"
 
 	// Parse a few useful values
@@ -589,9 +1204,105 @@ func (ws *WebServer) collectMetrics() map[string]interface{} {
 		}
 	}
 
+	if total, errors := requestErrorTotals(txt); total > 0 {
+		metrics["error_count"] = errors
+		metrics["error_rate"] = float64(errors) / float64(total)
+	}
+
+	for q, v := range requestLatencyPercentiles(txt) {
+		metrics[q] = v
+	}
+
 	return metrics
 }
 
+var (
+	reStatusTotal = regexp.MustCompile(`loadgen_requests_total\{[^}]*status="(\d+)"[^}]*\}\s+(\d+(?:\.\d+)?)`)
+	reLatencyBkt  = regexp.MustCompile(`loadgen_request_duration_seconds_bucket\{[^}]*le="([^"]+)"[^}]*\}\s+(\d+(?:\.\d+)?)`)
+)
+
+// requestErrorTotals sums loadgen_requests_total across every
+// service/method/... combination, split into the grand total and the
+// subset whose status is a 4xx/5xx, for handleReportCompare's error_rate
+// delta.
+func requestErrorTotals(txt string) (total, errors float64) {
+	for _, m := range reStatusTotal.FindAllStringSubmatch(txt, -1) {
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		total += v
+		if len(m[1]) == 3 && (m[1][0] == '4' || m[1][0] == '5') {
+			errors += v
+		}
+	}
+	return total, errors
+}
+
+// requestLatencyPercentiles approximates p50/p95/p99 (in seconds) from
+// loadgen_request_duration_seconds_bucket's cumulative histogram buckets,
+// summed across every service/method label combination sharing the same
+// bucket boundaries (prometheus.DefBuckets - see internal/metrics). The
+// result is an interpolation over bucket boundaries, not an exact
+// quantile, which is the usual tradeoff of reading quantiles back out of a
+// Prometheus histogram instead of a summary.
+func requestLatencyPercentiles(txt string) map[string]float64 {
+	counts := make(map[float64]float64)
+	var bounds []float64
+	for _, m := range reLatencyBkt.FindAllStringSubmatch(txt, -1) {
+		le, err := strconv.ParseFloat(m[1], 64)
+		if err != nil { // "+Inf"
+			le = math.Inf(1)
+		}
+		v, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			continue
+		}
+		if _, seen := counts[le]; !seen {
+			bounds = append(bounds, le)
+		}
+		counts[le] += v
+	}
+	if len(bounds) == 0 {
+		return nil
+	}
+	sort.Float64s(bounds)
+	total := counts[bounds[len(bounds)-1]]
+	if total == 0 {
+		return nil
+	}
+
+	out := make(map[string]float64, 3)
+	for name, q := range map[string]float64{"p50": 0.5, "p95": 0.95, "p99": 0.99} {
+		target := q * total
+		for _, le := range bounds {
+			if counts[le] >= target {
+				out[name] = le
+				break
+			}
+		}
+	}
+	return out
+}
+
+// recordMetricsSample appends m's numeric fields to ws.metricsTS as of t,
+// so /api/query and /api/query_range can chart them after the fact. Only
+// the fields collectMetrics actually parses as numbers are series; a
+// collection error (m["error"] set) records nothing for this tick.
+func (ws *WebServer) recordMetricsSample(m map[string]interface{}, t time.Time) {
+	for _, name := range []string{"active_users", "websocket_connections", "total_requests"} {
+		v, ok := m[name]
+		if !ok {
+			continue
+		}
+		n, ok := v.(int)
+		if !ok {
+			continue
+		}
+		ws.metricsTS.Record(name, nil, t, float64(n))
+	}
+}
+
 // handleMetricsProxy proxies the metrics endpoint so the browser can fetch /metrics relative to the web UI
 func (ws *WebServer) handleMetricsProxy(w http.ResponseWriter, r *http.Request) {
 	resp, err := http.Get("http://localhost:" + ws.config.MetricsPort + "/metrics")
@@ -626,19 +1337,28 @@ func (ws *WebServer) handleOverview(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	metrics := ws.collectMetrics()
+	metrics := ws.collectMetrics(r.Context())
 
 	overview := map[string]interface{}{
 		"total_users":   totalUsers,
 		"tracked_users": ws.cleanup.GetTrackedUsers(),
 		"tracked_count": len(ws.cleanup.GetTrackedUsers()),
 		"metrics":       metrics,
+		"queue_depth":   ws.queueDepth(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(overview)
 }
 
+// queueDepth reports how many runs are waiting behind the current test
+// (see runTest's queue drain).
+func (ws *WebServer) queueDepth() int {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return len(ws.queue)
+}
+
 func (ws *WebServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	ws.mu.RLock()
 	defer ws.mu.RUnlock()
@@ -652,22 +1372,597 @@ func (ws *WebServer) handleStatus(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(ws.currentTest)
 }
 
+// handleReports serves GET /api/reports?status=&since=&until=&min_users=&max_users=&limit=&offset=&sort_by=&label.<key>=<value>,
+// binding the query string onto a reportstore.ListOpts (see parseListOpts).
+// limit defaults to 50 when unset, matching the old hardcoded "last 5"
+// behavior's intent of returning recent reports rather than the full
+// history.
 func (ws *WebServer) handleReports(w http.ResponseWriter, r *http.Request) {
-	ws.mu.RLock()
-	defer ws.mu.RUnlock()
+	opts, err := parseListOpts(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Limit == 0 {
+		opts.Limit = 50
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	// Return only the most recent 5 reports
-	reports := ws.reports
-	if len(reports) > 5 {
-		reports = reports[len(reports)-5:]
+	reports, err := ws.reports.List(r.Context(), opts)
+	if err != nil {
+		http.Error(w, "failed to list reports: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(reports)
 }
 
+// parseListOpts binds URL query parameters onto a reportstore.ListOpts,
+// the same query-string-to-filter-struct shape this codebase's alert-list
+// APIs use.
+func parseListOpts(q url.Values) (reportstore.ListOpts, error) {
+	var opts reportstore.ListOpts
+
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid since: %w", err)
+		}
+		opts.Since = t
+	}
+	if v := q.Get("until"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid until: %w", err)
+		}
+		opts.Until = t
+	}
+	opts.Status = q.Get("status")
+	opts.SortBy = q.Get("sort_by")
+
+	for param, dst := range map[string]*int{
+		"min_users": &opts.MinUsers,
+		"max_users": &opts.MaxUsers,
+		"limit":     &opts.Limit,
+		"offset":    &opts.Offset,
+	} {
+		v := q.Get(param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %w", param, err)
+		}
+		*dst = n
+	}
+
+	for param, vals := range q {
+		key, ok := strings.CutPrefix(param, "label.")
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if opts.Labels == nil {
+			opts.Labels = make(map[string]string)
+		}
+		opts.Labels[key] = vals[0]
+	}
+
+	return opts, nil
+}
+
+// handleReportByID serves GET and DELETE on /api/reports/{id}.
+func (ws *WebServer) handleReportByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/reports/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "invalid report id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		report, err := ws.reports.Get(r.Context(), id)
+		if err == reportstore.ErrNotFound {
+			http.Error(w, "report not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to get report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+
+	case http.MethodDelete:
+		err := ws.reports.Delete(r.Context(), id)
+		if err == reportstore.ErrNotFound {
+			http.Error(w, "report not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to delete report: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// metricDelta is one named metric's value in each of the two compared
+// reports, plus b-a, so a caller doesn't have to redo the subtraction for
+// every field handleReportCompare exposes.
+type metricDelta struct {
+	A, B, Delta float64
+}
+
+// handleReportCompare serves GET /api/reports/compare?a=<id>&b=<id>,
+// returning per-metric deltas between two past runs: p50/p95/p99 request
+// latency and error_rate (both parsed out of each report's stored
+// Prometheus snapshot by collectMetrics at the time it ran - see
+// requestLatencyPercentiles/requestErrorTotals) plus RPS (derived from
+// each report's own total_requests and StartTime/EndTime, not stored
+// directly).
+func (ws *WebServer) handleReportCompare(w http.ResponseWriter, r *http.Request) {
+	aID, errA := strconv.Atoi(r.URL.Query().Get("a"))
+	bID, errB := strconv.Atoi(r.URL.Query().Get("b"))
+	if errA != nil || errB != nil {
+		http.Error(w, "a and b must both be report ids", http.StatusBadRequest)
+		return
+	}
+
+	a, err := ws.reports.Get(r.Context(), aID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("report %d: %v", aID, err), http.StatusNotFound)
+		return
+	}
+	b, err := ws.reports.Get(r.Context(), bID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("report %d: %v", bID, err), http.StatusNotFound)
+		return
+	}
+
+	deltas := make(map[string]metricDelta)
+	for _, name := range []string{"p50", "p95", "p99", "error_rate"} {
+		av, aok := asFloat64(a.Metrics[name])
+		bv, bok := asFloat64(b.Metrics[name])
+		if !aok || !bok {
+			continue
+		}
+		deltas[name] = metricDelta{A: av, B: bv, Delta: bv - av}
+	}
+	if rpsA, ok := reportRPS(a); ok {
+		if rpsB, ok := reportRPS(b); ok {
+			deltas["rps"] = metricDelta{A: rpsA, B: rpsB, Delta: rpsB - rpsA}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"a":      a,
+		"b":      b,
+		"deltas": deltas,
+	})
+}
+
+// reportRPS computes r's average requests/sec from its stored
+// total_requests and wall-clock duration, or ok=false if either isn't
+// available.
+func reportRPS(r TestReport) (rps float64, ok bool) {
+	total, ok := asFloat64(r.Metrics["total_requests"])
+	if !ok {
+		return 0, false
+	}
+	elapsed := r.EndTime.Sub(r.StartTime).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	return total / elapsed, true
+}
+
+// asFloat64 coerces a metrics map value to float64 regardless of whether
+// it's the int collectMetrics originally stored (the memory backend) or
+// the float64 a JSON round-trip through sqlite/jsonl decodes every number
+// as.
+func asFloat64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// runScheduler is a ticker loop evaluating every saved schedule every 30s
+// and firing any that are due (see fireDueSchedules), started by Start
+// and stopped by Close.
+func (ws *WebServer) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ws.fireDueSchedules()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fireDueSchedules fires (see fireSchedule) every enabled schedule whose
+// NextRun is no later than now.
+func (ws *WebServer) fireDueSchedules() {
+	all, err := ws.schedules.List(context.Background())
+	if err != nil {
+		log.Printf("web: failed to list schedules: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, s := range all {
+		if !s.Enabled || s.NextRun.After(now) {
+			continue
+		}
+		ws.fireSchedule(s)
+	}
+}
+
+// fireSchedule starts s's configured run, skipping (not queuing) it if a
+// test is already running - a recurring schedule would otherwise pile up
+// behind a long-running manual test instead of just waiting for its next
+// occurrence. A one-shot schedule (empty CronExpr) disables itself after
+// firing once; a recurring one advances and persists NextRun, disabling
+// itself instead if that would land past EndAt.
+func (ws *WebServer) fireSchedule(s scheduler.ScheduledTest) {
+	if !ws.startIfFree(TestRun{
+		Users:           s.Spec.Users,
+		Duration:        s.Spec.Duration,
+		Ramp:            s.Spec.Ramp,
+		WorkloadProfile: s.Spec.WorkloadProfile,
+	}) {
+		log.Printf("web: schedule %d skipped: a test is already running", s.ID)
+		ws.publish("schedules", map[string]interface{}{"skipped": s.ID})
+		return
+	}
+
+	if s.OneShot() {
+		s.Enabled = false
+	} else {
+		cron, err := scheduler.Parse(s.CronExpr)
+		if err != nil {
+			log.Printf("web: schedule %d has an invalid cron expression %q: %v", s.ID, s.CronExpr, err)
+			return
+		}
+		next, err := cron.Next(time.Now())
+		if err != nil {
+			log.Printf("web: schedule %d: %v", s.ID, err)
+			return
+		}
+		if !s.EndAt.IsZero() && next.After(s.EndAt) {
+			s.Enabled = false
+		} else {
+			s.NextRun = next
+		}
+	}
+
+	if err := ws.schedules.Save(context.Background(), s); err != nil {
+		log.Printf("web: failed to persist schedule %d's next run: %v", s.ID, err)
+	}
+	ws.publish("schedules", map[string]interface{}{"fired": s.ID})
+}
+
+// startIfFree starts req only if no test is currently running, skipping
+// it (returning false) rather than queuing otherwise. Scheduled/recurring
+// runs use this - see fireSchedule - so they don't pile up behind an
+// in-flight test; enqueueOrStart (run-now, and any other caller wanting
+// queuing semantics) is still available for that.
+func (ws *WebServer) startIfFree(req TestRun) bool {
+	ws.mu.Lock()
+	if ws.currentTest != nil && ws.currentTest.Status == "running" {
+		ws.mu.Unlock()
+		return false
+	}
+	ws.mu.Unlock()
+
+	ws.beginTest(req)
+	return true
+}
+
+// handleSchedules serves GET (list all schedules) and POST (create one)
+// on /api/schedules. A created schedule's NextRun is computed
+// immediately from its cron expression so it shows up correctly in the
+// "Schedules" card before it's ever fired.
+func (ws *WebServer) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		all, err := ws.schedules.List(r.Context())
+		if err != nil {
+			http.Error(w, "failed to list schedules: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(all)
+
+	case http.MethodPost:
+		var req struct {
+			Name            string `json:"name"`
+			Cron            string `json:"cron"`
+			Users           int    `json:"users"`
+			Duration        string `json:"duration"`
+			Ramp            string `json:"ramp"`
+			WorkloadProfile string `json:"workload_profile"`
+			StartAt         string `json:"startAt"`
+			EndAt           string `json:"endAt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		var startAt, endAt time.Time
+		if req.StartAt != "" {
+			t, err := time.Parse(time.RFC3339, req.StartAt)
+			if err != nil {
+				http.Error(w, "invalid startAt: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			startAt = t
+		}
+		if req.EndAt != "" {
+			t, err := time.Parse(time.RFC3339, req.EndAt)
+			if err != nil {
+				http.Error(w, "invalid endAt: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			endAt = t
+		}
+
+		s := scheduler.ScheduledTest{
+			ID:   ws.nextScheduleID(),
+			Name: req.Name,
+			Spec: scheduler.RunSpec{
+				Users:           req.Users,
+				Duration:        req.Duration,
+				Ramp:            req.Ramp,
+				WorkloadProfile: req.WorkloadProfile,
+			},
+			StartAt: startAt,
+			EndAt:   endAt,
+			Enabled: true,
+		}
+
+		if req.Cron != "" {
+			cron, err := scheduler.Parse(req.Cron)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			next, err := cron.Next(time.Now())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			s.CronExpr = req.Cron
+			s.NextRun = next
+		} else if !startAt.IsZero() {
+			s.NextRun = startAt
+		} else {
+			http.Error(w, "one of cron or startAt is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := ws.schedules.Save(r.Context(), s); err != nil {
+			http.Error(w, "failed to save schedule: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ws.publish("schedules", map[string]interface{}{"created": s.ID})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScheduleByID serves DELETE /api/schedules/{id} and
+// POST /api/schedules/{id}/run-now.
+func (ws *WebServer) handleScheduleByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/schedules/")
+	id64 := rest
+	action := ""
+	if idPart, act, found := strings.Cut(rest, "/"); found {
+		id64, action = idPart, act
+	}
+
+	id, err := strconv.Atoi(id64)
+	if err != nil {
+		http.Error(w, "invalid schedule id", http.StatusBadRequest)
+		return
+	}
+
+	if action != "" {
+		if action != "run-now" || r.Method != http.MethodPost {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		s, err := ws.schedules.Get(r.Context(), id)
+		if err == scheduler.ErrNotFound {
+			http.Error(w, "schedule not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to get schedule: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ws.enqueueOrStart(TestRun{
+			Users:           s.Spec.Users,
+			Duration:        s.Spec.Duration,
+			Ramp:            s.Spec.Ramp,
+			WorkloadProfile: s.Spec.WorkloadProfile,
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": "triggered"})
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := ws.schedules.Delete(r.Context(), id); err != nil {
+		if err == scheduler.ErrNotFound {
+			http.Error(w, "schedule not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to delete schedule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ws.publish("schedules", map[string]interface{}{"deleted": id})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
+}
+
+// handleQuery serves GET /api/query?query=<expr>&time=<RFC3339>, modeled
+// on Prometheus's instant query endpoint: query is parsed per
+// internal/query's grammar (bare metric, rate(metric[window]), or a
+// {label="value"} filter) and evaluated as of time (now if omitted).
+func (ws *WebServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	expr, err := query.Parse(r.URL.Query().Get("query"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	at := time.Now()
+	if ts := r.URL.Query().Get("time"); ts != "" {
+		parsed, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			http.Error(w, "invalid time: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		at = parsed
+	}
+
+	v, ok := ws.metricsTS.Instant(expr, at)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"resultType": "scalar", "result": nil})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"resultType": "scalar",
+		"result":     [2]float64{float64(at.Unix()), v},
+	})
+}
+
+// handleQueryRange serves GET /api/query_range?query=<expr>&start=<RFC3339>&end=<RFC3339>&step=<duration>,
+// modeled on Prometheus's range query endpoint, driving the control
+// panel's Chart.js graph.
+func (ws *WebServer) handleQueryRange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	expr, err := query.Parse(q.Get("query"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, q.Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse(time.RFC3339, q.Get("end"))
+	if err != nil {
+		http.Error(w, "invalid end: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	step, err := time.ParseDuration(q.Get("step"))
+	if err != nil {
+		step = time.Second
+	}
+
+	result := ws.metricsTS.Range(expr, start, end, step)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"resultType": "matrix",
+		"result":     result,
+	})
+}
+
+// handleEvents serves /api/events as a text/event-stream: every status,
+// metrics, tracked_users, and report update published during this
+// connection's lifetime (see publish), plus a heartbeat comment every 15s
+// so idle connections aren't reaped by intermediate proxies. Replaces the
+// control panel's 2-second setInterval polling of /api/status,
+// /api/overview, and /api/reports with push updates; those endpoints stay
+// in place for anything that still wants to poll them.
+func (ws *WebServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := ws.subscribe()
+	defer ws.unsubscribe(ch)
+
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev.Data)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Kind, payload)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 func (ws *WebServer) runTest(ctx context.Context, req TestRun) {
+	// testID doubles as this run's report ID (see report.ID below) and the
+	// test_id every log line and lifecycle event from here down carries,
+	// via the enriched ctx obs.WithLogger attaches it to.
+	testID := ws.nextReportID()
+	runLogger := ws.logger.With(map[string]interface{}{"test_id": testID})
+	ctx = obs.WithLogger(ctx, runLogger)
+	runLogger.Event("test.started")
+
 	duration, err := time.ParseDuration(req.Duration)
 	if err != nil {
+		runLogger.Error("invalid duration", err)
+		runLogger.Event("test.error")
 		ws.mu.Lock()
 		if ws.currentTest != nil {
 			ws.currentTest.Status = "error"
@@ -676,31 +1971,117 @@ func (ws *WebServer) runTest(ctx context.Context, req TestRun) {
 		return
 	}
 
-	gen := generator.New(ws.config, req.Users, duration, req.Ramp, ws.cleanup)
+	gen := generator.New(ctx, ws.config, req.Users, duration, req.Ramp, req.WorkloadProfile, ws.cleanup, 0, 0)
+
+	ws.mu.Lock()
+	ws.currentGenerator = gen
+	ws.mu.Unlock()
+
+	sampleCtx, stopSampling := context.WithCancel(ctx)
+	defer stopSampling()
+	go ws.sampleMetrics(sampleCtx)
+
+	// Attribute every request this run makes to its Labels (see
+	// metrics.SetRunLabels); safe as process-wide state only because at
+	// most one test ever runs at a time (ws.currentTest is a single
+	// pointer, never a set).
+	metrics.SetRunLabels(req.Labels)
+	defer metrics.ClearRunLabels()
+
+	// Snapshot tracked users before the run so an abort (see
+	// handleAbortCurrent) can tell which ones this run added and forget
+	// just those, rather than every user tracked process-wide.
+	before := make(map[string]bool)
+	for _, u := range ws.cleanup.GetTrackedUsers() {
+		before[u] = true
+	}
 
-	startTime := time.Now()
+	startTime := ws.clock.Now()
 	gen.Run(ctx)
-	endTime := time.Now()
+	endTime := ws.clock.Now()
+	stopSampling()
 
 	// Create report
 	ws.mu.Lock()
 	status := "completed"
-	if ws.currentTest != nil && ws.currentTest.Status == "stopped" {
-		status = "stopped"
+	if ws.currentTest != nil {
+		switch ws.currentTest.Status {
+		case "stopped", "draining", "aborted":
+			status = ws.currentTest.Status
+			if status == "draining" {
+				status = "drained"
+			}
+		}
 	}
-
-	report := TestReport{
-		ID:           len(ws.reports) + 1,
-		Users:        req.Users,
-		Duration:     req.Duration,
-		Ramp:         req.Ramp,
-		StartTime:    startTime,
-		EndTime:      endTime,
-		Status:       status,
-		Metrics:      ws.collectMetrics(),
-		TrackedUsers: gen.GetTrackedUsers(),
-	}
-	ws.reports = append(ws.reports, report)
 	ws.currentTest = nil
+	ws.currentGenerator = nil
+	var queued *TestRun
+	if len(ws.queue) > 0 {
+		next := ws.queue[0]
+		ws.queue = ws.queue[1:]
+		queued = &next
+	}
 	ws.mu.Unlock()
+
+	trackedUsers := gen.GetTrackedUsers()
+	if status == "aborted" {
+		// Discard this run's tracked-user state (Loki's
+		// shutdown_and_forget: stop tracking, don't clean up) rather than
+		// reporting or persisting them as cleanup candidates.
+		var added []string
+		for _, u := range trackedUsers {
+			if !before[u] {
+				added = append(added, u)
+			}
+		}
+		ws.cleanup.Forget(added)
+		trackedUsers = nil
+	}
+
+	report := TestReport{
+		ID:              testID,
+		Users:           req.Users,
+		Duration:        req.Duration,
+		Ramp:            req.Ramp,
+		WorkloadProfile: req.WorkloadProfile,
+		StartTime:       startTime,
+		EndTime:         endTime,
+		Status:          status,
+		Metrics:         ws.collectMetrics(ctx),
+		TrackedUsers:    trackedUsers,
+		Series:          ws.metricsTS.Snapshot(),
+		Labels:          req.Labels,
+	}
+	if err := ws.reports.Save(context.Background(), report); err != nil {
+		log.Printf("web: failed to save report %d: %v", report.ID, err)
+	}
+
+	runLogger.With(map[string]interface{}{"status": status, "elapsed_ms": endTime.Sub(startTime).Milliseconds()}).Event("test.completed")
+
+	ws.publish("report", report)
+	ws.publish("status", map[string]string{"status": "stopped"})
+
+	// Drain the next queued run, if any (see enqueueOrStart).
+	if queued != nil {
+		ws.beginTest(*queued)
+	}
+}
+
+// sampleMetrics collects and records one metrics sample per second into
+// ws.metricsTS until ctx is done, so a running test's counters become a
+// chartable time series for /api/query_range (see recordMetricsSample).
+// It also republishes the sample as a "metrics" SSE event.
+func (ws *WebServer) sampleMetrics(ctx context.Context) {
+	for {
+		timer := ws.clock.NewTimer(time.Second)
+		select {
+		case <-timer.C:
+			m := ws.collectMetrics(ctx)
+			ws.recordMetricsSample(m, ws.clock.Now())
+			ws.publish("metrics", m)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
 }
@@ -0,0 +1,24 @@
+// Package loadgenpb will hold the protoc/buf-generated Go types for
+// ../loadgen.proto (LoadgenCoordinatorClient/Server, the AgentMessage/
+// CoordinatorMessage struct hierarchy, etc). This checkout has neither
+// protoc nor buf available to run codegen, so nothing is generated here
+// yet - run the command below once one of those is installed. Until
+// then, internal/coordinator and internal/agent work against plain Go
+// types shaped like the proto messages (coordinator.UserRange,
+// agent.StartTestCommand, ...) so the assignment/aggregation/
+// reconnection logic can be written and reviewed independently of the
+// transport; swapping those types for this package's generated ones is
+// the remaining step once codegen can run.
+//
+// For the same reason there's no cmd/ entrypoint yet to carry a --worker
+// flag: with no generated client/server there's nothing for a worker
+// process to actually dial. internal/agent.Agent.RunTest is written and
+// ready to be driven by one once the transport exists.
+//
+// ../control.proto's LoadgenControl service is in the same boat:
+// internal/control.Controller implements its RPCs' logic against plain
+// Go request/response types, ready to sit behind a generated
+// LoadgenControlServer once buf can run.
+//
+//go:generate buf generate --path ../loadgen.proto --path ../control.proto
+package loadgenpb
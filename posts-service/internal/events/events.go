@@ -0,0 +1,92 @@
+// Package events is posts-service's publishing side of its one
+// integration event so far: image.uploaded, consumed asynchronously by
+// cmd/moderation-worker to run content moderation off the request path.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// ImageUploadedTopic is the topic CreatePost publishes to and
+// cmd/moderation-worker consumes from.
+const ImageUploadedTopic = "image.uploaded"
+
+// ImageUploaded is published once per created post that has an
+// associated image, carrying just enough for a worker to fetch the
+// bytes itself and classify them.
+type ImageUploaded struct {
+	PostID   int    `json:"post_id"`
+	ImageID  string `json:"image_id"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+}
+
+// Publisher publishes ImageUploaded events. Handlers depend on this
+// interface, not *KafkaPublisher directly, so the moderation pipeline
+// can be disabled (Noop) wherever a broker isn't available.
+type Publisher interface {
+	PublishImageUploaded(ctx context.Context, event ImageUploaded) error
+	Close() error
+}
+
+// Noop discards every event - the default when KAFKA_BROKERS isn't set.
+// Posts still get their pending post_moderation row; it just never
+// advances past pending, which is an acceptable degraded mode for local
+// dev and tests rather than a hard dependency on a running broker.
+type Noop struct{}
+
+func (Noop) PublishImageUploaded(ctx context.Context, event ImageUploaded) error { return nil }
+func (Noop) Close() error                                                        { return nil }
+
+// KafkaPublisher publishes ImageUploaded events over a single shared
+// writer.
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher builds a KafkaPublisher against brokers, writing to
+// ImageUploadedTopic.
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  ImageUploadedTopic,
+			Balancer:               &kafka.LeastBytes{},
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+func (p *KafkaPublisher) PublishImageUploaded(ctx context.Context, event ImageUploaded) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.ImageID),
+		Value: payload,
+	})
+}
+
+func (p *KafkaPublisher) Close() error { return p.writer.Close() }
+
+// BrokersFromEnv splits a comma-separated KAFKA_BROKERS value into a
+// broker list; nil when raw is empty so callers can tell "unconfigured"
+// apart from "configured with one broker".
+func BrokersFromEnv(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	brokers := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			brokers = append(brokers, p)
+		}
+	}
+	return brokers
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics is posts-service's in-memory request/business counters, read
+// back out by GetMetrics and the Prometheus registry below.
+type Metrics struct {
+	mu             sync.RWMutex
+	requestsTotal  int64
+	errorsTotal    int64
+	postsCreated   int64
+	postsRetrieved int64
+	likesToggled   int64
+	imagesServed   int64
+	totalLatency   time.Duration
+	startTime      time.Time
+}
+
+// NewMetrics returns a Metrics with its start time set to now.
+func NewMetrics() *Metrics {
+	return &Metrics{startTime: time.Now()}
+}
+
+func (m *Metrics) RecordRequest(d time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requestsTotal++
+	m.totalLatency += d
+	if failed {
+		m.errorsTotal++
+	}
+}
+
+func (m *Metrics) RecordPostCreated() {
+	m.mu.Lock()
+	m.postsCreated++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) RecordPostsRetrieved(n int) {
+	m.mu.Lock()
+	m.postsRetrieved += int64(n)
+	m.mu.Unlock()
+}
+
+func (m *Metrics) RecordLikeToggled() {
+	m.mu.Lock()
+	m.likesToggled++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) RecordImageServed() {
+	m.mu.Lock()
+	m.imagesServed++
+	m.mu.Unlock()
+}
+
+// StartTime returns when this Metrics was created, for uptime gauges.
+func (m *Metrics) StartTime() time.Time {
+	return m.startTime
+}
+
+// snapshot is a consistent read of every counter, taken under RLock.
+type snapshot struct {
+	requestsTotal  int64
+	errorsTotal    int64
+	postsCreated   int64
+	postsRetrieved int64
+	likesToggled   int64
+	imagesServed   int64
+	avgLatencyMs   float64
+	uptime         time.Duration
+}
+
+func (m *Metrics) snapshot() snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var avgLatency float64
+	if m.requestsTotal > 0 {
+		avgLatency = float64(m.totalLatency.Nanoseconds()) / float64(m.requestsTotal) / 1e6
+	}
+
+	return snapshot{
+		requestsTotal:  m.requestsTotal,
+		errorsTotal:    m.errorsTotal,
+		postsCreated:   m.postsCreated,
+		postsRetrieved: m.postsRetrieved,
+		likesToggled:   m.likesToggled,
+		imagesServed:   m.imagesServed,
+		avgLatencyMs:   avgLatency,
+		uptime:         time.Since(m.startTime),
+	}
+}
+
+// PrometheusMetrics holds posts-service's Prometheus collectors. Callers
+// register them once at startup via Register.
+type PrometheusMetrics struct {
+	HTTPRequestsTotal    *prometheus.CounterVec
+	HTTPRequestDuration  *prometheus.HistogramVec
+	ServiceErrorsTotal   *prometheus.CounterVec
+	ServiceUptimeSeconds *prometheus.GaugeVec
+	PostsCreatedTotal    *prometheus.CounterVec
+	LikesTotal           *prometheus.CounterVec
+}
+
+// NewPrometheusMetrics builds posts-service's Prometheus collectors.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		HTTPRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "http_requests_total", Help: "Total number of HTTP requests"},
+			[]string{"method", "endpoint", "status", "service", "version", "instance"},
+		),
+		HTTPRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{Name: "http_request_duration_seconds", Help: "HTTP request duration in seconds", Buckets: prometheus.DefBuckets},
+			[]string{"method", "endpoint", "service", "version", "instance"},
+		),
+		ServiceErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "service_errors_total", Help: "Total number of service errors"},
+			[]string{"service", "version", "instance", "error_type"},
+		),
+		ServiceUptimeSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "service_uptime_seconds", Help: "Service uptime in seconds"},
+			[]string{"service", "version", "instance"},
+		),
+		PostsCreatedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "business_posts_created_total", Help: "Total number of posts created"},
+			[]string{"service", "version", "instance"},
+		),
+		LikesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "business_likes_total", Help: "Total number of likes"},
+			[]string{"service", "version", "instance"},
+		),
+	}
+}
+
+// Register registers every collector against the default registry.
+func (p *PrometheusMetrics) Register() {
+	prometheus.MustRegister(
+		p.HTTPRequestsTotal,
+		p.HTTPRequestDuration,
+		p.ServiceErrorsTotal,
+		p.ServiceUptimeSeconds,
+		p.PostsCreatedTotal,
+		p.LikesTotal,
+	)
+}
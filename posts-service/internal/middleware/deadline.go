@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRequestTimeout bounds how long a request's downstream DB/Redis/
+// Mongo calls are allowed to run when REQUEST_TIMEOUT_SECONDS isn't set.
+const DefaultRequestTimeout = 10 * time.Second
+
+// RequestDeadline attaches a timeout to c.Request's context, so a client
+// disconnect or a slow downstream call doesn't leave a query running
+// forever - every handler that threads c.Request.Context() into its
+// QueryContext/ExecContext/redis *Ctx/Mongo calls inherits the deadline
+// for free.
+func RequestDeadline(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+// Package middleware holds posts-service's request-scoped gin
+// middleware that isn't otherwise tied to the OTel bootstrap (tracing,
+// metrics) living alongside main - auth and CORS, both of which only
+// need a couple of explicit dependencies instead of package-level state.
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth validates the caller's bearer token against userServiceURL
+// (GET /api/users/validate) and sets "user_id"/"username" in the gin
+// context on success. transport is used for the outbound call so it can
+// be wrapped with tracing (e.g. posts-service's tracingRoundTripper);
+// pass nil for http.DefaultTransport.
+func Auth(userServiceURL string, transport http.RoundTripper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.JSON(401, gin.H{"error": "Authorization header required"})
+			c.Abort()
+			return
+		}
+
+		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
+
+		req, _ := http.NewRequestWithContext(c.Request.Context(), "GET", userServiceURL+"/api/users/validate", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+
+		client := &http.Client{Timeout: 5 * time.Second, Transport: transport}
+		resp, err := client.Do(req)
+		if err != nil || resp.StatusCode != 200 {
+			c.JSON(401, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		defer resp.Body.Close()
+
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+
+		// Safe type conversion
+		userID := 0
+		username := ""
+
+		if uid, ok := result["userId"]; ok && uid != nil {
+			if uidFloat, ok := uid.(float64); ok {
+				userID = int(uidFloat)
+			}
+		}
+
+		if uname, ok := result["username"]; ok && uname != nil {
+			if unameStr, ok := uname.(string); ok {
+				username = unameStr
+			}
+		}
+
+		if userID == 0 || username == "" {
+			c.JSON(401, gin.H{"error": "Invalid token data"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Set("username", username)
+		c.Next()
+	}
+}
+
+// CORS allows any origin the same way posts-service always has.
+func CORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,96 @@
+// Package moderation holds the pluggable image classifier
+// cmd/moderation-worker runs against each uploaded image.
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Result is a classifier's verdict for one image.
+type Result struct {
+	Approved bool
+	Labels   json.RawMessage
+	Score    float64
+}
+
+// Moderator classifies image bytes and decides whether the post they
+// belong to should be visible.
+type Moderator interface {
+	Classify(ctx context.Context, data []byte, mimeType string) (Result, error)
+}
+
+// AlwaysAllow approves every image without looking at it - useful for
+// local dev or any deployment that doesn't have a model server to call,
+// so the worker still drains the queue and flips posts to approved.
+type AlwaysAllow struct{}
+
+func (AlwaysAllow) Classify(ctx context.Context, data []byte, mimeType string) (Result, error) {
+	return Result{Approved: true, Score: 0}, nil
+}
+
+// httpModeratorResponse is the model server's expected response shape:
+// a label -> confidence map and an overall nsfw score in [0, 1].
+type httpModeratorResponse struct {
+	Labels json.RawMessage `json:"labels"`
+	Score  float64         `json:"score"`
+}
+
+// HTTPModerator calls an external model server to classify an image.
+// The server is expected to accept the raw image bytes as the request
+// body (Content-Type set to the image's mime type) and respond with
+// httpModeratorResponse; any score at or above Threshold is rejected.
+type HTTPModerator struct {
+	URL       string
+	Threshold float64
+	Client    *http.Client
+}
+
+// NewHTTPModerator builds an HTTPModerator against url with a sane
+// default timeout and rejection threshold.
+func NewHTTPModerator(url string, threshold float64) *HTTPModerator {
+	return &HTTPModerator{
+		URL:       url,
+		Threshold: threshold,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (m *HTTPModerator) Classify(ctx context.Context, data []byte, mimeType string) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", mimeType)
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("moderation model server returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var parsed httpModeratorResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Result{}, fmt.Errorf("decoding moderation model response: %w", err)
+	}
+
+	return Result{
+		Approved: parsed.Score < m.Threshold,
+		Labels:   parsed.Labels,
+		Score:    parsed.Score,
+	}, nil
+}
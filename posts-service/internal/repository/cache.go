@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// defaultPageLimit/maxPageLimit bound a feed page's ?limit= query param.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// ParsePageLimit clamps a parsed ?limit= value to [1, maxPageLimit],
+// defaulting to defaultPageLimit when none was supplied.
+func ParsePageLimit(raw string) int {
+	limit := defaultPageLimit
+	if raw != "" {
+		var n int
+		if _, err := fmt.Sscanf(raw, "%d", &n); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return limit
+}
+
+// cacheStampedeLeaseTTL/cacheStampedeWait/cacheStampedePollInterval tune
+// FeedCache's stampede protection: on a cache miss, the first goroutine
+// to SETNX the lock key rebuilds the value and repopulates the cache;
+// every other goroutine that misses at the same time polls the real
+// cache key briefly instead of also hitting the database, and only falls
+// through to building it itself if the lock holder doesn't finish in time
+// (e.g. it crashed while holding the lease).
+const (
+	cacheStampedeLeaseTTL     = 5 * time.Second
+	cacheStampedeWait         = 500 * time.Millisecond
+	cacheStampedePollInterval = 50 * time.Millisecond
+)
+
+// FeedCache is the Redis cache-aside layer in front of PostRepository.ListFeed.
+type FeedCache struct {
+	redis *redis.Client
+}
+
+// NewFeedCache builds a FeedCache over redisClient.
+func NewFeedCache(redisClient *redis.Client) *FeedCache {
+	return &FeedCache{redis: redisClient}
+}
+
+// GetOrBuild returns the cached bytes at key, rebuilding them via build on
+// a miss. ttl is the rebuilt value's cache lifetime.
+func (c *FeedCache) GetOrBuild(ctx context.Context, key string, ttl time.Duration, build func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+		return []byte(cached), nil
+	}
+
+	lockKey := key + ":lock"
+	acquired, lockErr := c.redis.SetNX(ctx, lockKey, 1, cacheStampedeLeaseTTL).Result()
+	if lockErr == nil && acquired {
+		defer c.redis.Del(ctx, lockKey)
+
+		data, err := build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.redis.Set(ctx, key, data, ttl)
+		return data, nil
+	}
+
+	deadline := time.Now().Add(cacheStampedeWait)
+	for time.Now().Before(deadline) {
+		time.Sleep(cacheStampedePollInterval)
+		if cached, err := c.redis.Get(ctx, key).Result(); err == nil {
+			return []byte(cached), nil
+		}
+	}
+
+	// The lock holder hasn't finished (or died holding the lease) -
+	// build it ourselves rather than blocking this request indefinitely.
+	return build(ctx)
+}
+
+// FeedKey builds the cache key for a feed page: scope is "all" or
+// "user:<username>", rawCursor is the caller's raw (still base64) ?cursor=
+// value.
+func FeedKey(scope string, limit int, rawCursor string) string {
+	return fmt.Sprintf("posts:%s:limit=%d:cursor=%s", scope, limit, rawCursor)
+}
+
+// InvalidateDefaultPages drops the first-page ("all", no cursor, default
+// limit) cache entry and, for each given username, that user's first
+// page - the entries a create/like/delete actually changes the content
+// of. Deeper pages and non-default limits are left to expire via their
+// own TTL rather than tracked individually.
+func (c *FeedCache) InvalidateDefaultPages(ctx context.Context, usernames ...string) {
+	keys := []string{FeedKey("all", defaultPageLimit, "")}
+	for _, username := range usernames {
+		keys = append(keys, FeedKey("user:"+username, defaultPageLimit, ""))
+	}
+	c.redis.Del(ctx, keys...)
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// likesFlushInterval is how often StartFlusher writes Redis's hot like
+// counters back to posts.likes_count. Likes toggled between flushes are
+// only durable in Redis until the next tick or shutdown.
+const likesFlushInterval = 10 * time.Second
+
+// LikeRepository implements posts-service's two-tier like counter:
+// post_likes in Postgres stays the authoritative per-user record, but the
+// hot likes_count value lives in Redis (likes:post:<id>, INCR/DECR) and is
+// only batched back to posts.likes_count periodically, instead of one
+// UPDATE per toggle.
+type LikeRepository struct {
+	db    Querier
+	redis *redis.Client
+
+	dirtyMu  sync.Mutex
+	dirtyIDs map[string]struct{}
+}
+
+// NewLikeRepository builds a LikeRepository over db and redisClient.
+func NewLikeRepository(db Querier, redisClient *redis.Client) *LikeRepository {
+	return &LikeRepository{db: db, redis: redisClient, dirtyIDs: make(map[string]struct{})}
+}
+
+func counterKey(postID string) string { return "likes:post:" + postID }
+
+func markerKey(postID string, userID int) string {
+	return "liked:" + postID + ":" + strconv.Itoa(userID)
+}
+
+func (r *LikeRepository) markDirty(postID string) {
+	r.dirtyMu.Lock()
+	defer r.dirtyMu.Unlock()
+	r.dirtyIDs[postID] = struct{}{}
+}
+
+// drainDirty returns the currently dirty post IDs and resets the set, so
+// a flush pass only retries posts that changed again after it started.
+func (r *LikeRepository) drainDirty() []string {
+	r.dirtyMu.Lock()
+	defer r.dirtyMu.Unlock()
+	ids := make([]string, 0, len(r.dirtyIDs))
+	for id := range r.dirtyIDs {
+		ids = append(ids, id)
+	}
+	r.dirtyIDs = make(map[string]struct{})
+	return ids
+}
+
+// Toggle applies a like/unlike, returning the new liked state. The Redis
+// marker replaces a "SELECT id FROM post_likes" check (so the hot path
+// never hits Postgres to find out whether the user already liked the
+// post), but post_likes itself is still written so it stays the
+// authoritative per-user record other queries (e.g. CountAll) rely on.
+func (r *LikeRepository) Toggle(ctx context.Context, postID string, userID int) (liked bool, err error) {
+	marker := markerKey(postID, userID)
+	counter := counterKey(postID)
+
+	if err := r.seedCounter(ctx, postID, counter); err != nil {
+		return false, err
+	}
+
+	acquired, err := r.redis.SetNX(ctx, marker, 1, 0).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if acquired {
+		if _, err := r.db.ExecContext(ctx, "INSERT INTO post_likes (post_id, user_id) VALUES ($1, $2) ON CONFLICT (post_id, user_id) DO NOTHING", postID, userID); err != nil {
+			r.redis.Del(ctx, marker)
+			return false, err
+		}
+		if err := r.redis.Incr(ctx, counter).Err(); err != nil {
+			return false, err
+		}
+		r.markDirty(postID)
+		return true, nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM post_likes WHERE post_id = $1 AND user_id = $2", postID, userID); err != nil {
+		return false, err
+	}
+	if err := r.redis.Del(ctx, marker).Err(); err != nil {
+		return false, err
+	}
+	if err := r.redis.Decr(ctx, counter).Err(); err != nil {
+		return false, err
+	}
+	r.markDirty(postID)
+	return false, nil
+}
+
+// seedCounter makes sure counter exists in Redis before Toggle's first
+// Incr/Decr against it, seeded from this post's authoritative
+// posts.likes_count baseline via SETNX (so a concurrent seed racing this
+// one, or a toggle that runs before we get the lock, can't stomp it).
+// Without this, any post that already has likes when counter doesn't
+// exist yet - a fresh deploy, a Redis restart/eviction, or simply the
+// post's first toggle since this counter scheme went live - would have
+// its first Incr/Decr create the key starting from 0 instead of the real
+// count, silently corrupting every Count()/flush() read afterward.
+func (r *LikeRepository) seedCounter(ctx context.Context, postID, counter string) error {
+	exists, err := r.redis.Exists(ctx, counter).Result()
+	if err != nil {
+		return err
+	}
+	if exists != 0 {
+		return nil
+	}
+
+	var baseline int
+	if err := r.db.QueryRowContext(ctx, "SELECT likes_count FROM posts WHERE id = $1", postID).Scan(&baseline); err != nil {
+		return err
+	}
+	return r.redis.SetNX(ctx, counter, baseline, 0).Err()
+}
+
+// Count reads the hot counter for postID, falling back to fallback (the
+// value already loaded from posts.likes_count) when Redis has no counter
+// for it yet - e.g. a post nobody has liked since the service started
+// tracking it in Redis.
+func (r *LikeRepository) Count(ctx context.Context, postID string, fallback int) int {
+	val, err := r.redis.Get(ctx, counterKey(postID)).Result()
+	if err != nil {
+		return fallback
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+// CountAll returns the total number of likes across all posts, for the
+// /metrics endpoint - post_likes, not the per-post Redis counters, is
+// still the source of truth here since not every counter has flushed.
+func (r *LikeRepository) CountAll(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM post_likes").Scan(&count)
+	return count, err
+}
+
+// StartFlusher runs a flush pass every likesFlushInterval until ctx is
+// cancelled, then flushes once more so a graceful shutdown doesn't lose
+// whatever counters are still dirty.
+func (r *LikeRepository) StartFlusher(ctx context.Context) {
+	ticker := time.NewTicker(likesFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush(context.Background())
+		case <-ctx.Done():
+			r.flush(context.Background())
+			return
+		}
+	}
+}
+
+// flush writes every dirty post's Redis counter back to
+// posts.likes_count. Failed writes are re-marked dirty so the next tick
+// retries them instead of silently dropping the delta.
+func (r *LikeRepository) flush(ctx context.Context) {
+	for _, postID := range r.drainDirty() {
+		count, err := r.redis.Get(ctx, counterKey(postID)).Result()
+		if err != nil {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			continue
+		}
+		if _, err := r.db.ExecContext(ctx, "UPDATE posts SET likes_count = $1 WHERE id = $2", n, postID); err != nil {
+			log.Printf("Failed to flush like count for post %s: %v", postID, err)
+			r.markDirty(postID)
+		}
+	}
+}
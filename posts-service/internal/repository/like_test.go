@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestLikeRepository(t *testing.T) (*LikeRepository, sqlmock.Sqlmock, *miniredis.Miniredis) {
+	t.Helper()
+
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { mockDB.Close() })
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return NewLikeRepository(mockDB, rdb), mock, mr
+}
+
+// TestLikeRepositoryToggleSeedsCounterFromBaseline covers the bug fixed in
+// seedCounter: liking a post that already has likes_count=7 in Postgres
+// but no likes:post:<id> key yet in Redis (fresh deploy, Redis
+// restart/eviction, or simply this post's first toggle) must INCR from
+// the real baseline, not from an implicit 0.
+func TestLikeRepositoryToggleSeedsCounterFromBaseline(t *testing.T) {
+	repo, mock, mr := newTestLikeRepository(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT likes_count FROM posts WHERE id = \$1`).
+		WithArgs("42").
+		WillReturnRows(sqlmock.NewRows([]string{"likes_count"}).AddRow(7))
+	mock.ExpectExec(`INSERT INTO post_likes`).
+		WithArgs("42", 99).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	liked, err := repo.Toggle(ctx, "42", 99)
+	if err != nil {
+		t.Fatalf("Toggle: %v", err)
+	}
+	if !liked {
+		t.Fatalf("expected liked=true")
+	}
+	if got := repo.Count(ctx, "42", -1); got != 8 {
+		t.Fatalf("expected seeded baseline 7 + 1 = 8, got %d", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+	_ = mr
+}
+
+// TestLikeRepositoryToggleReusesExistingCounter checks that seedCounter
+// is a no-op once counter already exists, so a post with many toggles
+// doesn't re-query Postgres on every one.
+func TestLikeRepositoryToggleReusesExistingCounter(t *testing.T) {
+	repo, mock, mr := newTestLikeRepository(t)
+	ctx := context.Background()
+
+	if err := mr.Set(counterKey("42"), "3"); err != nil {
+		t.Fatalf("seeding miniredis: %v", err)
+	}
+
+	mock.ExpectExec(`INSERT INTO post_likes`).
+		WithArgs("42", 99).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	liked, err := repo.Toggle(ctx, "42", 99)
+	if err != nil {
+		t.Fatalf("Toggle: %v", err)
+	}
+	if !liked {
+		t.Fatalf("expected liked=true")
+	}
+	if got := repo.Count(ctx, "42", -1); got != 4 {
+		t.Fatalf("expected existing counter 3 + 1 = 4 with no baseline query, got %d", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestLikeRepositoryToggleUnlikeSeedsCounterFromBaseline covers the same
+// seeding for the unlike path: Decr must also start from the Postgres
+// baseline rather than an implicit 0 when counter doesn't exist yet.
+func TestLikeRepositoryToggleUnlikeSeedsCounterFromBaseline(t *testing.T) {
+	repo, mock, mr := newTestLikeRepository(t)
+	ctx := context.Background()
+
+	if err := mr.Set(markerKey("42", 99), "1"); err != nil {
+		t.Fatalf("seeding miniredis marker: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT likes_count FROM posts WHERE id = \$1`).
+		WithArgs("42").
+		WillReturnRows(sqlmock.NewRows([]string{"likes_count"}).AddRow(7))
+	mock.ExpectExec(`DELETE FROM post_likes`).
+		WithArgs("42", 99).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	liked, err := repo.Toggle(ctx, "42", 99)
+	if err != nil {
+		t.Fatalf("Toggle: %v", err)
+	}
+	if liked {
+		t.Fatalf("expected liked=false")
+	}
+	if got := repo.Count(ctx, "42", -1); got != 6 {
+		t.Fatalf("expected seeded baseline 7 - 1 = 6, got %d", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
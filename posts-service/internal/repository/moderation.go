@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// ModerationStatus is a post_moderation row's lifecycle state: every post
+// starts pending, and cmd/moderation-worker moves it to approved or
+// rejected once its classifier has run.
+type ModerationStatus string
+
+const (
+	ModerationPending  ModerationStatus = "pending"
+	ModerationApproved ModerationStatus = "approved"
+	ModerationRejected ModerationStatus = "rejected"
+)
+
+// VisibleFeedFilter is ANDed into PostRepository.ListFeed's filterClause
+// by handlers serving unauthenticated readers, so pending/rejected posts
+// never show up in a feed before (or after failing) moderation.
+const VisibleFeedFilter = `id NOT IN (SELECT post_id FROM post_moderation WHERE status IN ('pending', 'rejected'))`
+
+// ModerationResult mirrors a row of the post_moderation table.
+type ModerationResult struct {
+	PostID    int              `json:"post_id"`
+	Status    ModerationStatus `json:"status"`
+	Labels    json.RawMessage  `json:"labels,omitempty"`
+	Score     float64          `json:"score"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// ModerationRepository is the Postgres-backed store behind the
+// post_moderation table.
+type ModerationRepository struct {
+	db Querier
+}
+
+// NewModerationRepository builds a ModerationRepository over db.
+func NewModerationRepository(db Querier) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// CreatePending inserts the initial pending row for a freshly created
+// post. It's a no-op if the row already exists, so it's safe to call
+// even if a retry somehow re-creates the same post id.
+func (r *ModerationRepository) CreatePending(ctx context.Context, postID int) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO post_moderation (post_id, status) VALUES ($1, $2) ON CONFLICT (post_id) DO NOTHING`,
+		postID, ModerationPending)
+	return err
+}
+
+// Get returns postID's current moderation result.
+func (r *ModerationRepository) Get(ctx context.Context, postID int) (ModerationResult, error) {
+	var res ModerationResult
+	var labels []byte
+	err := r.db.QueryRowContext(ctx,
+		`SELECT post_id, status, labels, COALESCE(score, 0), updated_at FROM post_moderation WHERE post_id = $1`,
+		postID,
+	).Scan(&res.PostID, &res.Status, &labels, &res.Score, &res.UpdatedAt)
+	if err != nil {
+		return ModerationResult{}, err
+	}
+	res.Labels = labels
+	return res, nil
+}
+
+// UpdateResult records a classifier's verdict for postID. Only
+// cmd/moderation-worker calls this - the API itself only ever creates
+// the pending row.
+func (r *ModerationRepository) UpdateResult(ctx context.Context, postID int, status ModerationStatus, labels []byte, score float64) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE post_moderation SET status = $2, labels = $3, score = $4, updated_at = CURRENT_TIMESTAMP WHERE post_id = $1`,
+		postID, status, labels, score)
+	return err
+}
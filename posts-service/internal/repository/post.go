@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Post mirrors a row of the posts table.
+type Post struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Username   string    `json:"username" db:"username"`
+	Caption    string    `json:"caption" db:"caption"`
+	ImageURL   string    `json:"image_url" db:"image_url"`
+	ImageID    string    `json:"image_id" db:"image_id"`
+	LikesCount int       `json:"likes_count" db:"likes_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Cursor is the decoded form of a feed endpoint's ?cursor= value: the
+// (created_at, id) of the last row the caller has already seen, used as
+// a keyset predicate so paging stays index-bound instead of degrading
+// with OFFSET on deep pages.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// EncodeCursor builds the opaque ?cursor= value for the row (createdAt,
+// id): base64 so it's URL-safe and the client never has to know its
+// internal "timestamp|id" shape.
+func EncodeCursor(createdAt time.Time, id int) string {
+	raw := fmt.Sprintf("%s|%d", createdAt.Format(time.RFC3339Nano), id)
+	return base64.URLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a ?cursor= value produced by EncodeCursor. An empty
+// raw string is not an error - it just means "first page" - so callers
+// can pass the raw query param straight through.
+func DecodeCursor(raw string) (*Cursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.URLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(decoded), "|", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &Cursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// Page is one page of a keyset-paginated feed query.
+type Page struct {
+	Posts      []Post `json:"posts"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PostRepository is the Postgres-backed store behind the posts table.
+type PostRepository struct {
+	db Querier
+}
+
+// NewPostRepository builds a PostRepository over db.
+func NewPostRepository(db Querier) *PostRepository {
+	return &PostRepository{db: db}
+}
+
+// Create inserts a new post and returns it with its generated id and
+// created_at filled in.
+func (r *PostRepository) Create(ctx context.Context, userID int, username, caption, imageURL, imageID string) (Post, error) {
+	query := `INSERT INTO posts (user_id, username, caption, image_url, image_id)
+			  VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+
+	post := Post{UserID: userID, Username: username, Caption: caption, ImageURL: imageURL, ImageID: imageID}
+	if err := r.db.QueryRowContext(ctx, query, userID, username, caption, imageURL, imageID).Scan(&post.ID, &post.CreatedAt); err != nil {
+		return Post{}, err
+	}
+	return post, nil
+}
+
+// Get returns a single post by id.
+func (r *PostRepository) Get(ctx context.Context, id string) (Post, error) {
+	query := `SELECT id, user_id, username, caption, image_url, image_id, likes_count, created_at, updated_at
+			  FROM posts WHERE id = $1`
+
+	var post Post
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&post.ID, &post.UserID, &post.Username, &post.Caption,
+		&post.ImageURL, &post.ImageID, &post.LikesCount, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		return Post{}, err
+	}
+	return post, nil
+}
+
+// Delete removes a post by id.
+func (r *PostRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM posts WHERE id = $1", id)
+	return err
+}
+
+// CountAll returns the total number of posts, for the /metrics endpoint.
+func (r *PostRepository) CountAll(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM posts").Scan(&count)
+	return count, err
+}
+
+// ListFeed runs a keyset-paginated posts query: filterClause (e.g.
+// "username = $1") is ANDed with the (created_at, id) < (cursor) keyset
+// predicate when cursor is non-nil. filterArgs are filterClause's own
+// placeholder values, numbered from $1; ListFeed appends its own
+// cursor/limit placeholders after them so callers never have to juggle
+// placeholder numbers themselves.
+//
+// It fetches one row past limit to know whether another page follows,
+// trims it back off before returning, and encodes the next cursor from
+// the last row actually returned.
+func (r *PostRepository) ListFeed(ctx context.Context, filterClause string, filterArgs []interface{}, cursor *Cursor, limit int) (Page, error) {
+	args := append([]interface{}{}, filterArgs...)
+	where := ""
+	if filterClause != "" {
+		where = "WHERE " + filterClause
+	}
+
+	if cursor != nil {
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		keysetPredicate := fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+		if where == "" {
+			where = "WHERE " + keysetPredicate
+		} else {
+			where += " AND " + keysetPredicate
+		}
+	}
+
+	args = append(args, limit+1)
+	query := fmt.Sprintf(`SELECT id, user_id, username, caption, image_url, image_id, likes_count, created_at, updated_at
+		FROM posts %s ORDER BY created_at DESC, id DESC LIMIT $%d`, where, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	var posts []Post
+	for rows.Next() {
+		var post Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Username, &post.Caption,
+			&post.ImageURL, &post.ImageID, &post.LikesCount, &post.CreatedAt, &post.UpdatedAt); err != nil {
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	nextCursor := ""
+	if len(posts) > limit {
+		last := posts[limit-1]
+		nextCursor = EncodeCursor(last.CreatedAt, last.ID)
+		posts = posts[:limit]
+	}
+	return Page{Posts: posts, NextCursor: nextCursor}, nil
+}
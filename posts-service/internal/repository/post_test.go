@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestPostRepositoryCreate exercises the RETURNING-clause insert against a
+// mocked driver - this is the table-driven sqlmock unit test the old
+// package-main design couldn't support, since db.QueryRowContext used to
+// be a package-level *tracedDB no test could substitute.
+func TestPostRepositoryCreate(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer mockDB.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO posts`).
+		WithArgs(1, "alice", "hello", "/api/images/img-1", "img-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(42, now))
+
+	repo := NewPostRepository(mockDB)
+	post, err := repo.Create(context.Background(), 1, "alice", "hello", "/api/images/img-1", "img-1")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if post.ID != 42 || post.Username != "alice" {
+		t.Fatalf("unexpected post: %+v", post)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestPostRepositoryListFeedAppliesKeysetAndFilter checks that ListFeed
+// both ANDs the caller's filterClause with the keyset predicate and
+// trims the lookahead row off before computing NextCursor.
+func TestPostRepositoryListFeedAppliesKeysetAndFilter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer mockDB.Close()
+
+	cursorTime := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "username", "caption", "image_url", "image_id", "likes_count", "created_at", "updated_at"}).
+		AddRow(2, 1, "alice", "c2", "/i/2", "2", 0, cursorTime.Add(-time.Hour), cursorTime.Add(-time.Hour)).
+		AddRow(1, 1, "alice", "c1", "/i/1", "1", 0, cursorTime.Add(-2*time.Hour), cursorTime.Add(-2*time.Hour))
+
+	mock.ExpectQuery(`SELECT .* FROM posts WHERE username = \$1 AND \(created_at, id\) < \(\$2, \$3\) ORDER BY created_at DESC, id DESC LIMIT \$4`).
+		WithArgs("alice", cursorTime, 5, 2).
+		WillReturnRows(rows)
+
+	repo := NewPostRepository(mockDB)
+	page, err := repo.ListFeed(context.Background(), "username = $1", []interface{}{"alice"}, &Cursor{CreatedAt: cursorTime, ID: 5}, 1)
+	if err != nil {
+		t.Fatalf("ListFeed: %v", err)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].ID != 2 {
+		t.Fatalf("expected the single trimmed row (id=2), got %+v", page.Posts)
+	}
+	if page.NextCursor == "" {
+		t.Fatalf("expected a next cursor since a second row was fetched")
+	}
+}
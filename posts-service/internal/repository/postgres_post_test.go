@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"posts-service/internal/testhelper"
+)
+
+// TestPostRepositoryAgainstRealPostgres is the integration counterpart to
+// post_test.go's sqlmock unit tests: it runs Create/Get/ListFeed against
+// a real Postgres (via testhelper.NewPostgres) to catch SQL that a mock
+// would happily accept but a real server would reject - bad column
+// types, the row-comparison keyset predicate's actual semantics, etc.
+// Skipped automatically when Docker isn't available to testcontainers.
+func TestPostRepositoryAgainstRealPostgres(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testcontainers-backed test in -short mode")
+	}
+
+	db, cleanup := testhelper.NewPostgres(t)
+	defer cleanup()
+
+	repo := NewPostRepository(db)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, 1, "alice", "hello world", "/api/images/img-1", "img-1")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == 0 {
+		t.Fatalf("expected a generated id")
+	}
+
+	fetched, err := repo.Get(ctx, strconv.Itoa(created.ID))
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if fetched.Username != "alice" || fetched.Caption != "hello world" {
+		t.Fatalf("unexpected post: %+v", fetched)
+	}
+
+	page, err := repo.ListFeed(ctx, "username = $1", []interface{}{"alice"}, nil, 10)
+	if err != nil {
+		t.Fatalf("ListFeed: %v", err)
+	}
+	if len(page.Posts) != 1 || page.Posts[0].ID != created.ID {
+		t.Fatalf("expected feed to contain the created post, got %+v", page.Posts)
+	}
+}
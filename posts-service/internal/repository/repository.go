@@ -0,0 +1,21 @@
+// Package repository holds posts-service's Postgres/Redis data access:
+// PostRepository for the posts table and feed pagination, LikeRepository
+// for the two-tier Redis/Postgres like counter. Handlers depend on these
+// through the Querier interface below rather than a package-level *sql.DB,
+// so tests can swap in sqlmock instead of reaching into global state.
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is the subset of *sql.DB (and posts-service's tracedDB, which
+// wraps it) that repository code needs. Accepting this instead of *sql.DB
+// directly is what makes PostRepository/LikeRepository testable with
+// sqlmock, which only needs to satisfy these three methods.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
@@ -0,0 +1,49 @@
+// Package server wraps the HTTP listen/shutdown lifecycle around an
+// already-assembled gin.Engine, so main only has to build the router
+// (routes + middleware) and hand it off.
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ShutdownTimeout bounds how long Shutdown waits for in-flight requests
+// to finish before giving up.
+const ShutdownTimeout = 10 * time.Second
+
+// Server owns the *http.Server lifecycle for posts-service's router.
+type Server struct {
+	httpServer *http.Server
+}
+
+// New builds a Server listening on addr (e.g. ":8083") and serving
+// handler.
+func New(addr string, handler http.Handler) *Server {
+	return &Server{httpServer: &http.Server{Addr: addr, Handler: handler}}
+}
+
+// Run starts serving in the background and blocks until ctx is
+// cancelled, then gracefully shuts down within ShutdownTimeout.
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Posts service starting on %s", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down posts service...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	return s.httpServer.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,418 @@
+// Package storage holds posts-service's image storage backends behind a
+// single Store interface, selected at startup by IMAGE_STORE
+// (mongo|s3|fs). Handlers depend on the interface, not a concrete
+// backend, so swapping backends never touches handler code.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	awscreds "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ObjectMeta is the metadata Get returns alongside an image's bytes -
+// everything GetImage needs for conditional requests and Range handling
+// without depending on a particular backend's internal document shape.
+type ObjectMeta struct {
+	MimeType     string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+// Store is the storage backend for post images.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, filename, mimeType string) error
+	Get(ctx context.Context, key string) (data []byte, meta ObjectMeta, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// sha256Hex is the strong ETag every backend computes once at upload
+// time and stores alongside the image, so GetImage never has to re-hash
+// the bytes on every read just to answer If-None-Match.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// PresignedUploader is implemented by Store backends that can hand a
+// client a URL to upload an object to directly, bypassing this service
+// for the image bytes themselves. Only S3Store implements it; the
+// upload-url handler 501s against mongo/fs.
+type PresignedUploader interface {
+	PresignPutURL(ctx context.Context, key, mimeType string) (url string, expiresIn time.Duration, err error)
+}
+
+// Config selects and configures the active backend.
+type Config struct {
+	Backend string // "mongo", "fs", or "s3"; defaults to "mongo"
+	FSDir   string
+	S3      S3Config
+}
+
+// BackendFromEnv reads IMAGE_STORE, lower-cased, defaulting to "mongo" -
+// this service's original backend. Callers decide up front whether that
+// means they need to connect to Mongo at all.
+func BackendFromEnv() string {
+	backend := strings.ToLower(os.Getenv("IMAGE_STORE"))
+	if backend == "" {
+		return "mongo"
+	}
+	return backend
+}
+
+// ConfigFromEnv reads every backend's env vars into a Config, so callers
+// can pass it to New without knowing which backend is active.
+func ConfigFromEnv() Config {
+	return Config{
+		Backend: BackendFromEnv(),
+		FSDir:   getEnv("IMAGE_STORE_FS_DIR", "./data/images"),
+		S3:      S3ConfigFromEnv(),
+	}
+}
+
+// New builds the Store selected by cfg.Backend. mongoCollection is only
+// used when cfg.Backend is "mongo" (or empty); pass nil otherwise.
+func New(ctx context.Context, cfg Config, mongoCollection *mongo.Collection) (Store, error) {
+	switch cfg.Backend {
+	case "fs":
+		return NewFSStore(cfg.FSDir)
+	case "s3":
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return NewMongoStore(mongoCollection), nil
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// ---- mongo backend (original behavior) ----
+
+// ImageData is mongoStore's document shape - a single BSON document per
+// image holding both bytes and metadata. ETag is a strong sha256 hash of
+// Data, computed once at upload so GetImage can answer conditional
+// requests without re-reading the bytes.
+type ImageData struct {
+	ID         string    `bson:"_id"`
+	Data       []byte    `bson:"data"`
+	Filename   string    `bson:"filename"`
+	MimeType   string    `bson:"mime_type"`
+	Size       int64     `bson:"size"`
+	ETag       string    `bson:"etag"`
+	UploadedAt time.Time `bson:"uploaded_at"`
+}
+
+// mongoReadTimeout bounds a single image read against Mongo. Images are
+// stored as one BSON document holding the full byte slice, so a large
+// image (or a degraded replica set) can otherwise hold the request's
+// context open well past what the caller is willing to wait; this gives
+// Get its own deadline independent of (and no longer than) whatever's
+// left on the caller's context.
+const mongoReadTimeout = 10 * time.Second
+
+// mongoStore stores image bytes and metadata as a single BSON document
+// per image - posts-service's original image storage behavior.
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore builds a Store backed by collection.
+func NewMongoStore(collection *mongo.Collection) Store {
+	return &mongoStore{collection: collection}
+}
+
+func (s *mongoStore) Put(ctx context.Context, key string, data []byte, filename, mimeType string) error {
+	doc := ImageData{
+		ID:         key,
+		Data:       data,
+		Filename:   filename,
+		MimeType:   mimeType,
+		Size:       int64(len(data)),
+		ETag:       sha256Hex(data),
+		UploadedAt: time.Now(),
+	}
+	_, err := s.collection.InsertOne(ctx, doc)
+	return err
+}
+
+func (s *mongoStore) Get(ctx context.Context, key string) ([]byte, ObjectMeta, error) {
+	ctx, cancel := context.WithTimeout(ctx, mongoReadTimeout)
+	defer cancel()
+
+	var doc ImageData
+	if err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&doc); err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	return doc.Data, ObjectMeta{
+		MimeType:     doc.MimeType,
+		Size:         doc.Size,
+		ETag:         doc.ETag,
+		LastModified: doc.UploadedAt,
+	}, nil
+}
+
+func (s *mongoStore) Delete(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// ---- filesystem backend ----
+
+// fsMeta is fsStore's sidecar metadata file format - the fields
+// ImageData carries alongside the raw bytes, without bson tags since
+// nothing here talks to Mongo.
+type fsMeta struct {
+	Filename   string    `json:"filename"`
+	MimeType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	ETag       string    `json:"etag"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+// fsStore stores each image as two files under dir: <key>.bin (raw
+// bytes) and <key>.json (fsMeta). It exists mainly for local dev and
+// single-node deployments where standing up Mongo or an object store
+// isn't worth it.
+type fsStore struct {
+	dir string
+}
+
+// NewFSStore builds a Store rooted at dir, creating it if necessary.
+func NewFSStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating image store dir %q: %w", dir, err)
+	}
+	return &fsStore{dir: dir}, nil
+}
+
+// sanitizeKey rejects anything that isn't a single path segment, so a key
+// arriving from a URL param (the image handler's :id) can never escape
+// dir via "../" traversal.
+func sanitizeKey(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid image key %q", key)
+	}
+	return key, nil
+}
+
+func (s *fsStore) Put(ctx context.Context, key string, data []byte, filename, mimeType string) error {
+	key, err := sanitizeKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.dataPath(key), data, 0o644); err != nil {
+		return err
+	}
+	meta := fsMeta{
+		Filename:   filename,
+		MimeType:   mimeType,
+		Size:       int64(len(data)),
+		ETag:       sha256Hex(data),
+		UploadedAt: time.Now(),
+	}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.metaPath(key), metaBytes, 0o644)
+}
+
+func (s *fsStore) Get(ctx context.Context, key string) ([]byte, ObjectMeta, error) {
+	key, err := sanitizeKey(key)
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	data, err := os.ReadFile(s.dataPath(key))
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	metaBytes, err := os.ReadFile(s.metaPath(key))
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	var meta fsMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	return data, ObjectMeta{
+		MimeType:     meta.MimeType,
+		Size:         meta.Size,
+		ETag:         meta.ETag,
+		LastModified: meta.UploadedAt,
+	}, nil
+}
+
+func (s *fsStore) Delete(ctx context.Context, key string) error {
+	key, err := sanitizeKey(key)
+	if err != nil {
+		return err
+	}
+	os.Remove(s.metaPath(key))
+	return os.Remove(s.dataPath(key))
+}
+
+func (s *fsStore) dataPath(key string) string { return filepath.Join(s.dir, key+".bin") }
+func (s *fsStore) metaPath(key string) string { return filepath.Join(s.dir, key+".json") }
+
+// ---- S3-compatible backend ----
+
+// S3Config holds everything needed to talk to an S3-compatible bucket -
+// AWS S3 itself, or MinIO/GCS/Aliyun OSS/Tencent COS's S3 compatibility
+// APIs, which all work through the same client with a custom endpoint
+// and path-style addressing.
+type S3Config struct {
+	Bucket       string
+	Endpoint     string
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool
+}
+
+// S3ConfigFromEnv reads IMAGE_STORE_S3_* environment variables. Endpoint
+// is left empty for real AWS S3 (the SDK resolves the regional endpoint
+// itself); set it to point the same client at MinIO or another
+// provider's compatibility endpoint.
+func S3ConfigFromEnv() S3Config {
+	return S3Config{
+		Bucket:       os.Getenv("IMAGE_STORE_S3_BUCKET"),
+		Endpoint:     os.Getenv("IMAGE_STORE_S3_ENDPOINT"),
+		Region:       getEnv("IMAGE_STORE_S3_REGION", "us-east-1"),
+		AccessKey:    os.Getenv("IMAGE_STORE_S3_ACCESS_KEY"),
+		SecretKey:    os.Getenv("IMAGE_STORE_S3_SECRET_KEY"),
+		UsePathStyle: getEnv("IMAGE_STORE_S3_USE_PATH_STYLE", "true") != "false",
+	}
+}
+
+// s3PresignTTL is how long a presigned upload URL stays valid.
+const s3PresignTTL = 15 * time.Minute
+
+// s3Store stores images as objects in cfg.Bucket and is the only backend
+// that supports PresignedUploader, since presigned URLs are an S3-API
+// concept with no Mongo/filesystem equivalent.
+type s3Store struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+// NewS3Store builds a Store backed by cfg's bucket.
+func NewS3Store(ctx context.Context, cfg S3Config) (Store, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("IMAGE_STORE_S3_BUCKET is required when IMAGE_STORE=s3")
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(cfg.Region),
+		awsconfig.WithCredentialsProvider(awscreds.NewStaticCredentialsProvider(cfg.AccessKey, cfg.SecretKey, "")),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+
+	return &s3Store{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  cfg.Bucket,
+	}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, data []byte, filename, mimeType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(mimeType),
+		// S3's own ETag header is MD5-based (and not even that for
+		// multipart uploads), so a sha256 is stored as metadata to keep
+		// the same strong-ETag semantics across every backend.
+		Metadata: map[string]string{"filename": filename, "etag": sha256Hex(data)},
+	})
+	return err
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) ([]byte, ObjectMeta, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, ObjectMeta{}, err
+	}
+
+	mimeType := ""
+	if out.ContentType != nil {
+		mimeType = *out.ContentType
+	}
+	etag := out.Metadata["etag"]
+	if etag == "" {
+		etag = sha256Hex(data)
+	}
+	var lastModified time.Time
+	if out.LastModified != nil {
+		lastModified = *out.LastModified
+	}
+	return data, ObjectMeta{
+		MimeType:     mimeType,
+		Size:         int64(len(data)),
+		ETag:         etag,
+		LastModified: lastModified,
+	}, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// PresignPutURL lets a client upload an image's bytes straight to the
+// bucket, so the API pod never sees them - the bottleneck this backend
+// exists to remove. The client still calls createPost afterwards with
+// the returned key so a Post row can be written.
+func (s *s3Store) PresignPutURL(ctx context.Context, key, mimeType string) (string, time.Duration, error) {
+	req, err := s.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(mimeType),
+	}, s3.WithPresignExpires(s3PresignTTL))
+	if err != nil {
+		return "", 0, err
+	}
+	return req.URL, s3PresignTTL, nil
+}
@@ -0,0 +1,87 @@
+// Package testhelper spins up real backing services for posts-service's
+// integration tests, so repository tests can exercise actual SQL instead
+// of only a mocked driver.
+package testhelper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// schema mirrors main.go's createTables - kept here rather than shared so
+// this package doesn't import posts-service's main package.
+const schema = `
+CREATE TABLE IF NOT EXISTS posts (
+	id SERIAL PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	username VARCHAR(255) NOT NULL,
+	caption TEXT,
+	image_url VARCHAR(500),
+	image_id VARCHAR(255),
+	likes_count INTEGER DEFAULT 0,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS post_likes (
+	id SERIAL PRIMARY KEY,
+	post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE(post_id, user_id)
+);`
+
+// NewPostgres starts a disposable Postgres container, applies posts-
+// service's schema, and returns an open *sql.DB plus a cleanup func that
+// terminates the container. Callers should `defer cleanup()`.
+func NewPostgres(t *testing.T) (*sql.DB, func()) {
+	t.Helper()
+
+	// Skip (not fail) when there's no healthy Docker/Podman provider to
+	// run the container against, so go test ./... stays green in CI/dev
+	// environments without Docker instead of hard-failing with a
+	// container-runtime error unrelated to posts-service's own code.
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	container, err := postgres.Run(ctx, "postgres:16-alpine",
+		postgres.WithDatabase("postsdb_test"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("password"),
+		testcontainers.WithWaitStrategy(wait.ForListeningPort("5432/tcp")),
+	)
+	if err != nil {
+		t.Fatalf("starting postgres container: %v", err)
+	}
+
+	connStr, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("getting connection string: %v", err)
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		t.Fatalf("applying schema: %v", err)
+	}
+
+	cleanup := func() {
+		db.Close()
+		if err := container.Terminate(context.Background()); err != nil {
+			fmt.Printf("terminating postgres container: %v\n", err)
+		}
+	}
+	return db, cleanup
+}
@@ -0,0 +1,75 @@
+// Package thumbnail resizes a source image into the fixed set of
+// bounding-box sizes GetImage's ?size= query parameter supports.
+package thumbnail
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Size is one of the supported ?size= query values.
+type Size string
+
+const (
+	Thumb  Size = "thumb"
+	Small  Size = "small"
+	Medium Size = "medium"
+)
+
+// bounds maps each Size to the max width/height a generated thumbnail
+// fits within, aspect ratio preserved.
+var bounds = map[Size]int{
+	Thumb:  150,
+	Small:  320,
+	Medium: 640,
+}
+
+// Valid reports whether size is one of the supported ?size= values.
+func Valid(size string) bool {
+	_, ok := bounds[Size(size)]
+	return ok
+}
+
+// Generate decodes data as an image and scales it to fit within size's
+// bounding box (images already smaller than the bound are left at their
+// original dimensions), returning JPEG-encoded bytes - always JPEG
+// regardless of source format, since it's the one format every client
+// can be relied on to decode.
+func Generate(data []byte, size string) ([]byte, error) {
+	maxDim, ok := bounds[Size(size)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported thumbnail size %q", size)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding source image: %w", err)
+	}
+
+	srcBounds := src.Bounds()
+	w, h := srcBounds.Dx(), srcBounds.Dy()
+	if w > maxDim || h > maxDim {
+		if w >= h {
+			h = h * maxDim / w
+			w = maxDim
+		} else {
+			w = w * maxDim / h
+			h = maxDim
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcBounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("encoding thumbnail: %w", err)
+	}
+	return buf.Bytes(), nil
+}
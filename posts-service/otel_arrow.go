@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// arrowHandshakeTimeout bounds how long newArrowExporter waits to find out
+// whether endpoint speaks the OTel-Arrow columnar stream service before
+// giving up and falling back to plain OTLP.
+const arrowHandshakeTimeout = 3 * time.Second
+
+// arrowStreamMethod is the OTel-Arrow collector's streaming RPC (external
+// docs 4, 6): a bidirectional stream of Arrow record batches, one per
+// signal. Probing it with grpc.NewStream lets newArrowExporter tell a
+// collector built with the Arrow receiver apart from an ordinary OTLP
+// collector (which answers with Unimplemented) without needing the actual
+// otel-arrow Go module.
+const arrowStreamMethod = "/opentelemetry.proto.experimental.arrow.v1.ArrowTracesService/ArrowTraces"
+
+// arrowExporter is a sdktrace.SpanExporter that batches spans into Arrow
+// record batches and streams them over a persistent bidirectional gRPC
+// connection instead of one protobuf message per batch, so CPU spent on
+// serialization stays flat as span volume grows (plain protobuf OTLP
+// re-marshals every field of every span on every batch).
+//
+// The real columnar encoder lives in the separate
+// github.com/open-telemetry/otel-arrow module; it isn't vendored in this
+// checkout (no go.mod, no network access to fetch it here), so this type
+// can't actually produce Arrow record batches yet. What it does do
+// honestly: open a gRPC connection to cfg.endpoint and probe
+// arrowStreamMethod once at startup (arrowHandshake). If the collector
+// answers Unimplemented - true of every collector this repo has ever
+// pointed at, including the default jaeger:4317 - ExportSpans falls back
+// to a plain otlptracegrpc.Exporter so tracing keeps working unchanged.
+// Swapping in the real encoder is then a matter of replacing the
+// fallback-only success path below with an otel-arrow-backed producer.
+type arrowExporter struct {
+	conn     *grpc.ClientConn
+	fallback sdktrace.SpanExporter
+}
+
+// newArrowCapableExporter builds the exporter used for
+// OTEL_EXPORTER_OTLP_PROTOCOL=arrow: it tries the Arrow handshake against
+// cfg.endpoint and, on any failure (unreachable endpoint, TLS mismatch, or
+// - the common case - a collector that doesn't speak Arrow), falls back to
+// the same gRPC OTLP exporter every other protocol value uses.
+func newArrowCapableExporter(ctx context.Context, cfg otelConfig) (sdktrace.SpanExporter, error) {
+	fallback, err := newOTLPExporter(ctx, "grpc", cfg.endpoint, cfg.insecure, cfg.headers)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, handshakeErr := arrowHandshake(ctx, cfg)
+	if handshakeErr != nil {
+		log.Printf("OTel-Arrow handshake with %s failed, falling back to standard OTLP: %v", cfg.endpoint, handshakeErr)
+		return fallback, nil
+	}
+
+	log.Printf("OTel-Arrow handshake with %s succeeded; columnar encoder isn't vendored in this checkout, exporting as standard OTLP until it is", cfg.endpoint)
+	return &arrowExporter{conn: conn, fallback: fallback}, nil
+}
+
+// arrowHandshake dials endpoint and opens (then immediately closes) a
+// stream on arrowStreamMethod, returning the open connection if the
+// collector accepts it or an error - including grpc's Unimplemented, the
+// expected response from a non-Arrow collector - otherwise.
+func arrowHandshake(ctx context.Context, cfg otelConfig) (*grpc.ClientConn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, arrowHandshakeTimeout)
+	defer cancel()
+
+	var dialOpts []grpc.DialOption
+	if cfg.insecure {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	dialOpts = append(dialOpts, grpc.WithBlock())
+
+	conn, err := grpc.DialContext(dialCtx, cfg.endpoint, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := conn.NewStream(dialCtx, &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}, arrowStreamMethod)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_ = stream.CloseSend()
+
+	return conn, nil
+}
+
+// ExportSpans delegates to a.fallback; see the arrowExporter doc comment
+// for why no Arrow-native encoding path exists yet.
+func (a *arrowExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return a.fallback.ExportSpans(ctx, spans)
+}
+
+// Shutdown closes both the probed Arrow stream connection and the
+// fallback exporter.
+func (a *arrowExporter) Shutdown(ctx context.Context) error {
+	if a.conn != nil {
+		a.conn.Close()
+	}
+	return a.fallback.Shutdown(ctx)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// QueryHook starts a child span around every query run through a
+// tracedDB, mirroring bunotel.NewQueryHook's BeforeQuery/AfterQuery shape
+// (external doc 2) adapted to database/sql's *Context methods instead of
+// bun's query-builder hook points.
+type QueryHook struct {
+	tracer trace.Tracer
+	system string
+}
+
+// newQueryHook returns a QueryHook that starts spans on tracer. This
+// service's only database/sql driver is lib/pq, so system is always
+// "postgresql"; a second driver would need its own QueryHook value.
+func newQueryHook(tracer trace.Tracer) *QueryHook {
+	return &QueryHook{tracer: tracer, system: "postgresql"}
+}
+
+// BeforeQuery starts a span as a child of ctx's current span (the
+// tracingMiddleware span, for any call made with a request's context) and
+// returns ctx carrying it. args is only used to count bind parameters -
+// every query in this service is already parameterized ($1, $2, ...), so
+// db.statement is just the query text with no redaction needed beyond
+// never attaching args themselves as attributes.
+func (h *QueryHook) BeforeQuery(ctx context.Context, query string, args []interface{}) (context.Context, trace.Span) {
+	if h == nil || h.tracer == nil {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+
+	op := queryOperation(query)
+	ctx, span := h.tracer.Start(ctx, "db."+op)
+	span.SetAttributes(
+		attribute.String("db.system", h.system),
+		attribute.String("db.statement", query),
+		attribute.String("db.operation", op),
+		attribute.Int("db.args.count", len(args)),
+	)
+	return ctx, span
+}
+
+// AfterQuery ends span, marking it as errored when err is non-nil.
+// sql.ErrNoRows is the expected "not found" outcome for a single-row
+// lookup, not a query failure, so it's recorded but not treated as an
+// error status.
+func (h *QueryHook) AfterQuery(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	defer span.End()
+
+	if err == nil {
+		return
+	}
+	if err == sql.ErrNoRows {
+		span.SetAttributes(attribute.Bool("db.no_rows", true))
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// queryOperation returns query's leading keyword upper-cased (SELECT,
+// INSERT, UPDATE, DELETE, ...), the value OTel's db.operation semantic
+// convention expects.
+func queryOperation(query string) string {
+	trimmed := strings.TrimSpace(query)
+	if i := strings.IndexAny(trimmed, " \n\t"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	return strings.ToUpper(trimmed)
+}
+
+// tracedDB wraps a *sql.DB so every query issued through its *Context
+// methods gets a child span via hook, without handlers having to call
+// createSpan themselves. Every other *sql.DB method (Stats, Close, ...)
+// is promoted unchanged through the embedded field.
+type tracedDB struct {
+	*sql.DB
+	hook *QueryHook
+}
+
+// newTracedDB wraps db, starting spans via tracer for every query run
+// through the result.
+func newTracedDB(db *sql.DB, tracer trace.Tracer) *tracedDB {
+	return &tracedDB{DB: db, hook: newQueryHook(tracer)}
+}
+
+// QueryContext shadows *sql.DB.QueryContext to wrap it in a child span.
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.hook.BeforeQuery(ctx, query, args)
+	rows, err := t.DB.QueryContext(ctx, query, args...)
+	t.hook.AfterQuery(span, err)
+	return rows, err
+}
+
+// QueryRowContext shadows *sql.DB.QueryRowContext to wrap it in a child
+// span. The row's deferred error (if any) is only known once Scan is
+// called, so the span records whatever QueryRowContext itself returns -
+// driver-level failures only, same as sql.DB.QueryRow's own contract.
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.hook.BeforeQuery(ctx, query, args)
+	row := t.DB.QueryRowContext(ctx, query, args...)
+	t.hook.AfterQuery(span, row.Err())
+	return row
+}
+
+// ExecContext shadows *sql.DB.ExecContext to wrap it in a child span.
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.hook.BeforeQuery(ctx, query, args)
+	res, err := t.DB.ExecContext(ctx, query, args...)
+	t.hook.AfterQuery(span, err)
+	return res, err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// capturedRequestHeaders and capturedResponseHeaders are the lower-cased
+// header names whitelisted by OTEL_INSTRUMENTATION_HTTP_CAPTURED_REQUEST_HEADERS
+// / _RESPONSE_HEADERS. Only headers named here are ever attached to a
+// span - Authorization/Cookie included - so an operator has to opt in
+// explicitly rather than leaking them by default.
+var (
+	capturedRequestHeaders  []string
+	capturedResponseHeaders []string
+)
+
+// loadCapturedHeaders parses the OTEL_INSTRUMENTATION_HTTP_CAPTURED_*_HEADERS
+// env vars (comma-separated header names) into capturedRequestHeaders /
+// capturedResponseHeaders. Called once from initTracing.
+func loadCapturedHeaders() {
+	capturedRequestHeaders = parseHeaderList(os.Getenv("OTEL_INSTRUMENTATION_HTTP_CAPTURED_REQUEST_HEADERS"))
+	capturedResponseHeaders = parseHeaderList(os.Getenv("OTEL_INSTRUMENTATION_HTTP_CAPTURED_RESPONSE_HEADERS"))
+}
+
+// parseHeaderList splits a comma-separated header-name list, trimming
+// whitespace and lower-casing each name for case-insensitive matching.
+func parseHeaderList(raw string) []string {
+	if raw == "" {
+		return nil
+	}
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// applyCapturedRequestHeaders attaches each whitelisted request header
+// present in h to span as an http.request.header.<lower_name> attribute.
+// http.Header.Values canonicalizes the key, so matching is case-insensitive,
+// and a repeated header becomes a string-slice attribute value.
+func applyCapturedRequestHeaders(span trace.Span, h http.Header) {
+	for _, name := range capturedRequestHeaders {
+		if values := h.Values(name); len(values) > 0 {
+			span.SetAttributes(attribute.StringSlice("http.request.header."+name, values))
+		}
+	}
+}
+
+// applyCapturedResponseHeaders is applyCapturedRequestHeaders' response-side
+// counterpart, using the http.response.header.<lower_name> attribute name.
+func applyCapturedResponseHeaders(span trace.Span, h http.Header) {
+	for _, name := range capturedResponseHeaders {
+		if values := h.Values(name); len(values) > 0 {
+			span.SetAttributes(attribute.StringSlice("http.response.header."+name, values))
+		}
+	}
+}
+
+// capturedHeadersMiddleware is tracingMiddleware's companion: it attaches
+// the configured request/response header whitelists to the span that
+// tracingMiddleware already started on this request's context, so it
+// must be registered after tracingMiddleware in main's r.Use chain.
+func capturedHeadersMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		span := trace.SpanFromContext(c.Request.Context())
+		applyCapturedRequestHeaders(span, c.Request.Header)
+
+		c.Next()
+
+		applyCapturedResponseHeaders(span, c.Writer.Header())
+	}
+}
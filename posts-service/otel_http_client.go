@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingRoundTripper wraps an http.RoundTripper so every outbound
+// request started with a traced context (req.Context() carrying a span,
+// e.g. one built from c.Request.Context() in a gin handler) gets its own
+// client span as a child of that context, with the span's trace/span IDs
+// propagated to the callee via the standard W3C traceparent header. This
+// is how the service's one outbound call - authMiddleware validating a
+// token against user-service - gets linked into the same trace as the
+// inbound request that triggered it.
+type tracingRoundTripper struct {
+	tracer trace.Tracer
+	next   http.RoundTripper
+}
+
+// newTracingRoundTripper wraps next (http.DefaultTransport if nil) to
+// start client spans via tracer.
+func newTracingRoundTripper(tracer trace.Tracer, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &tracingRoundTripper{tracer: tracer, next: next}
+}
+
+// RoundTrip starts a client span, injects it into req's headers as W3C
+// traceparent, runs the underlying transport, and records the response
+// status (or transport error) before returning.
+func (t *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.tracer == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL.String()),
+		attribute.String("server.address", req.URL.Hostname()),
+	)
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -3,9 +3,15 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
@@ -13,53 +19,161 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// otelShutdownTimeout bounds how long main waits for buffered spans to
+// flush to the exporter on shutdown.
+const otelShutdownTimeout = 5 * time.Second
+
 var tracer trace.Tracer
 
-func initTracing() {
+// otelConfig holds the exporter/resource settings shared by every OTel
+// signal this service emits (traces in this file, metrics in
+// otel_metrics_init.go), so both read the same endpoint/protocol/headers
+// instead of drifting out of sync.
+type otelConfig struct {
+	endpoint       string
+	protocol       string
+	insecure       bool
+	headers        map[string]string
+	serviceName    string
+	serviceVersion string
+	samplerRatio   float64
+}
+
+// otelConfigFromEnv reads the OTEL_EXPORTER_OTLP_* / OTEL_SERVICE_* /
+// OTEL_TRACES_SAMPLER_ARG environment variables, falling back to the
+// jaeger:4317 gRPC defaults this service has always shipped with.
+func otelConfigFromEnv() otelConfig {
+	cfg := otelConfig{
+		endpoint:       "jaeger:4317",
+		protocol:       "grpc",
+		insecure:       true,
+		serviceName:    "posts-service",
+		serviceVersion: "1.0.0",
+		samplerRatio:   1.0,
+	}
+
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); v != "" {
+		cfg.endpoint = v
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); v != "" {
+		cfg.protocol = v
+	}
+	if v := os.Getenv("OTEL_EXPORTER_OTLP_INSECURE"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.insecure = b
+		}
+	}
+	cfg.headers = parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if v := os.Getenv("OTEL_SERVICE_NAME"); v != "" {
+		cfg.serviceName = v
+	}
+	if v := os.Getenv("OTEL_SERVICE_VERSION"); v != "" {
+		cfg.serviceVersion = v
+	}
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.samplerRatio = f
+		}
+	}
+
+	return cfg
+}
+
+// initTracing configures the global TracerProvider from otelConfigFromEnv.
+// It returns a shutdown func that main should defer (with
+// otelShutdownTimeout) to flush in-flight spans before exit; if
+// initialization fails, the returned shutdown is a no-op and getTracer
+// falls back to a no-op tracer.
+func initTracing() (shutdown func(context.Context) error) {
+	shutdown = func(context.Context) error { return nil }
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Failed to initialize tracing: %v", r)
+			shutdown = func(context.Context) error { return nil }
 		}
 	}()
 
 	ctx := context.Background()
+	cfg := otelConfigFromEnv()
 
-	// Create OTLP exporter
-	exporter, err := otlptracegrpc.New(ctx,
-		otlptracegrpc.WithEndpoint("jaeger:4317"),
-		otlptracegrpc.WithInsecure(),
-	)
+	var exporter sdktrace.SpanExporter
+	var err error
+	if cfg.protocol == "arrow" {
+		exporter, err = newArrowCapableExporter(ctx, cfg)
+	} else {
+		exporter, err = newOTLPExporter(ctx, cfg.protocol, cfg.endpoint, cfg.insecure, cfg.headers)
+	}
 	if err != nil {
 		log.Printf("Failed to create OTLP exporter: %v", err)
-		return
+		return shutdown
 	}
 
-	// Create resource
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
-			semconv.ServiceName("posts-service"),
-			semconv.ServiceVersion("1.0.0"),
+			semconv.ServiceName(cfg.serviceName),
+			semconv.ServiceVersion(cfg.serviceVersion),
 		),
 	)
 	if err != nil {
 		log.Printf("Failed to create resource: %v", err)
-		return
+		return shutdown
 	}
 
-	// Create tracer provider
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.samplerRatio))),
 	)
 
-	// Set global tracer provider
 	otel.SetTracerProvider(tp)
 	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracer = otel.Tracer(cfg.serviceName)
+	loadCapturedHeaders()
+
+	log.Printf("OpenTelemetry initialized successfully (endpoint=%s protocol=%s)", cfg.endpoint, cfg.protocol)
+	return tp.Shutdown
+}
 
-	// Get tracer
-	tracer = otel.Tracer("posts-service")
+// newOTLPExporter builds a gRPC (the default) or HTTP/protobuf OTLP
+// trace exporter depending on protocol, so the same binary can target
+// Tempo, an OTel Collector, or a SaaS backend without recompiling.
+func newOTLPExporter(ctx context.Context, protocol, endpoint string, insecure bool, headers map[string]string) (sdktrace.SpanExporter, error) {
+	if protocol == "http/protobuf" || protocol == "http" {
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(headers))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	}
 
-	log.Println("OpenTelemetry initialized successfully")
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	if len(headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(headers))
+	}
+	return otlptracegrpc.New(ctx, opts...)
+}
+
+// parseOTLPHeaders parses the standard OTEL_EXPORTER_OTLP_HEADERS
+// format: comma-separated key=value pairs, e.g. "api-key=secret,x-env=prod".
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers
 }
 
 func getTracer() trace.Tracer {
@@ -69,7 +183,12 @@ func getTracer() trace.Tracer {
 	return tracer
 }
 
-func createSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+// createSpan starts a span named name. If req is non-nil, any headers
+// whitelisted via OTEL_INSTRUMENTATION_HTTP_CAPTURED_REQUEST_HEADERS are
+// attached to it immediately (see applyCapturedRequestHeaders); response
+// headers aren't known yet at creation time, so capturing those is
+// capturedHeadersMiddleware's job once the handler has written a response.
+func createSpan(ctx context.Context, name string, req *http.Request) (context.Context, trace.Span) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Printf("Failed to create span: %v", r)
@@ -80,5 +199,9 @@ func createSpan(ctx context.Context, name string) (context.Context, trace.Span)
 		return ctx, trace.SpanFromContext(ctx)
 	}
 
-	return tracer.Start(ctx, name)
+	ctx, span := tracer.Start(ctx, name)
+	if req != nil {
+		applyCapturedRequestHeaders(span, req.Header)
+	}
+	return ctx, span
 }
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+var (
+	httpServerRequestDuration  metric.Float64Histogram
+	httpServerActiveRequests   metric.Int64UpDownCounter
+	httpServerRequestBodySize  metric.Int64Histogram
+	httpServerResponseBodySize metric.Int64Histogram
+)
+
+// initMetrics configures the global MeterProvider on the same OTLP
+// endpoint/protocol/headers as initTracing (see otelConfigFromEnv) and
+// registers the stable OTel HTTP server semantic-convention instruments
+// recorded by otelHTTPMetricsMiddleware. It returns a shutdown func that
+// main should defer (with otelShutdownTimeout); if initialization fails,
+// the instruments stay nil and otelHTTPMetricsMiddleware is a no-op.
+func initMetrics() (shutdown func(context.Context) error) {
+	shutdown = func(context.Context) error { return nil }
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Failed to initialize OTel metrics: %v", r)
+			shutdown = func(context.Context) error { return nil }
+		}
+	}()
+
+	ctx := context.Background()
+	cfg := otelConfigFromEnv()
+
+	exporter, err := newOTLPMetricExporter(ctx, cfg)
+	if err != nil {
+		log.Printf("Failed to create OTLP metric exporter: %v", err)
+		return shutdown
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.serviceName),
+			semconv.ServiceVersion(cfg.serviceVersion),
+		),
+	)
+	if err != nil {
+		log.Printf("Failed to create resource: %v", err)
+		return shutdown
+	}
+
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+	)
+	otel.SetMeterProvider(mp)
+
+	if err := registerHTTPServerInstruments(mp.Meter(cfg.serviceName)); err != nil {
+		log.Printf("Failed to create HTTP server instruments: %v", err)
+		return mp.Shutdown
+	}
+
+	log.Printf("OTel HTTP server metrics initialized successfully (endpoint=%s protocol=%s)", cfg.endpoint, cfg.protocol)
+	return mp.Shutdown
+}
+
+// registerHTTPServerInstruments creates the four stable HTTP server
+// semantic-convention instruments from m, assigning them to the package
+// vars otelHTTPMetricsMiddleware records against. Split out from
+// initMetrics so tests can register instruments against a ManualReader
+// without dialing a real OTLP endpoint.
+func registerHTTPServerInstruments(m metric.Meter) error {
+	var err error
+
+	httpServerRequestDuration, err = m.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests"),
+	)
+	if err != nil {
+		return err
+	}
+
+	httpServerActiveRequests, err = m.Int64UpDownCounter(
+		"http.server.active_requests",
+		metric.WithUnit("{request}"),
+		metric.WithDescription("Number of in-flight HTTP server requests"),
+	)
+	if err != nil {
+		return err
+	}
+
+	httpServerRequestBodySize, err = m.Int64Histogram(
+		"http.server.request.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server request bodies"),
+	)
+	if err != nil {
+		return err
+	}
+
+	httpServerResponseBodySize, err = m.Int64Histogram(
+		"http.server.response.body.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of HTTP server response bodies"),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newOTLPMetricExporter builds a gRPC (the default) or HTTP/protobuf OTLP
+// metric exporter depending on cfg.protocol, mirroring newOTLPExporter's
+// trace-exporter branching.
+func newOTLPMetricExporter(ctx context.Context, cfg otelConfig) (sdkmetric.Exporter, error) {
+	if cfg.protocol == "http/protobuf" || cfg.protocol == "http" {
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(cfg.endpoint)}
+		if cfg.insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		}
+		if len(cfg.headers) > 0 {
+			opts = append(opts, otlpmetrichttp.WithHeaders(cfg.headers))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	}
+
+	opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(cfg.endpoint)}
+	if cfg.insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if len(cfg.headers) > 0 {
+		opts = append(opts, otlpmetricgrpc.WithHeaders(cfg.headers))
+	}
+	return otlpmetricgrpc.New(ctx, opts...)
+}
+
+// otelHTTPMetricsMiddleware records the stable OTel HTTP server
+// semantic-convention metrics for every request: request duration,
+// in-flight count, and request/response body sizes, each tagged with
+// the semconv attribute set (http.request.method, http.route,
+// http.response.status_code, network.protocol.name/version,
+// server.address, server.port, url.scheme). A no-op until initMetrics
+// has successfully registered the instruments.
+func otelHTTPMetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if httpServerRequestDuration == nil {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("network.protocol.name", "http"),
+			attribute.String("network.protocol.version", protocolVersion(c.Request.Proto)),
+			attribute.String("server.address", serverAddress(c.Request)),
+			attribute.Int("server.port", serverPort(c.Request)),
+			attribute.String("url.scheme", urlScheme(c.Request)),
+		}
+		activeOpt := metric.WithAttributes(attrs...)
+
+		httpServerActiveRequests.Add(ctx, 1, activeOpt)
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start)
+		httpServerActiveRequests.Add(ctx, -1, activeOpt)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		finalOpt := metric.WithAttributes(append(attrs,
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", c.Writer.Status()),
+		)...)
+
+		requestBodySize := c.Request.ContentLength
+		if requestBodySize < 0 {
+			requestBodySize = 0
+		}
+
+		httpServerRequestDuration.Record(ctx, duration.Seconds(), finalOpt)
+		httpServerRequestBodySize.Record(ctx, requestBodySize, finalOpt)
+		httpServerResponseBodySize.Record(ctx, int64(c.Writer.Size()), finalOpt)
+	}
+}
+
+// protocolVersion extracts "1.1" from a Go request proto string like
+// "HTTP/1.1", matching the network.protocol.version semconv attribute.
+func protocolVersion(proto string) string {
+	if i := strings.Index(proto, "/"); i >= 0 {
+		return proto[i+1:]
+	}
+	return proto
+}
+
+// serverAddress returns the request's Host header without its port, for
+// the server.address semconv attribute.
+func serverAddress(r *http.Request) string {
+	host := r.Host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		return host[:i]
+	}
+	return host
+}
+
+// serverPort returns the request's destination port for the server.port
+// semconv attribute, falling back to the scheme's default port when the
+// Host header doesn't include one.
+func serverPort(r *http.Request) int {
+	host := r.Host
+	if i := strings.LastIndex(host, ":"); i >= 0 {
+		if p, err := strconv.Atoi(host[i+1:]); err == nil {
+			return p
+		}
+	}
+	if r.TLS != nil {
+		return 443
+	}
+	return 80
+}
+
+// urlScheme returns "https" or "http" for the url.scheme semconv attribute.
+func urlScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
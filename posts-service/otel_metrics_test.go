@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// setupTestMetrics registers the HTTP server instruments against a
+// ManualReader so tests can collect recorded data points without dialing
+// a real OTLP endpoint.
+func setupTestMetrics(t *testing.T) *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	if err := registerHTTPServerInstruments(mp.Meter("posts-service-test")); err != nil {
+		t.Fatalf("registering HTTP server instruments: %v", err)
+	}
+	return reader
+}
+
+// attributesFor returns every attribute.Set recorded for metricName.
+func attributesFor(t *testing.T, reader *sdkmetric.ManualReader, metricName string) []attribute.Set {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collecting metrics: %v", err)
+	}
+
+	var sets []attribute.Set
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != metricName {
+				continue
+			}
+			switch data := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					sets = append(sets, dp.Attributes)
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					sets = append(sets, dp.Attributes)
+				}
+			}
+		}
+	}
+	return sets
+}
+
+func TestOtelHTTPMetricsMiddlewareStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name   string
+		status int
+	}{
+		{"2xx", http.StatusOK},
+		{"4xx", http.StatusBadRequest},
+		{"5xx", http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := setupTestMetrics(t)
+
+			r := gin.New()
+			r.Use(otelHTTPMetricsMiddleware())
+			r.GET("/api/posts", func(c *gin.Context) {
+				c.Status(tc.status)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			sets := attributesFor(t, reader, "http.server.request.duration")
+			if len(sets) != 1 {
+				t.Fatalf("expected 1 recorded data point, got %d", len(sets))
+			}
+
+			status, ok := sets[0].Value(attribute.Key("http.response.status_code"))
+			if !ok || status.AsInt64() != int64(tc.status) {
+				t.Errorf("expected http.response.status_code=%d, got %v (present=%v)", tc.status, status, ok)
+			}
+
+			route, ok := sets[0].Value(attribute.Key("http.route"))
+			if !ok || route.AsString() != "/api/posts" {
+				t.Errorf("expected http.route=/api/posts, got %v (present=%v)", route, ok)
+			}
+
+			method, ok := sets[0].Value(attribute.Key("http.request.method"))
+			if !ok || method.AsString() != http.MethodGet {
+				t.Errorf("expected http.request.method=GET, got %v (present=%v)", method, ok)
+			}
+		})
+	}
+}
+
+func TestOtelHTTPMetricsMiddlewareActiveRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reader := setupTestMetrics(t)
+
+	r := gin.New()
+	r.Use(otelHTTPMetricsMiddleware())
+	r.GET("/api/posts", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("collecting metrics: %v", err)
+	}
+
+	var found bool
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "http.server.active_requests" {
+				continue
+			}
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				continue
+			}
+			for _, dp := range sum.DataPoints {
+				found = true
+				// incremented before the handler and decremented after,
+				// so the net change after a completed request is 0
+				if dp.Value != 0 {
+					t.Errorf("expected active_requests net value 0 after completed request, got %d", dp.Value)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected a recorded http.server.active_requests data point")
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+
+	"posts-service/internal/handlers"
+)
+
+// tracingMiddleware starts a span for every request, extracting any
+// upstream trace context from the request headers first so posts-service
+// participates in whatever trace the caller already started.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Tracing middleware panic: %v", r)
+			}
+		}()
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		tracer := getTracer()
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+c.FullPath())
+		defer span.End()
+
+		span.SetAttributes(
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.url", c.Request.URL.String()),
+			attribute.String("http.route", c.FullPath()),
+		)
+
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		span.SetAttributes(attribute.Int("http.status_code", c.Writer.Status()))
+	}
+}
+
+// businessMetricsMiddleware updates posts-service's in-memory Metrics and
+// Prometheus collectors for every request - the request/error counts and
+// uptime gauge that used to live on package-level vars before the
+// handlers package existed.
+func businessMetricsMiddleware(metrics *handlers.Metrics, prom *handlers.PrometheusMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		duration := time.Since(start)
+		status := strconv.Itoa(c.Writer.Status())
+		failed := c.Writer.Status() >= 400
+
+		metrics.RecordRequest(duration, failed)
+
+		prom.HTTPRequestsTotal.WithLabelValues(
+			c.Request.Method, c.FullPath(), status, "posts-service", serviceVersion, instanceID,
+		).Inc()
+
+		prom.HTTPRequestDuration.WithLabelValues(
+			c.Request.Method, c.FullPath(), "posts-service", serviceVersion, instanceID,
+		).Observe(duration.Seconds())
+
+		if failed {
+			prom.ServiceErrorsTotal.WithLabelValues("posts-service", serviceVersion, instanceID, "http_error").Inc()
+		}
+
+		prom.ServiceUptimeSeconds.WithLabelValues("posts-service", serviceVersion, instanceID).Set(time.Since(metrics.StartTime()).Seconds())
+	}
+}